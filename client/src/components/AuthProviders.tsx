@@ -1,4 +1,4 @@
-import React, { useEffect } from 'react';
+import React, { useEffect, useState } from 'react';
 import {
   Box,
   Container,
@@ -14,6 +14,7 @@ import { useDispatch, useSelector } from 'react-redux';
 import { RootState, AppDispatch } from '../store';
 import { fetchProviders } from '../store/slices/authProviderSlice';
 import { AuthProvider } from '../types/api';
+import { publicAxios } from '../service/http-common';
 
 const AuthProviders: React.FC = () => {
   const theme = useTheme();
@@ -26,24 +27,21 @@ const AuthProviders: React.FC = () => {
     dispatch(fetchProviders());
   }, [dispatch]);
 
-  const handleProviderClick = (provider: AuthProvider) => {
-    // Создаем URL для авторизации с параметрами
-    const authUrl = new URL(provider.AuthURL);
-    authUrl.searchParams.append('client_id', provider.ClientId);
-    authUrl.searchParams.append('redirect_uri', provider.RedirectUri);
-    authUrl.searchParams.append('response_type', 'code');
-    
-    // Используем scopes из provider
-    const scope = getProviderScope(provider);
-    authUrl.searchParams.append('scope', scope);
-    authUrl.searchParams.append('state', provider.Provider);
-    
-    // Перенаправляем пользователя на страницу авторизации
-    window.location.href = authUrl.toString();
-  };
+  const [startError, setStartError] = useState<string | null>(null);
 
-  const getProviderScope = (provider: AuthProvider) => {
-    return provider.Scopes ? provider.Scopes.join(' ') : '';
+  const handleProviderClick = async (provider: AuthProvider) => {
+    // Адрес входа с state, nonce и PKCE готовит сервер; параметры он сохраняет в cookie
+    try {
+      setStartError(null);
+      const response = await publicAxios.get(`/user/login/${encodeURIComponent(provider.Provider)}/start`, {
+        withCredentials: true,
+      });
+      localStorage.setItem('authProvider', provider.Provider);
+      window.location.href = response.data.auth_url;
+    } catch (error) {
+      console.error('Error starting login:', error);
+      setStartError('Не удалось начать вход, попробуйте ещё раз');
+    }
   };
 
   const getProviderDisplayName = (providerName: string) => {
@@ -102,6 +100,11 @@ const AuthProviders: React.FC = () => {
 
   return (
     <Container maxWidth="sm" sx={{ py: 2 }}>
+      {startError && (
+        <Alert severity="error" sx={{ mb: 2 }}>
+          {startError}
+        </Alert>
+      )}
       <Stack spacing={2} sx={{ width: '100%' }}>
         {providers.map((provider) => (
           <Button
@@ -15,22 +15,27 @@ const AuthCallback: React.FC = () => {
   interface PostData {
     AuthorizationCode: string;
     ProviderKey: string;
+    State: string;
   }
 
   useEffect(() => {
     const handleAuthCallback = async () => {
       const queryParams = new URLSearchParams(location.search);
       const code = queryParams.get('code');
-      const provider = queryParams.get('provider') || queryParams.get('state');
+      const state = queryParams.get('state');
+      // Провайдера запоминает страница входа: state теперь случайный и провайдера не содержит
+      const provider = queryParams.get('provider') || localStorage.getItem('authProvider');
 
       if (code && intervalRef.current !== code) {
         intervalRef.current = code;
         
 
-        if (code && provider) {
+        if (code && provider && state) {
+          localStorage.removeItem('authProvider');
           const data: PostData = {
             AuthorizationCode: code,
             ProviderKey: provider,
+            State: state,
           };
 
           try {
@@ -279,12 +279,11 @@ const ExerciseCard: React.FC = () => {
       // Оставляем isCorrect = true для отображения сообщения об успехе
     }
     
-    // Отправляем статистику
+    // Отправляем ответ на проверку: попытку засчитывает сервер
     try {
-      await authAxios.post('/exercise_stat/update', {
+      await authAxios.post('/exercises/submit', {
         exercise_id: exercise.exercise.id,
-        attempts: 1,
-        success_attempts: result.correct ? 1 : 0,
+        code: userCode,
       });
       await fetchStat();
     } catch (error) {
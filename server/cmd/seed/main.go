@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/app"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+// Загружает общие категории и упражнения из internal/app/seed_data.json
+func main() {
+
+	err := godotenv.Load()
+	if err != nil {
+		fmt.Println("err godotenv")
+	}
+
+	ctx := context.Background()
+
+	dbConn, err := pgxpool.New(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		panic(err.Error())
+	}
+	defer dbConn.Close()
+
+	report, err := app.Seed(ctx, dbConn)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Println("seed:", report.String())
+	for _, skipped := range report.Skipped {
+		fmt.Println("skipped:", skipped)
+	}
+}
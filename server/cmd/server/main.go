@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"inzarubin80/MemCode/internal/app"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "inzarubin80/MemCode/server/docs"
 
@@ -17,14 +22,18 @@ import (
 // мое исправление
 func main() {
 
+	seed := flag.Bool("seed", false, "load common categories and exercises from seed_data.json before start")
+	flag.Parse()
+
 	err := godotenv.Load()
 	if err != nil {
 		fmt.Println("err godotenv")
 	}
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	options := app.Options{
-			Addr: ":8090",
+		Addr: ":8090",
 	}
 
 	conf := app.NewConfig(options)
@@ -41,6 +50,14 @@ func main() {
 		panic(err.Error())
 	}
 
+	if *seed {
+		report, err := app.Seed(ctx, dbConn)
+		if err != nil {
+			panic(err.Error())
+		}
+		fmt.Println("seed:", report.String())
+	}
+
 	server, err := app.NewApp(ctx, conf, dbConn)
 	if err != nil {
 		panic(err.Error())
@@ -49,9 +66,23 @@ func main() {
 	mux := http.NewServeMux()
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
+	// По сигналу останавливаем фоновые задачи и даём завершиться текущим запросам
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			fmt.Println("shutdown:", err.Error())
+		}
+	}()
+
 	err = server.ListenAndServe()
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		fmt.Println(err.Error())
+		return
 	}
+	<-shutdownDone
 
 }
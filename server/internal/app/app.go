@@ -2,15 +2,21 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	authinterface "inzarubin80/MemCode/internal/app/authinterface"
+	"inzarubin80/MemCode/internal/app/clients/oidc"
 	providerUserData "inzarubin80/MemCode/internal/app/clients/provider_user_data"
 	appHttp "inzarubin80/MemCode/internal/app/http"
 	middleware "inzarubin80/MemCode/internal/app/http/middleware"
 	tokenservice "inzarubin80/MemCode/internal/app/token_service"
+	"inzarubin80/MemCode/internal/bundle"
+	"inzarubin80/MemCode/internal/importer"
+	"inzarubin80/MemCode/internal/mailer"
 	"inzarubin80/MemCode/internal/model"
 	repository "inzarubin80/MemCode/internal/repository"
 	service "inzarubin80/MemCode/internal/service"
+	tp "inzarubin80/MemCode/internal/transaction_provider"
 	"net/http"
 	"time"
 
@@ -23,6 +29,8 @@ import (
 
 const (
 	readHeaderTimeoutSeconds = 3
+	// Самое большое допустимое тело запроса - архив для импорта упражнений с полями формы
+	maxRequestBodySize = importer.MaxArchiveSize + 1<<20
 )
 
 type (
@@ -31,41 +39,92 @@ type (
 	}
 	server interface {
 		ListenAndServe() error
+		Shutdown(ctx context.Context) error
 		Close() error
 	}
 
 	MemCodeService interface {
 		GetUser(ctx context.Context, userID model.UserID) (*model.User, error)
-		Login(ctx context.Context, providerKey string, authorizationCode string) (*model.AuthData, error)
+		StartLogin(ctx context.Context, providerKey string) (*model.AuthFlow, string, error)
+		Login(ctx context.Context, providerKey string, authorizationCode string, flow *model.AuthFlow) (*model.AuthData, error)
 		Authorization(context.Context, string) (*model.Claims, error)
 		RefreshToken(ctx context.Context, refreshToken string) (*model.AuthData, error)
 		SetUserName(ctx context.Context, userID model.UserID, name string) error
-		SetUserAdmin(ctx context.Context, userID model.UserID, isAdmin bool) (*model.User, error)
+		SetUserAdmin(ctx context.Context, grantedBy model.UserID, userID model.UserID, isAdmin bool) (*model.User, error)
+		GetRoles(ctx context.Context) ([]*model.RoleInfo, error)
+		GrantUserRole(ctx context.Context, grantedBy model.UserID, change *model.UserRoleChange) (*model.User, error)
+		RevokeUserRole(ctx context.Context, change *model.UserRoleChange) (*model.User, error)
+		GetUserSessions(ctx context.Context, userID model.UserID, currentToken string) ([]*model.Session, error)
+		DeleteUserSession(ctx context.Context, userID model.UserID, sessionID int64) error
+		LogoutEverywhere(ctx context.Context, userID model.UserID) error
+		GetUserLinkedProviders(ctx context.Context, userID model.UserID) ([]*model.LinkedProvider, error)
+		LinkProvider(ctx context.Context, userID model.UserID, providerKey string, authorizationCode string, flow *model.AuthFlow) ([]*model.LinkedProvider, error)
+		UnlinkProvider(ctx context.Context, userID model.UserID, provider string) ([]*model.LinkedProvider, error)
+
+		// Local auth methods
+		Register(ctx context.Context, request *model.RegisterRequest) error
+		LoginWithPassword(ctx context.Context, request *model.PasswordLoginRequest) (*model.AuthData, error)
+		VerifyEmail(ctx context.Context, token string) error
+		ResendVerificationEmail(ctx context.Context, email string) error
+		RequestPasswordReset(ctx context.Context, email string) error
+		ResetPassword(ctx context.Context, token string, password string) error
 
 		// Exercise methods
-		CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error)
+		CreateExercise(ctx context.Context, userID model.UserID, roles model.Roles, exercise *model.Exercise) (*model.Exercise, error)
 		GetExercise(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseDetailse, error)
-		UpdateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error)
-		DeleteExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64) error
-		GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) (*model.ExerciseListWithUserResponse, error)
-		UpsertExerciseStat(userID model.UserID, exerciseID int64, attempts int, successAttempts int) (*model.ExerciseStat, error)
+		UpdateExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error)
+		DeleteExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64) error
+		GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) (*model.ExerciseListWithUserResponse, error)
+		UpsertExerciseStat(ctx context.Context, userID model.UserID, update *model.ExerciseStatUpdate) (*model.ExerciseStat, error)
+		ImportExercises(ctx context.Context, userID model.UserID, roles model.Roles, request *model.ExerciseImportRequest) (*model.ImportResult, error)
+		ExportBundle(ctx context.Context, userID model.UserID, withStats bool, writer bundle.Writer) error
+		ImportBundle(ctx context.Context, userID model.UserID, data *model.Bundle, strategy string) (*model.BundleImportResult, error)
+		SubmitAttempt(ctx context.Context, userID model.UserID, submission *model.AttemptSubmission) (*model.AttemptResult, error)
+		GetExerciseAttempts(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) (*model.ExerciseAttemptListResponse, error)
+		GetExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) (*model.ExerciseRevisionListResponse, error)
+		DiffExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, from, to int) (*model.ExerciseRevisionDiff, error)
+		RestoreExerciseRevision(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, revision int) (*model.Exercise, error)
 
 		// Category methods
-		CreateCategory(ctx context.Context, userID model.UserID, isAdmin bool, category *model.Category) (*model.Category, error)
+		CreateCategory(ctx context.Context, userID model.UserID, roles model.Roles, category *model.Category) (*model.Category, error)
 		GetCategories(ctx context.Context, userID model.UserID) (model.CategoryListResponse, error)
 		GetCategory(ctx context.Context, userID model.UserID, categoryID int64) (*model.Category, error)
-		UpdateCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64, category *model.Category) (*model.Category, error)
-		DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error
+		UpdateCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64, category *model.Category) (*model.Category, error)
+		DeleteCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64, policy model.CategoryDeletePolicy) error
+		GetCategoryTree(ctx context.Context, userID model.UserID) ([]*model.CategoryTreeNode, error)
+
+		// Tag methods
+		GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error)
+		CreateTag(ctx context.Context, userID model.UserID, roles model.Roles, tag *model.Tag) (*model.Tag, error)
+		RenameTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64, name string) (*model.Tag, error)
+		DeleteTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64) error
+		MergeTags(ctx context.Context, userID model.UserID, roles model.Roles, merge *model.TagMerge) (*model.Tag, error)
+		AddExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error
+		RemoveExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error
+
+		// Trash methods
+		GetTrash(ctx context.Context, userID model.UserID, roles model.Roles) ([]*model.TrashItem, error)
+		RestoreExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64) error
+		RestoreCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64) error
+		PurgeTrash(ctx context.Context, retention time.Duration) error
 
 		// Добавлено для соответствия GetExerciseStatService
 		GetExerciseStat(userID model.UserID, exerciseID int64) (*model.ExerciseStat, error)
 		GetUserStats(ctx context.Context, userID model.UserID) (*model.UserStats, error)
+		GetUserActivity(ctx context.Context, userID model.UserID, from, to time.Time) (*model.UserActivity, error)
+		SetUserTimezone(ctx context.Context, userID model.UserID, timezone string) error
 
 		// User Exercises methods
-		GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page int, pageSize int) (*model.ExerciseListWithUserResponse, error)
+		GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page int, pageSize int) (*model.ExerciseListWithUserResponse, error)
 		AddUserExercise(ctx context.Context, userID model.UserID, exerciseID int64) error
 		RemoveUserExercise(ctx context.Context, userID model.UserID, exerciseID int64) error
+		GetDueExercises(ctx context.Context, userID model.UserID, page, pageSize int) (*model.ExerciseListWithUserResponse, error)
 		GetAllUsers(ctx context.Context) ([]*model.User, error)
+		CleanupExpiredTokens(ctx context.Context) error
+	}
+
+	jwksProvider interface {
+		JWKS() model.JSONWebKeySet
 	}
 
 	TokenService interface {
@@ -81,15 +140,18 @@ type (
 		oauthConfig                *oauth2.Config
 		store                      *sessions.CookieStore
 		providersOauthConfFrontend []authinterface.ProviderOauthConfFrontend
+		scheduler                  *Scheduler
+		jwks                       jwksProvider
 	}
 )
 
 func (a *App) ListenAndServe() error {
 	handlers := map[string]http.Handler{
-		a.config.path.getUser:      appHttp.NewGetUserHandler(a.store, a.config.path.getUser, a.pokerService),
-		a.config.path.ping:         appHttp.NewPingHandlerHandler(a.config.path.ping),
-		a.config.path.setUserName:  appHttp.NewSetUserNameHandler(a.pokerService, a.config.path.setUserName),
-		a.config.path.setUserAdmin: appHttp.NewSetUserAdminHandler(a.store, a.config.path.setUserAdmin, a.pokerService),
+		a.config.path.getUser:         appHttp.NewGetUserHandler(a.store, a.config.path.getUser, a.pokerService),
+		a.config.path.ping:            appHttp.NewPingHandlerHandler(a.config.path.ping),
+		a.config.path.setUserName:     appHttp.NewSetUserNameHandler(a.pokerService, a.config.path.setUserName),
+		a.config.path.setUserAdmin:    middleware.NewPermissionMiddleware(appHttp.NewSetUserAdminHandler(a.store, a.config.path.setUserAdmin, a.pokerService), model.PermissionManageRoles),
+		a.config.path.setUserTimezone: appHttp.NewSetUserTimezoneHandler(a.pokerService, a.config.path.setUserTimezone),
 
 		// Exercise handlers
 		a.config.path.getExercises:       appHttp.NewGetExercisesHandler(a.pokerService, "getExercises"),
@@ -99,40 +161,101 @@ func (a *App) ListenAndServe() error {
 		a.config.path.deleteExercise:     appHttp.NewDeleteExerciseHandler(a.pokerService, "delete_exercise"),
 		a.config.path.updateExerciseStat: appHttp.NewUpdateExerciseStatHandler(a.pokerService),
 		a.config.path.getExerciseStat:    appHttp.NewGetExerciseStatHandler(a.pokerService),
+		a.config.path.getExerciseHistory: appHttp.NewGetExerciseAttemptsHandler(a.pokerService, "get_exercise_attempts"),
+		a.config.path.submitAttempt:      appHttp.NewSubmitAttemptHandler(a.pokerService, "submit_attempt"),
+		a.config.path.importExercises:    appHttp.NewImportExercisesHandler(a.pokerService, "import_exercises"),
+
+		// Exercise revision handlers
+		a.config.path.getExerciseRevisions:    appHttp.NewGetExerciseRevisionsHandler(a.pokerService, "get_exercise_revisions"),
+		a.config.path.diffExerciseRevisions:   appHttp.NewDiffExerciseRevisionsHandler(a.pokerService, "diff_exercise_revisions"),
+		a.config.path.restoreExerciseRevision: appHttp.NewRestoreExerciseRevisionHandler(a.pokerService, "restore_exercise_revision"),
 
 		// Category handlers
-		a.config.path.getCategories:  appHttp.NewGetCategoriesHandler(a.pokerService, "get_categories"),
-		a.config.path.createCategory: appHttp.NewCreateCategoryHandler(a.pokerService, "create_category"),
-		a.config.path.getCategory:    appHttp.NewGetCategoryHandler(a.pokerService, "get_category"),
-		a.config.path.updateCategory: appHttp.NewUpdateCategoryHandler(a.pokerService, "update_category"),
-		a.config.path.deleteCategory: appHttp.NewDeleteCategoryHandler(a.pokerService, "delete_category"),
+		a.config.path.getCategories:   appHttp.NewGetCategoriesHandler(a.pokerService, "get_categories"),
+		a.config.path.createCategory:  appHttp.NewCreateCategoryHandler(a.pokerService, "create_category"),
+		a.config.path.getCategory:     appHttp.NewGetCategoryHandler(a.pokerService, "get_category"),
+		a.config.path.updateCategory:  appHttp.NewUpdateCategoryHandler(a.pokerService, "update_category"),
+		a.config.path.deleteCategory:  appHttp.NewDeleteCategoryHandler(a.pokerService, "delete_category"),
+		a.config.path.getCategoryTree: appHttp.NewGetCategoryTreeHandler(a.pokerService, "get_category_tree"),
+
+		// Tag handlers
+		a.config.path.getTags:           appHttp.NewGetTagsHandler(a.pokerService, "get_tags"),
+		a.config.path.createTag:         appHttp.NewCreateTagHandler(a.pokerService, "create_tag"),
+		a.config.path.renameTag:         appHttp.NewRenameTagHandler(a.pokerService, "rename_tag"),
+		a.config.path.deleteTag:         appHttp.NewDeleteTagHandler(a.pokerService, "delete_tag"),
+		a.config.path.mergeTags:         appHttp.NewMergeTagsHandler(a.pokerService, "merge_tags"),
+		a.config.path.addExerciseTag:    appHttp.NewAddExerciseTagHandler(a.pokerService, "add_exercise_tag"),
+		a.config.path.removeExerciseTag: appHttp.NewRemoveExerciseTagHandler(a.pokerService, "remove_exercise_tag"),
+
+		a.config.path.getTrash:                 appHttp.NewGetTrashHandler(a.pokerService, "get_trash"),
+		a.config.path.restoreExerciseFromTrash: appHttp.NewRestoreExerciseFromTrashHandler(a.pokerService, "restore_exercise_from_trash"),
+		a.config.path.restoreCategoryFromTrash: appHttp.NewRestoreCategoryFromTrashHandler(a.pokerService, "restore_category_from_trash"),
 
 		// New handler for getUserStats
-		a.config.path.getUserStats: appHttp.NewGetUserStatsHandler(a.pokerService),
-		a.config.path.getAllUsers:  appHttp.NewGetAllUsersHandler(a.store, a.config.path.getAllUsers, a.pokerService),
+		a.config.path.getUserStats:    appHttp.NewGetUserStatsHandler(a.pokerService),
+		a.config.path.getUserActivity: appHttp.NewGetUserActivityHandler(a.pokerService, "get_user_activity"),
+		a.config.path.getAllUsers:     middleware.NewPermissionMiddleware(appHttp.NewGetAllUsersHandler(a.store, a.config.path.getAllUsers, a.pokerService), model.PermissionViewUsers),
+
+		// Role handlers
+		a.config.path.getRoles:       middleware.NewPermissionMiddleware(appHttp.NewGetRolesHandler(a.pokerService, "get_roles"), model.PermissionManageRoles),
+		a.config.path.grantUserRole:  middleware.NewPermissionMiddleware(appHttp.NewGrantUserRoleHandler(a.pokerService, "grant_user_role"), model.PermissionManageRoles),
+		a.config.path.revokeUserRole: middleware.NewPermissionMiddleware(appHttp.NewRevokeUserRoleHandler(a.pokerService, "revoke_user_role"), model.PermissionManageRoles),
+
+		// Session handlers
+		a.config.path.getUserSessions:   appHttp.NewGetUserSessionsHandler(a.pokerService, "get_user_sessions", a.store),
+		a.config.path.deleteUserSession: appHttp.NewDeleteUserSessionHandler(a.pokerService, "delete_user_session"),
+		a.config.path.logoutEverywhere:  appHttp.NewLogoutEverywhereHandler(a.pokerService, "logout_everywhere", a.store),
+
+		// Linked provider handlers
+		a.config.path.getUserProviders: appHttp.NewGetUserProvidersHandler(a.pokerService, "get_user_providers"),
+		a.config.path.linkProvider:     appHttp.NewLinkProviderHandler(a.pokerService, "link_provider", a.store),
+		a.config.path.unlinkProvider:   appHttp.NewUnlinkProviderHandler(a.pokerService, "unlink_provider"),
+
+		// Export handlers
+		a.config.path.exportBundle: appHttp.NewExportBundleHandler(a.pokerService, "export_bundle"),
+		a.config.path.importBundle: appHttp.NewImportBundleHandler(a.pokerService, "import_bundle"),
 
 		// User Exercises handler
 		a.config.path.getUserExercises:   appHttp.NewGetUserExercisesHandler(a.pokerService, "get_user_exercises"),
 		a.config.path.addUserExercise:    appHttp.NewAddUserExerciseHandler(a.pokerService, "add_user_exercise"),
 		a.config.path.removeUserExercise: appHttp.NewRemoveUserExerciseHandler(a.pokerService, "remove_user_exercise"),
+		a.config.path.getDueExercises:    appHttp.NewGetDueExercisesHandler(a.pokerService, "get_due_exercises"),
 	}
 
 	for path, handler := range handlers {
 		a.mux.Handle(path, middleware.NewAuthMiddleware(handler, a.store, a.pokerService))
 	}
 
-	a.mux.Handle(a.config.path.login, appHttp.NewLoginHandler(a.pokerService, a.config.path.login, a.store))
-	a.mux.Handle(a.config.path.refreshToken, appHttp.NewRefreshTokenHandler(a.pokerService, a.config.path.refreshToken, a.store))
+	a.mux.Handle(a.config.path.startLogin, appHttp.NewStartLoginHandler(a.pokerService, "start_login", a.store))
+	a.mux.Handle(a.config.path.login, middleware.NewClientMiddleware(appHttp.NewLoginHandler(a.pokerService, a.config.path.login, a.store), a.config.trustedProxies))
+	a.mux.Handle(a.config.path.refreshToken, middleware.NewClientMiddleware(appHttp.NewRefreshTokenHandler(a.pokerService, a.config.path.refreshToken, a.store), a.config.trustedProxies))
 	a.mux.Handle(a.config.path.getProviders, appHttp.NewProvadersHandler(a.providersOauthConfFrontend, a.config.path.refreshToken))
 	a.mux.Handle(a.config.path.logOut, appHttp.NewLogOutHandlerHandler(a.pokerService, a.config.path.logOut, a.store))
+	a.mux.Handle(a.config.path.getJWKS, appHttp.NewGetJWKSHandler(a.jwks, "get_jwks"))
+
+	// Вход по email и паролю (без авторизации)
+	a.mux.Handle(a.config.path.register, appHttp.NewRegisterHandler(a.pokerService, "register"))
+	a.mux.Handle(a.config.path.passwordLogin, middleware.NewClientMiddleware(appHttp.NewPasswordLoginHandler(a.pokerService, "password_login", a.store), a.config.trustedProxies))
+	a.mux.Handle(a.config.path.verifyEmail, appHttp.NewVerifyEmailHandler(a.pokerService, "verify_email"))
+	a.mux.Handle(a.config.path.resendVerificationEmail, appHttp.NewResendVerificationEmailHandler(a.pokerService, "resend_verification_email"))
+	a.mux.Handle(a.config.path.forgotPassword, appHttp.NewForgotPasswordHandler(a.pokerService, "forgot_password"))
+	a.mux.Handle(a.config.path.resetPassword, appHttp.NewResetPasswordHandler(a.pokerService, "reset_password"))
 
 	// Languages handler (без авторизации)
 	a.mux.Handle(a.config.path.getLanguages, appHttp.NewGetLanguagesHandler("get_languages"))
 
+	a.scheduler.Start(context.Background())
+
 	fmt.Println("start server")
 	return a.server.ListenAndServe()
 }
 
+// Shutdown останавливает фоновые задачи и HTTP-сервер, дожидаясь завершения текущих запросов
+func (a *App) Shutdown(ctx context.Context) error {
+	a.scheduler.Stop()
+	return a.server.Shutdown(ctx)
+}
+
 func NewApp(ctx context.Context, config config, dbConn *pgxpool.Pool) (*App, error) {
 
 	var (
@@ -144,14 +267,42 @@ func NewApp(ctx context.Context, config config, dbConn *pgxpool.Pool) (*App, err
 	// Swagger UI
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
-	accessTokenService := tokenservice.NewtokenService([]byte(config.sectrets.accessTokenSecret), 30*time.Minute, model.Access_Token_Type)
+	// Access-токены могут проверять другие сервисы, поэтому при заданном каталоге ключей они подписываются асимметрично.
+	// Refresh-токены проверяем только мы, для них остаётся HS256.
+	var keys *tokenservice.KeySet
+	if config.sectrets.jwtKeysDir != "" {
+		var err error
+		keys, err = tokenservice.LoadKeySet(config.sectrets.jwtKeysDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	accessTokenService := tokenservice.NewKeySetTokenService(keys, []byte(config.sectrets.accessTokenSecret), config.sectrets.acceptHS256Until, 30*time.Minute, model.Access_Token_Type)
 	refreshTokenService := tokenservice.NewtokenService([]byte(config.sectrets.refreshTokenSecret), 24*time.Hour, model.Refresh_Token_Type)
 
+	provadersConf := make(authinterface.MapProviderOauthConf, len(config.provadersConf))
+	for key, value := range config.provadersConf {
+		provadersConf[key] = value
+	}
+	if config.providersFile != "" {
+		fileProvaders, err := loadProvidersFile(config.providersFile, config.appRoot)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileProvaders {
+			provadersConf[key] = value
+		}
+	}
+
 	providerOauthConfFrontend := []authinterface.ProviderOauthConfFrontend{}
 	providers := make(authinterface.ProvidersUserData)
-	for key, value := range config.provadersConf {
+	for key, value := range provadersConf {
 
-		providers[key] = providerUserData.NewProviderUserData(value.UrlUserData, value.Oauth2Config, key)
+		if value.Issuer != "" {
+			providers[key] = oidc.NewProvider(key, value.Issuer, value.Oauth2Config.ClientID, value.Oauth2Config.ClientSecret, value.Oauth2Config.RedirectURL, value.Oauth2Config.Scopes)
+		} else {
+			providers[key] = providerUserData.NewProviderUserData(value.UrlUserData, value.Oauth2Config, key)
+		}
 
 		providerOauthConfFrontend = append(providerOauthConfFrontend,
 			authinterface.ProviderOauthConfFrontend{
@@ -161,11 +312,21 @@ func NewApp(ctx context.Context, config config, dbConn *pgxpool.Pool) (*App, err
 				AuthURL:     value.Oauth2Config.Endpoint.AuthURL,
 				IconSVG:     value.IconSVG,
 				Scopes:      value.Oauth2Config.Scopes,
+				StartURL:    "/api/user/login/" + key + "/start",
 			},
 		)
 	}
 
-	pokerService := service.NewPokerService(pokerRepository, accessTokenService, refreshTokenService, providers)
+	// Без почтальона приложение запускается только со входом через провайдеров
+	mailSender, err := mailer.New(config.mail.mailer, config.mail.dir, config.mail.devMode)
+	if errors.Is(err, mailer.ErrNotConfigured) {
+		fmt.Println("warning:", err.Error()+", registration and password reset are disabled")
+	} else if err != nil {
+		return nil, err
+	}
+
+	transactionProvider := tp.NewTransactionProvider(dbConn)
+	pokerService := service.NewPokerService(pokerRepository, accessTokenService, refreshTokenService, providers, transactionProvider, mailSender, config.mail.publicURL)
 
 	// Создаем CORS middleware
 	corsMiddleware := cors.New(cors.Options{
@@ -191,8 +352,23 @@ func NewApp(ctx context.Context, config config, dbConn *pgxpool.Pool) (*App, err
 		Debug: true,
 	})
 
-	// Обертываем основной обработчик
-	handler := corsMiddleware.Handler(middleware.NewLogMux(mux))
+	// Обертываем основной обработчик; размер тела ограничивается до любого другого middleware
+	handler := corsMiddleware.Handler(middleware.NewBodyLimitMiddleware(middleware.NewLogMux(mux), maxRequestBodySize))
+
+	// Фоновые задачи; новые регистрируются здесь же
+	scheduler := NewScheduler(dbConn)
+	scheduler.Register(Task{
+		Name:     "cleanup_expired_tokens",
+		Interval: config.jobs.tokenCleanupInterval,
+		Run:      pokerService.CleanupExpiredTokens,
+	})
+	scheduler.Register(Task{
+		Name:     "purge_trash",
+		Interval: config.jobs.trashPurgeInterval,
+		Run: func(ctx context.Context) error {
+			return pokerService.PurgeTrash(ctx, config.jobs.trashRetention)
+		},
+	})
 
 	return &App{
 		mux:                        mux,
@@ -201,6 +377,8 @@ func NewApp(ctx context.Context, config config, dbConn *pgxpool.Pool) (*App, err
 		config:                     config,
 		store:                      store,
 		providersOauthConfFrontend: providerOauthConfFrontend,
+		scheduler:                  scheduler,
+		jwks:                       accessTokenService,
 	}, nil
 
 }
@@ -9,12 +9,15 @@ import (
 
 type (
 	TokenService interface {
-		GenerateToken(userID model.UserID, isAdmin bool) (string, error)
+		GenerateToken(userID model.UserID, roles model.Roles) (string, error)
 		ValidateToken(tokenString string) (*model.Claims, error)
 	}
 
 	ProviderUserData interface {
-		GetUserData(ctx context.Context, authorizationCode string) (*model.UserProfileFromProvider, error)
+		// AuthCodeURL возвращает адрес страницы входа провайдера для начатого сервером входа
+		AuthCodeURL(ctx context.Context, flow *model.AuthFlow) (string, error)
+		// GetUserData обменивает код авторизации на профиль; flow равен nil, если вход начат фронтендом
+		GetUserData(ctx context.Context, authorizationCode string, flow *model.AuthFlow) (*model.UserProfileFromProvider, error)
 	}
 
 	ProvidersUserData map[string]ProviderUserData
@@ -23,6 +26,8 @@ type (
 		Oauth2Config *oauth2.Config
 		UrlUserData  string
 		IconSVG      string
+		// Issuer задан у OpenID Connect провайдеров: адреса берутся из discovery, Endpoint не нужен
+		Issuer string
 	}
 
 	MapProviderOauthConf map[string]*ProviderOauthConf
@@ -34,5 +39,7 @@ type (
 		RedirectUri string
 		IconSVG     string
 		Scopes      []string
+		// Адрес API, с которого начинается вход с state, nonce и PKCE
+		StartURL string
 	}
 )
@@ -0,0 +1,325 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/oauth2"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// Допустимое расхождение часов с провайдером при проверке exp и iat
+	clockSkew = time.Minute
+	// Ключи по неизвестному kid перезапрашиваются не чаще этого интервала
+	keysRefreshInterval = time.Minute
+	httpTimeout         = 10 * time.Second
+)
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type (
+	// Provider - вход через произвольный OpenID Connect провайдер (Keycloak, GitLab и т.п.).
+	// Адреса авторизации, обмена кода и ключей берутся из discovery-документа issuer
+	// при первом обращении, данные пользователя - из проверенного ID-токена.
+	Provider struct {
+		name   string
+		issuer string
+		client *http.Client
+
+		mu            sync.Mutex
+		oauthConfig   oauth2.Config
+		discovery     *discoveryDocument
+		keys          map[string]crypto.PublicKey
+		keysFetchedAt time.Time
+	}
+
+	discoveryDocument struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+
+	idTokenClaims struct {
+		Subject           string
+		Email             string
+		Name              string
+		GivenName         string
+		FamilyName        string
+		PreferredUsername string
+		Picture           string
+	}
+)
+
+// NewProvider создаёт провайдера; scope openid добавляется, если его нет в списке
+func NewProvider(name string, issuer string, clientID string, clientSecret string, redirectURL string, scopes []string) *Provider {
+	hasOpenID := false
+	for _, scope := range scopes {
+		if scope == "openid" {
+			hasOpenID = true
+		}
+	}
+	if !hasOpenID {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	return &Provider{
+		name:   name,
+		issuer: issuer,
+		client: &http.Client{Timeout: httpTimeout},
+		oauthConfig: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		},
+	}
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера с state, nonce и PKCE challenge
+func (p *Provider) AuthCodeURL(ctx context.Context, flow *model.AuthFlow) (string, error) {
+	config, err := p.config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(flow.State,
+		oauth2.S256ChallengeOption(flow.CodeVerifier),
+		oauth2.SetAuthURLParam("nonce", flow.Nonce),
+	), nil
+}
+
+// GetUserData обменивает код на токены и возвращает профиль из ID-токена.
+// Вход должен быть начат через AuthCodeURL: без nonce и PKCE verifier код не принимается.
+func (p *Provider) GetUserData(ctx context.Context, authorizationCode string, flow *model.AuthFlow) (*model.UserProfileFromProvider, error) {
+	if flow == nil || flow.Nonce == "" || flow.CodeVerifier == "" {
+		return nil, fmt.Errorf("%w: login with %s must be started by the server", model.ErrInvalidParameter, p.name)
+	}
+
+	config, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), authorizationCode, oauth2.VerifierOption(flow.CodeVerifier))
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("%s: token response has no id_token", p.name)
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = strings.TrimSpace(claims.GivenName + " " + claims.FamilyName)
+	}
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+
+	return &model.UserProfileFromProvider{
+		ProviderID:   claims.Subject,
+		Email:        claims.Email,
+		Name:         name,
+		FirstName:    claims.GivenName,
+		LastName:     claims.FamilyName,
+		AvatarURL:    claims.Picture,
+		ProviderName: p.name,
+	}, nil
+}
+
+// verifyIDToken проверяет подпись, issuer, audience, срок действия и nonce ID-токена
+func (p *Provider) verifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*idTokenClaims, error) {
+	parser := jwt.Parser{
+		ValidMethods: signingMethods,
+		// exp и iat проверяем ниже с допуском на расхождение часов
+		SkipClaimsValidation: true,
+	}
+
+	mapClaims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawIDToken, mapClaims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid id_token: %w", p.name, err)
+	}
+
+	if iss, _ := mapClaims["iss"].(string); iss != p.issuer {
+		return nil, fmt.Errorf("%s: id_token issuer %q does not match %q", p.name, iss, p.issuer)
+	}
+
+	audience := stringList(mapClaims["aud"])
+	if !contains(audience, p.oauthConfig.ClientID) {
+		return nil, fmt.Errorf("%s: id_token is not issued for this client", p.name)
+	}
+	if azp, ok := mapClaims["azp"].(string); ok && azp != p.oauthConfig.ClientID {
+		return nil, fmt.Errorf("%s: id_token is issued for another party", p.name)
+	}
+
+	now := time.Now()
+	exp, ok := mapClaims["exp"].(float64)
+	if !ok || now.Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("%s: id_token is expired", p.name)
+	}
+	if iat, ok := mapClaims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("%s: id_token is issued in the future", p.name)
+	}
+
+	if tokenNonce, _ := mapClaims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("%s: id_token nonce mismatch", p.name)
+	}
+
+	claims := &idTokenClaims{}
+	claims.Subject, _ = mapClaims["sub"].(string)
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%s: id_token has no subject", p.name)
+	}
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.Name, _ = mapClaims["name"].(string)
+	claims.GivenName, _ = mapClaims["given_name"].(string)
+	claims.FamilyName, _ = mapClaims["family_name"].(string)
+	claims.PreferredUsername, _ = mapClaims["preferred_username"].(string)
+	claims.Picture, _ = mapClaims["picture"].(string)
+	return claims, nil
+}
+
+// config возвращает настройки OAuth2 с адресами из discovery-документа.
+// Документ запрашивается при первом обращении, поэтому недоступный провайдер не мешает запуску сервера.
+func (p *Provider) config(ctx context.Context) (*oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery == nil {
+		var document discoveryDocument
+		if err := p.getJSON(ctx, strings.TrimSuffix(p.issuer, "/")+discoveryPath, &document); err != nil {
+			return nil, fmt.Errorf("%s: discovery: %w", p.name, err)
+		}
+		if document.Issuer != p.issuer {
+			return nil, fmt.Errorf("%s: discovery issuer %q does not match %q", p.name, document.Issuer, p.issuer)
+		}
+		if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+			return nil, fmt.Errorf("%s: discovery document is incomplete", p.name)
+		}
+		p.discovery = &document
+		p.oauthConfig.Endpoint = oauth2.Endpoint{
+			AuthURL:   document.AuthorizationEndpoint,
+			TokenURL:  document.TokenEndpoint,
+			AuthStyle: oauth2.AuthStyleAutoDetect,
+		}
+	}
+
+	config := p.oauthConfig
+	return &config, nil
+}
+
+// publicKey возвращает ключ проверки подписи по kid; при неизвестном kid набор ключей
+// запрашивается заново, так как провайдер мог сменить ключ
+func (p *Provider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.cachedKey(kid); key != nil {
+		return key, nil
+	}
+	if p.discovery == nil {
+		return nil, errors.New("discovery document is not loaded")
+	}
+	if time.Since(p.keysFetchedAt) < keysRefreshInterval && p.keys != nil {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	var keySet model.JSONWebKeySet
+	if err := p.getJSON(ctx, p.discovery.JWKSURI, &keySet); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := publicKey(jwk)
+		if err != nil {
+			// Ключи неподдерживаемых типов пропускаем, остальные остаются пригодны
+			fmt.Println(p.name, "jwks:", err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := p.cachedKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// cachedKey ищет ключ по kid; токен без kid допустим, только если у провайдера один ключ
+func (p *Provider) cachedKey(kid string) crypto.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", url, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// stringList приводит claim aud к списку: по спецификации это строка или массив строк
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
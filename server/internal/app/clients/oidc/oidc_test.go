@@ -0,0 +1,382 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/model"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testClientID = "memcode"
+	testKeyID    = "key-1"
+)
+
+// mockProvider - OpenID Connect провайдер на httptest: discovery, JWKS и обмен кода с проверкой PKCE
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu sync.Mutex
+	// Выданные коды: code -> параметры, запомненные на странице входа
+	codes         map[string]authorization
+	discoveryHits int
+	jwksHits      int
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{t: t, key: key, codes: map[string]authorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.discoveryHits++
+		m.mu.Unlock()
+		writeJSON(w, discoveryDocument{
+			Issuer:                m.issuer(),
+			AuthorizationEndpoint: m.issuer() + "/authorize",
+			TokenEndpoint:         m.issuer() + "/token",
+			JWKSURI:               m.issuer() + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.jwksHits++
+		m.mu.Unlock()
+		writeJSON(w, model.JSONWebKeySet{Keys: []model.JSONWebKey{{
+			Kty: "RSA",
+			Kid: testKeyID,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", m.token)
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) issuer() string {
+	return m.server.URL
+}
+
+func (m *mockProvider) provider() *Provider {
+	return NewProvider("mock", m.issuer(), testClientID, "secret", "http://localhost/callback", []string{"email"})
+}
+
+// authorize имитирует страницу входа: запоминает PKCE challenge и nonce из адреса и выдаёт код
+func (m *mockProvider) authorize(authURL string) (code string, state string) {
+	m.t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		m.t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code = "code-" + query.Get("state")
+	m.codes[code] = authorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	return code, query.Get("state")
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		http.Error(w, `{"error":"invalid_grant","error_description":"PKCE verification failed"}`, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     m.sign(m.claims(auth.nonce), m.key),
+	})
+}
+
+func (m *mockProvider) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":         m.issuer(),
+		"aud":         testClientID,
+		"sub":         "user-42",
+		"email":       "user@example.com",
+		"given_name":  "Ada",
+		"family_name": "Lovelace",
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Hour).Unix(),
+		"nonce":       nonce,
+	}
+}
+
+func (m *mockProvider) sign(claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	m.t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func testFlow() *model.AuthFlow {
+	return &model.AuthFlow{
+		Provider:     "mock",
+		State:        "state-123",
+		Nonce:        "nonce-456",
+		CodeVerifier: "verifier-0123456789-0123456789-0123456789-0123",
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), testFlow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, mock.issuer()+"/authorize?") {
+		t.Fatalf("auth url %q does not use discovered authorization endpoint", authURL)
+	}
+	query := mustParseQuery(t, authURL)
+	if query.Get("client_id") != testClientID || query.Get("nonce") != "nonce-456" {
+		t.Fatalf("unexpected auth url parameters: %v", query)
+	}
+	if !strings.Contains(query.Get("scope"), "openid") {
+		t.Fatalf("scope %q has no openid", query.Get("scope"))
+	}
+
+	// Документ запрашивается один раз
+	if _, err := provider.AuthCodeURL(context.Background(), testFlow()); err != nil {
+		t.Fatal(err)
+	}
+	if mock.discoveryHits != 1 {
+		t.Fatalf("discovery requested %d times, want 1", mock.discoveryHits)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := NewProvider("mock", mock.issuer()+"/other", testClientID, "secret", "http://localhost/callback", nil)
+
+	if _, err := provider.AuthCodeURL(context.Background(), testFlow()); err == nil {
+		t.Fatal("expected error for discovery served from another issuer")
+	}
+}
+
+func TestJWKSFetch(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+	ctx := context.Background()
+	if _, err := provider.config(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := provider.verifyIDToken(ctx, mock.sign(mock.claims("n"), mock.key), "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-42" || claims.Email != "user@example.com" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	// Известный kid берётся из кэша
+	if _, err := provider.verifyIDToken(ctx, mock.sign(mock.claims("n"), mock.key), "n"); err != nil {
+		t.Fatal(err)
+	}
+	if mock.jwksHits != 1 {
+		t.Fatalf("jwks requested %d times, want 1", mock.jwksHits)
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+	ctx := context.Background()
+	if _, err := provider.config(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  func() string
+		nonce  string
+		errMsg string
+	}{
+		{
+			name:   "bad signature",
+			token:  func() string { return mock.sign(mock.claims("n"), otherKey) },
+			nonce:  "n",
+			errMsg: "invalid id_token",
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := mock.claims("n")
+				claims["iss"] = "https://evil.example.com"
+				return mock.sign(claims, mock.key)
+			},
+			nonce:  "n",
+			errMsg: "issuer",
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := mock.claims("n")
+				claims["aud"] = []string{"another-client"}
+				return mock.sign(claims, mock.key)
+			},
+			nonce:  "n",
+			errMsg: "not issued for this client",
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := mock.claims("n")
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return mock.sign(claims, mock.key)
+			},
+			nonce:  "n",
+			errMsg: "expired",
+		},
+		{
+			name:   "nonce mismatch",
+			token:  func() string { return mock.sign(mock.claims("n"), mock.key) },
+			nonce:  "another",
+			errMsg: "nonce mismatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.verifyIDToken(ctx, tt.token(), tt.nonce)
+			if err == nil {
+				t.Fatal("expected id_token to be rejected")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("error %q does not mention %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestLoginRoundTrip(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+	ctx := context.Background()
+	flow := testFlow()
+
+	authURL, err := provider.AuthCodeURL(ctx, flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := mock.authorize(authURL)
+	if state != flow.State {
+		t.Fatalf("state %q was not passed to provider, got %q", flow.State, state)
+	}
+
+	profile, err := provider.GetUserData(ctx, code, flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ProviderID != "user-42" || profile.Name != "Ada Lovelace" || profile.ProviderName != "mock" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+}
+
+func TestLoginRejectsWrongVerifier(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, testFlow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := mock.authorize(authURL)
+
+	// Код, перехваченный без verifier исходного входа, не обменивается
+	stolen := testFlow()
+	stolen.CodeVerifier = "another-verifier-0123456789-0123456789-01234"
+	if _, err := provider.GetUserData(ctx, code, stolen); err == nil {
+		t.Fatal("expected code exchange to fail with another PKCE verifier")
+	}
+}
+
+func TestLoginRequiresServerStartedFlow(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+
+	for _, flow := range []*model.AuthFlow{nil, {Provider: "mock", State: "s"}} {
+		_, err := provider.GetUserData(context.Background(), "code", flow)
+		if !errors.Is(err, model.ErrInvalidParameter) {
+			t.Fatalf("GetUserData(%+v) error = %v, want ErrInvalidParameter", flow, err)
+		}
+	}
+}
+
+func mustParseQuery(t *testing.T, rawURL string) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Query()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"inzarubin80/MemCode/internal/model"
+	"net/http"
 
 	"golang.org/x/oauth2"
 )
@@ -23,22 +24,26 @@ func NewProviderUserData(url string, oauthConfig *oauth2.Config, provider string
 	}
 }
 
-func (p *ProviderUserData) GetUserData(ctx context.Context, authorizationCode string) (*model.UserProfileFromProvider, error) {
+func (p *ProviderUserData) AuthCodeURL(ctx context.Context, flow *model.AuthFlow) (string, error) {
+	return p.oauthConfig.AuthCodeURL(flow.State, oauth2.S256ChallengeOption(flow.CodeVerifier)), nil
+}
 
-	token, err := p.oauthConfig.Exchange(context.Background(), authorizationCode)
-	if err != nil {
-		return nil, err
+// GetUserData обменивает код на токен и запрашивает профиль.
+// Вход должен быть начат через AuthCodeURL: без PKCE verifier код не принимается.
+func (p *ProviderUserData) GetUserData(ctx context.Context, authorizationCode string, flow *model.AuthFlow) (*model.UserProfileFromProvider, error) {
+	if flow == nil || flow.CodeVerifier == "" {
+		return nil, fmt.Errorf("%w: login with %s must be started by the server", model.ErrInvalidParameter, p.provider)
 	}
 
-	client := p.oauthConfig.Client(context.Background(), token)
-	response, err := client.Get(p.url)
+	token, err := p.oauthConfig.Exchange(context.Background(), authorizationCode, oauth2.VerifierOption(flow.CodeVerifier))
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
+
+	client := p.oauthConfig.Client(ctx, token)
 
 	var profile map[string]interface{}
-	if err := json.NewDecoder(response.Body).Decode(&profile); err != nil {
+	if err := getJSON(ctx, client, p.url, &profile); err != nil {
 		return nil, err
 	}
 
@@ -49,12 +54,77 @@ func (p *ProviderUserData) GetUserData(ctx context.Context, authorizationCode st
 	case "google":
 		return p.parseGoogleProfile(profile)
 	case "github":
-		return p.parseGitHubProfile(profile)
+		userData, err := p.parseGitHubProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		// В профиле GitHub email пустой, если пользователь его скрыл; основной адрес есть в /user/emails.
+		// Если доступ к адресам не дан (scope user:email отклонён), остаётся email из профиля.
+		email, err := p.getGitHubPrimaryEmail(ctx, client)
+		if err != nil {
+			fmt.Println("github: primary email lookup failed:", err.Error())
+		} else if email != "" {
+			userData.Email = email
+		}
+		return userData, nil
 	default:
 		return p.parseDefaultProfile(profile)
 	}
 }
 
+// getGitHubPrimaryEmail возвращает основной подтверждённый email или пустую строку, если такого нет
+func (p *ProviderUserData) getGitHubPrimaryEmail(ctx context.Context, client *http.Client) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, p.url+"/emails", &emails); err != nil {
+		return "", err
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			return email.Email, nil
+		}
+	}
+	return "", nil
+}
+
+// getJSON запрашивает данные пользователя; числа сохраняются как json.Number, чтобы не терять точность идентификаторов
+func getJSON(ctx context.Context, client *http.Client, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", url, response.Status)
+	}
+
+	decoder := json.NewDecoder(response.Body)
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+// stringValue читает идентификатор, который провайдер может вернуть строкой или числом
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
 func (p *ProviderUserData) parseYandexProfile(profile map[string]interface{}) (*model.UserProfileFromProvider, error) {
 	displayName, _ := profile["real_name"].(string)
 	providerID, _ := profile["id"].(string)
@@ -108,9 +178,14 @@ func (p *ProviderUserData) parseGoogleProfile(profile map[string]interface{}) (*
 func (p *ProviderUserData) parseGitHubProfile(profile map[string]interface{}) (*model.UserProfileFromProvider, error) {
 	// GitHub API возвращает данные в своем формате
 	displayName, _ := profile["name"].(string)
-	providerID, _ := profile["id"].(float64) // GitHub возвращает ID как число
+	providerID := stringValue(profile["id"]) // GitHub возвращает ID как число
 	email, _ := profile["email"].(string)
 	login, _ := profile["login"].(string)
+	avatarURL, _ := profile["avatar_url"].(string)
+
+	if providerID == "" {
+		return nil, fmt.Errorf("github profile has no id")
+	}
 
 	// Если displayName пустой, используем login
 	if displayName == "" {
@@ -119,11 +194,12 @@ func (p *ProviderUserData) parseGitHubProfile(profile map[string]interface{}) (*
 
 	userData := &model.UserProfileFromProvider{
 		Name:         displayName,
-		ProviderID:   fmt.Sprintf("%.0f", providerID), // Конвертируем в строку
+		ProviderID:   providerID,
 		ProviderName: p.provider,
 		Email:        email,
 		FirstName:    displayName, // GitHub не предоставляет отдельно имя и фамилию
 		LastName:     "",
+		AvatarURL:    avatarURL,
 	}
 
 	return userData, nil
@@ -132,7 +208,7 @@ func (p *ProviderUserData) parseGitHubProfile(profile map[string]interface{}) (*
 func (p *ProviderUserData) parseDefaultProfile(profile map[string]interface{}) (*model.UserProfileFromProvider, error) {
 	// Универсальный обработчик для неизвестных провайдеров
 	displayName, _ := profile["name"].(string)
-	providerID, _ := profile["id"].(string)
+	providerID := stringValue(profile["id"])
 	email, _ := profile["email"].(string)
 	firstName, _ := profile["first_name"].(string)
 	lastName, _ := profile["last_name"].(string)
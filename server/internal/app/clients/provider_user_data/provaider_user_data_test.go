@@ -0,0 +1,135 @@
+package provideruserdata
+
+import (
+	"context"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// newGitHubStub поднимает замену GitHub: обмен кода, /user и /user/emails с заданными ответами
+func newGitHubStub(t *testing.T, profile string, emailsStatus int, emails string) *ProviderUserData {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"gho_test","token_type":"bearer","scope":"read:user,user:email"}`))
+	})
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_test" {
+			http.Error(w, `{"message":"Requires authentication"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(profile))
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(emailsStatus)
+		_, _ = w.Write([]byte(emails))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := &oauth2.Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  server.URL + "/login/oauth/authorize",
+			TokenURL: server.URL + "/login/oauth/access_token",
+		},
+		Scopes: []string{"read:user", "user:email"},
+	}
+	return NewProviderUserData(server.URL+"/user", config, "github")
+}
+
+func githubFlow() *model.AuthFlow {
+	return &model.AuthFlow{Provider: "github", State: "state", CodeVerifier: "verifier"}
+}
+
+func TestGitHubUserData(t *testing.T) {
+	tests := []struct {
+		name         string
+		profile      string
+		emailsStatus int
+		emails       string
+		wantID       string
+		wantName     string
+		wantEmail    string
+	}{
+		{
+			name:         "numeric id keeps precision",
+			profile:      `{"id": 12345678901234567, "login": "octocat", "name": null, "email": null}`,
+			emailsStatus: http.StatusOK,
+			emails:       `[]`,
+			wantID:       "12345678901234567",
+			wantName:     "octocat",
+		},
+		{
+			name:         "primary verified email",
+			profile:      `{"id": 1, "login": "octocat", "name": "The Octocat", "email": null}`,
+			emailsStatus: http.StatusOK,
+			emails: `[
+				{"email": "work@example.com", "primary": false, "verified": true},
+				{"email": "octocat@example.com", "primary": true, "verified": true}
+			]`,
+			wantID:    "1",
+			wantName:  "The Octocat",
+			wantEmail: "octocat@example.com",
+		},
+		{
+			name:         "no verified email",
+			profile:      `{"id": 1, "login": "octocat", "email": null}`,
+			emailsStatus: http.StatusOK,
+			emails: `[
+				{"email": "octocat@example.com", "primary": true, "verified": false},
+				{"email": "work@example.com", "primary": false, "verified": true}
+			]`,
+			wantID:   "1",
+			wantName: "octocat",
+		},
+		{
+			name:         "emails lookup fails, profile email is kept",
+			profile:      `{"id": 1, "login": "octocat", "email": "public@example.com"}`,
+			emailsStatus: http.StatusForbidden,
+			emails:       `{"message": "Resource not accessible by integration"}`,
+			wantID:       "1",
+			wantName:     "octocat",
+			wantEmail:    "public@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newGitHubStub(t, tt.profile, tt.emailsStatus, tt.emails)
+
+			userData, err := provider.GetUserData(context.Background(), "code", githubFlow())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if userData.ProviderID != tt.wantID {
+				t.Errorf("ProviderID = %q, want %q", userData.ProviderID, tt.wantID)
+			}
+			if userData.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", userData.Name, tt.wantName)
+			}
+			if userData.Email != tt.wantEmail {
+				t.Errorf("Email = %q, want %q", userData.Email, tt.wantEmail)
+			}
+			if userData.ProviderName != "github" {
+				t.Errorf("ProviderName = %q, want github", userData.ProviderName)
+			}
+		})
+	}
+}
+
+func TestGitHubProfileWithoutID(t *testing.T) {
+	provider := newGitHubStub(t, `{"login": "octocat"}`, http.StatusOK, `[]`)
+
+	if _, err := provider.GetUserData(context.Background(), "code", githubFlow()); err == nil {
+		t.Fatal("expected error for profile without id")
+	}
+}
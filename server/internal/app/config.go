@@ -1,12 +1,18 @@
 package app
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	authinterface "inzarubin80/MemCode/internal/app/authinterface"
 	"inzarubin80/MemCode/internal/app/icons"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/yandex"
 )
 
@@ -15,40 +21,102 @@ type (
 		Addr string
 	}
 	path struct {
-		index, login, session, refreshToken, logOut, getProviders,
-		ping, setUserName, getUser string
+		index, login, startLogin, session, refreshToken, logOut, getProviders, getJWKS,
+		ping, setUserName, setUserTimezone, getUser string
 
 		// Exercise routes
-		getExercises, createExercise, getExercise, updateExercise, deleteExercise string
+		getExercises, createExercise, getExercise, updateExercise, deleteExercise, submitAttempt, importExercises string
+
+		// Exercise revision routes
+		getExerciseRevisions, diffExerciseRevisions, restoreExerciseRevision string
 
 		// Category routes
-		getCategories, createCategory, getCategory, updateCategory, deleteCategory string
+		getCategories, createCategory, getCategory, updateCategory, deleteCategory, getCategoryTree string
 
 		// Languages route
 		getLanguages string
 
 		// Exercise stat route
-		updateExerciseStat, getExerciseStat, getUserStats string
+		updateExerciseStat, getExerciseStat, getExerciseHistory, getUserStats, getUserActivity string
+
+		// Export routes
+		exportBundle, importBundle string
 
 		// User Exercises route
-		getUserExercises, addUserExercise, removeUserExercise string
-		getAllUsers, setUserAdmin                             string
+		getUserExercises, addUserExercise, removeUserExercise, getDueExercises string
+		getAllUsers, setUserAdmin                                              string
+
+		// Role routes
+		getRoles, grantUserRole, revokeUserRole string
+
+		// Session routes
+		getUserSessions, deleteUserSession, logoutEverywhere string
+
+		// Linked provider routes
+		getUserProviders, linkProvider, unlinkProvider string
+
+		// Local auth routes
+		register, passwordLogin, verifyEmail, resendVerificationEmail, forgotPassword, resetPassword string
+
+		// Tag routes
+		getTags, createTag, renameTag, deleteTag, mergeTags, addExerciseTag, removeExerciseTag string
+
+		// Trash routes
+		getTrash, restoreExerciseFromTrash, restoreCategoryFromTrash string
 	}
 
 	sectrets struct {
 		storeSecret        string
 		accessTokenSecret  string
 		refreshTokenSecret string
+		// Каталог PEM-ключей для подписи access-токенов; если не задан, используется HS256 с accessTokenSecret
+		jwtKeysDir string
+		// До какого момента (RFC 3339) при заданных ключах принимать прежние HS256 access-токены; по умолчанию не принимаются
+		acceptHS256Until time.Time
+	}
+
+	// Отправка служебных писем (подтверждение email, сброс пароля)
+	mail struct {
+		// file или log; log только при devMode. Пустое значение отключает регистрацию и сброс пароля
+		mailer string
+		// Каталог для писем при mailer=file
+		dir string
+		// Адрес фронтенда, на который ведут ссылки из писем
+		publicURL string
+		// Режим разработки (DEV_MODE=true) разрешает печатать письма в лог
+		devMode bool
+	}
+
+	// Интервалы фоновых задач; нулевой интервал отключает задачу
+	jobs struct {
+		tokenCleanupInterval time.Duration
+		trashPurgeInterval   time.Duration
+		// Сколько удалённые записи хранятся в корзине до окончательного удаления
+		trashRetention time.Duration
 	}
 
 	config struct {
 		addr          string
 		path          path
 		sectrets      sectrets
+		jobs          jobs
+		mail          mail
 		provadersConf authinterface.MapProviderOauthConf
+		// Файл с дополнительными OpenID Connect провайдерами, см. auth_providers.example.yaml
+		providersFile string
+		appRoot       string
+		// Сети обратных прокси (TRUSTED_PROXIES через запятую), чьим заголовкам X-Forwarded-For верим
+		trustedProxies []*net.IPNet
 	}
 )
 
+const (
+	defaultTokenCleanupInterval = time.Hour
+	defaultTrashPurgeInterval   = time.Hour
+	defaultTrashRetention       = 30 * 24 * time.Hour
+	defaultGitHubAPIURL         = "https://api.github.com"
+)
+
 func NewConfig(opts Options) config {
 
 	provaders := make(authinterface.MapProviderOauthConf)
@@ -80,49 +148,61 @@ func NewConfig(opts Options) config {
 		IconSVG:     icons.GetProviderIcon("google"),
 	}
 
-	// Добавим GitHub провайдер для демонстрации
-	/*
+	// GitHub; адрес API можно переопределить, например, на локальную заглушку.
+	// Email берётся из /user/emails, так как в профиле он пустой, если пользователь его скрыл.
+	githubAPIURL := os.Getenv("GITHUB_API_URL")
+	if githubAPIURL == "" {
+		githubAPIURL = defaultGitHubAPIURL
+	}
 	provaders["github"] = &authinterface.ProviderOauthConf{
 		Oauth2Config: &oauth2.Config{
 			ClientID:     os.Getenv("CLIENT_ID_GITHUB"),
 			ClientSecret: os.Getenv("CLIENT_SECRET_GITHUB"),
 			RedirectURL:  os.Getenv("APP_ROOT") + "/auth/callback?provider=github",
-			Scopes:       []string{"user:email"},
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  "https://github.com/login/oauth/authorize",
-				TokenURL: "https://github.com/login/oauth/access_token",
-			},
+			Scopes:       []string{"read:user", "user:email"},
+			Endpoint:     github.Endpoint,
 		},
-		UrlUserData: "https://api.github.com/user",
+		UrlUserData: strings.TrimSuffix(githubAPIURL, "/") + "/user",
 		IconSVG:     icons.GetProviderIcon("github"),
 	}
-   */
+
 	config := config{
 		addr: opts.Addr,
 		path: path{
-			index:        "",
-			ping:         "GET /api/ping",
-			getProviders: "GET /api/providers",
-			login:        "POST	/api/user/login",
-			setUserName:  "POST	/api/user/name",
-			getUser:      "GET	/api/user",
-			refreshToken: "POST	/api/user/refresh",
-			session:      "GET		/api/user/session",
-			logOut:       "GET		/api/user/logout",
+			index:           "",
+			ping:            "GET /api/ping",
+			getProviders:    "GET /api/providers",
+			login:           "POST	/api/user/login",
+			startLogin:      "GET    /api/user/login/{provider}/start",
+			setUserName:     "POST	/api/user/name",
+			setUserTimezone: "POST	/api/user/timezone",
+			getUser:         "GET	/api/user",
+			refreshToken:    "POST	/api/user/refresh",
+			session:         "GET		/api/user/session",
+			logOut:          "GET		/api/user/logout",
+			getJWKS:         "GET /.well-known/jwks.json",
 
 			// Exercise routes
-			getExercises:   "GET    /api/exercises",
-			createExercise: "POST   /api/exercises/create",
-			getExercise:    "GET    /api/exercises/get",
-			updateExercise: "PUT    /api/exercises/update",
-			deleteExercise: "DELETE /api/exercises/delete",
+			getExercises:    "GET    /api/exercises",
+			createExercise:  "POST   /api/exercises/create",
+			getExercise:     "GET    /api/exercises/get",
+			updateExercise:  "PUT    /api/exercises/update",
+			deleteExercise:  "DELETE /api/exercises/delete",
+			submitAttempt:   "POST   /api/exercises/submit",
+			importExercises: "POST   /api/exercises/import",
+
+			// Exercise revision routes
+			getExerciseRevisions:    "GET    /api/exercises/{id}/revisions",
+			diffExerciseRevisions:   "GET    /api/exercises/{id}/revisions/diff",
+			restoreExerciseRevision: "POST   /api/exercises/{id}/revisions/{revision}/restore",
 
 			// Category routes
-			getCategories:  "GET    /api/categories",
-			createCategory: "POST   /api/categories/create",
-			getCategory:    "GET    /api/categories/get",
-			updateCategory: "PUT    /api/categories/update",
-			deleteCategory: "DELETE /api/categories/delete",
+			getCategories:   "GET    /api/categories",
+			createCategory:  "POST   /api/categories/create",
+			getCategory:     "GET    /api/categories/get",
+			updateCategory:  "PUT    /api/categories/update",
+			deleteCategory:  "DELETE /api/categories/delete",
+			getCategoryTree: "GET    /api/categories/tree",
 
 			// Languages route
 			getLanguages: "GET    /api/languages",
@@ -130,24 +210,152 @@ func NewConfig(opts Options) config {
 			// Exercise stat route
 			updateExerciseStat: "POST   /api/exercise_stat/update",
 			getExerciseStat:    "GET 	/api/exercise_stat",
+			getExerciseHistory: "GET    /api/exercise_stat/history",
 			getUserStats:       "GET    /api/user/stats",
+			getUserActivity:    "GET    /api/user/activity",
+
+			// Export routes
+			exportBundle: "GET    /api/export",
+			importBundle: "POST   /api/import",
 
 			// User Exercises route
 			getUserExercises:   "GET    /api/user/exercises",
 			addUserExercise:    "POST   /api/user/exercises/add",
 			removeUserExercise: "DELETE /api/user/exercises/remove",
+			getDueExercises:    "GET    /api/user/exercises/due",
 			getAllUsers:        "GET    /api/users",
 			setUserAdmin:       "POST   /api/users/set-admin",
+
+			// Role routes
+			getRoles:       "GET    /api/roles",
+			grantUserRole:  "POST   /api/users/roles/grant",
+			revokeUserRole: "POST   /api/users/roles/revoke",
+
+			// Session routes
+			getUserSessions:   "GET    /api/user/sessions",
+			deleteUserSession: "DELETE /api/user/sessions/{id}",
+			logoutEverywhere:  "DELETE /api/user/sessions",
+
+			// Linked provider routes
+			getUserProviders: "GET    /api/user/providers",
+			linkProvider:     "POST   /api/user/providers/link",
+			unlinkProvider:   "DELETE /api/user/providers/{provider}",
+
+			// Local auth routes
+			register:                "POST   /api/user/register",
+			passwordLogin:           "POST   /api/user/login/password",
+			verifyEmail:             "POST   /api/user/email/verify",
+			resendVerificationEmail: "POST   /api/user/email/resend",
+			forgotPassword:          "POST   /api/user/password/forgot",
+			resetPassword:           "POST   /api/user/password/reset",
+
+			// Tag routes
+			getTags:           "GET    /api/tags",
+			createTag:         "POST   /api/tags/create",
+			renameTag:         "PUT    /api/tags/{id}",
+			deleteTag:         "DELETE /api/tags/{id}",
+			mergeTags:         "POST   /api/tags/merge",
+			addExerciseTag:    "POST   /api/exercises/{id}/tags/{tag_id}",
+			removeExerciseTag: "DELETE /api/exercises/{id}/tags/{tag_id}",
+
+			getTrash:                 "GET    /api/trash",
+			restoreExerciseFromTrash: "POST   /api/trash/exercises/{id}/restore",
+			restoreCategoryFromTrash: "POST   /api/trash/categories/{id}/restore",
 		},
 
 		sectrets: sectrets{
 			storeSecret:        os.Getenv("STORE_SECRET"),
 			accessTokenSecret:  os.Getenv("ACCESS_TOKEN_SECRET"),
 			refreshTokenSecret: os.Getenv("REFRESH_TOKEN_SECRET"),
+			jwtKeysDir:         os.Getenv("JWT_KEYS_DIR"),
+			acceptHS256Until:   timeFromEnv("ACCEPT_HS256_UNTIL"),
 		},
 
-		provadersConf: provaders,
+		jobs: jobs{
+			tokenCleanupInterval: durationFromEnv("TOKEN_CLEANUP_INTERVAL", defaultTokenCleanupInterval),
+			trashPurgeInterval:   durationFromEnv("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval),
+			trashRetention:       durationFromEnv("TRASH_RETENTION", defaultTrashRetention),
+		},
+
+		mail: mail{
+			mailer:    os.Getenv("MAILER"),
+			dir:       os.Getenv("MAILER_DIR"),
+			publicURL: os.Getenv("APP_ROOT"),
+			devMode:   boolFromEnv("DEV_MODE"),
+		},
+
+		provadersConf:  provaders,
+		providersFile:  os.Getenv("AUTH_PROVIDERS_FILE"),
+		appRoot:        os.Getenv("APP_ROOT"),
+		trustedProxies: networksFromEnv("TRUSTED_PROXIES"),
 	}
 
 	return config
 }
+
+// boolFromEnv читает флаг вида "true" или "1"; пустое или неверное значение означает false
+func boolFromEnv(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Println("invalid", name, "value:", err.Error())
+		return false
+	}
+	return flag
+}
+
+// networksFromEnv читает список сетей вида "10.0.0.0/8,127.0.0.1"; адрес без маски означает один хост
+func networksFromEnv(name string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			fmt.Println("invalid", name, "value:", err.Error())
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// timeFromEnv читает момент времени в формате RFC 3339; при пустом или неверном значении возвращает нулевое время
+func timeFromEnv(name string) time.Time {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		fmt.Println("invalid", name, "value:", err.Error())
+		return time.Time{}
+	}
+	return parsed
+}
+
+// durationFromEnv читает интервал вида "30m" или "1h"; при пустом или неверном значении берётся значение по умолчанию
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Println("invalid", name, "value:", err.Error())
+		return defaultValue
+	}
+	return duration
+}
@@ -8,9 +8,11 @@ const (
 	DisplayName                          = "display_name"
 	DefaultEmail                         = "default_email"
 	SessionAuthenticationName            = "authentication"
+	SessionAuthFlowName                  = "auth_flow"
 	Token                                = "token"
 	ProviderKey                          = "provider_Key"
 	Page                                 = "page"
 	PageSize                             = "page_size"
 	IsAdminKey                contextKey = "isAdmin"
+	RolesKey                  contextKey = "roles"
 )
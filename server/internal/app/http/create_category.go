@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
@@ -18,11 +19,12 @@ import (
 // @Param        category body model.Category true "Данные категории"
 // @Success      200      {object}  model.Category
 // @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
 // @Router       /categories [post]
 
 type (
 	CreateCategoryService interface {
-		CreateCategory(ctx context.Context, userID model.UserID, isAdmin bool, category *model.Category) (*model.Category, error)
+		CreateCategory(ctx context.Context, userID model.UserID, roles model.Roles, category *model.Category) (*model.Category, error)
 	}
 
 	CreateCategoryHandler struct {
@@ -77,9 +79,21 @@ func (h *CreateCategoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	isAdmin, _ := ctx.Value(defenitions.IsAdminKey).(bool)
-	createdCategory, err := h.service.CreateCategory(ctx, userID, isAdmin, &category)
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	createdCategory, err := h.service.CreateCategory(ctx, userID, roles, &category)
 	if err != nil {
+		if errors.Is(err, model.ErrorForbidden) {
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
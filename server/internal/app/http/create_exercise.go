@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
@@ -18,11 +19,12 @@ import (
 // @Param        exercise body model.Exercise true "Данные упражнения"
 // @Success      200      {object}  model.Exercise
 // @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
 // @Router       /exercises [post]
 
 type (
 	CreateExerciseService interface {
-		CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error)
+		CreateExercise(ctx context.Context, userID model.UserID, roles model.Roles, exercise *model.Exercise) (*model.Exercise, error)
 	}
 
 	CreateExerciseHandler struct {
@@ -72,9 +74,23 @@ func (h *CreateExerciseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	isAdmin, _ := ctx.Value(defenitions.IsAdminKey).(bool)
-	createdExercise, err := h.service.CreateExercise(ctx, userID, isAdmin, &exercise)
+	// Валидация режима сравнения ответа
+	if exercise.ComparisonMode != "" && !model.IsSupportedComparisonMode(exercise.ComparisonMode) {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "unsupported comparison mode")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	createdExercise, err := h.service.CreateExercise(ctx, userID, roles, &exercise)
 	if err != nil {
+		if errors.Is(err, model.ErrorForbidden) {
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
@@ -11,18 +12,21 @@ import (
 
 // DeleteCategory godoc
 // @Summary      Удалить категорию
-// @Description  Удаляет категорию по ID
+// @Description  Переносит категорию в корзину вместе с упражнениями её владельца.
+// @Description  Если в категории есть упражнения других пользователей, возвращает 400.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "ID категории"
+// @Param        children query string false "Подкатегории: reject - не удалять, если есть (по умолчанию), cascade - удалить вместе, reparent - перенести к родителю"
 // @Success      200      {object}  uhttp.SuccessResponse
 // @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
 // @Router       /categories/{id} [delete]
 
 type (
 	DeleteCategoryService interface {
-		DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error
+		DeleteCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64, policy model.CategoryDeletePolicy) error
 	}
 
 	DeleteCategoryHandler struct {
@@ -60,9 +64,22 @@ func (h *DeleteCategoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	isAdmin, _ := ctx.Value(defenitions.IsAdminKey).(bool)
-	err = h.service.DeleteCategory(ctx, userID, isAdmin, categoryID)
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	policy := model.CategoryDeletePolicy(r.URL.Query().Get("children"))
+	err = h.service.DeleteCategory(ctx, userID, roles, categoryID, policy)
 	if err != nil {
+		if errors.Is(err, model.ErrorForbidden) {
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
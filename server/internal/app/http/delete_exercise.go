@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
@@ -18,11 +19,12 @@ import (
 // @Param        id path string true "ID упражнения"
 // @Success      200      {object}  uhttp.SuccessResponse
 // @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
 // @Router       /exercises/{id} [delete]
 
 type (
 	DeleteExerciseService interface {
-		DeleteExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64) error
+		DeleteExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64) error
 	}
 
 	DeleteExerciseHandler struct {
@@ -60,9 +62,13 @@ func (h *DeleteExerciseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	isAdmin, _ := ctx.Value(defenitions.IsAdminKey).(bool)
-	err = h.service.DeleteExercise(ctx, model.UserID(userID), isAdmin, exerciseID)
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	err = h.service.DeleteExercise(ctx, model.UserID(userID), roles, exerciseID)
 	if err != nil {
+		if errors.Is(err, model.ErrorForbidden) {
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
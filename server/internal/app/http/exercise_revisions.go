@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+)
+
+// GetExerciseRevisions godoc
+// @Summary      Ревизии упражнения
+// @Description  Возвращает сохранённые версии упражнения, начиная с последней
+// @Tags         exercises
+// @Produce      json
+// @Param        id          path      int     true   "ID упражнения"
+// @Param        page        query     int     false  "Номер страницы"
+// @Param        page_size   query     int     false  "Размер страницы"
+// @Success      200      {object}  model.ExerciseRevisionListResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /exercises/{id}/revisions [get]
+
+// DiffExerciseRevisions godoc
+// @Summary      Сравнить ревизии упражнения
+// @Description  Возвращает изменённые поля и построчное сравнение кода двух ревизий.
+// @Description  Код длиннее 1000 строк не сравнивается (400).
+// @Tags         exercises
+// @Produce      json
+// @Param        id     path      int     true   "ID упражнения"
+// @Param        from   query     int     false  "Исходная ревизия, по умолчанию предыдущая перед to"
+// @Param        to     query     int     false  "Конечная ревизия, по умолчанию текущая"
+// @Success      200      {object}  model.ExerciseRevisionDiff
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /exercises/{id}/revisions/diff [get]
+
+// RestoreExerciseRevision godoc
+// @Summary      Восстановить ревизию упражнения
+// @Description  Возвращает упражнению содержимое старой ревизии, которое сохраняется как новая ревизия
+// @Tags         exercises
+// @Produce      json
+// @Param        id         path      int     true   "ID упражнения"
+// @Param        revision   path      int     true   "Номер ревизии"
+// @Success      200      {object}  model.Exercise
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /exercises/{id}/revisions/{revision}/restore [post]
+
+type (
+	serviceGetExerciseRevisions interface {
+		GetExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) (*model.ExerciseRevisionListResponse, error)
+	}
+	GetExerciseRevisionsHandler struct {
+		name    string
+		service serviceGetExerciseRevisions
+	}
+
+	serviceDiffExerciseRevisions interface {
+		DiffExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, from, to int) (*model.ExerciseRevisionDiff, error)
+	}
+	DiffExerciseRevisionsHandler struct {
+		name    string
+		service serviceDiffExerciseRevisions
+	}
+
+	serviceRestoreExerciseRevision interface {
+		RestoreExerciseRevision(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, revision int) (*model.Exercise, error)
+	}
+	RestoreExerciseRevisionHandler struct {
+		name    string
+		service serviceRestoreExerciseRevision
+	}
+)
+
+func NewGetExerciseRevisionsHandler(service serviceGetExerciseRevisions, name string) *GetExerciseRevisionsHandler {
+	return &GetExerciseRevisionsHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetExerciseRevisionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	exerciseID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	// Получаем параметры пагинации
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
+
+	page := 1
+	pageSize := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	revisions, err := h.service.GetExerciseRevisions(ctx, userID, exerciseID, page, pageSize)
+	if err != nil {
+		sendRevisionError(w, err)
+		return
+	}
+	sendJSON(w, revisions)
+}
+
+func NewDiffExerciseRevisionsHandler(service serviceDiffExerciseRevisions, name string) *DiffExerciseRevisionsHandler {
+	return &DiffExerciseRevisionsHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *DiffExerciseRevisionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	exerciseID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var from, to int
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = strconv.Atoi(value); err != nil || from < 1 {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil || to < 1 {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+	}
+
+	diff, err := h.service.DiffExerciseRevisions(ctx, userID, exerciseID, from, to)
+	if err != nil {
+		sendRevisionError(w, err)
+		return
+	}
+	sendJSON(w, diff)
+}
+
+func NewRestoreExerciseRevisionHandler(service serviceRestoreExerciseRevision, name string) *RestoreExerciseRevisionHandler {
+	return &RestoreExerciseRevisionHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *RestoreExerciseRevisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	exerciseID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	revision, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	exercise, err := h.service.RestoreExerciseRevision(ctx, userID, roles, exerciseID, revision)
+	if err != nil {
+		sendRevisionError(w, err)
+		return
+	}
+	sendJSON(w, exercise)
+}
+
+func sendRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrorForbidden):
+		uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrorNotFound):
+		uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
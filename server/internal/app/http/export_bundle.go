@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/bundle"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+)
+
+// ExportBundle godoc
+// @Summary      Выгрузить категории и упражнения пользователя
+// @Description  Отдаёт переносимую выгрузку в формате JSON или YAML, при with_stats=true вместе со статистикой
+// @Tags         export
+// @Produce      json
+// @Param        format      query     string  false  "json или yaml"
+// @Param        with_stats  query     bool    false  "Добавить статистику упражнений"
+// @Success      200      {object}  model.Bundle
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /export [get]
+
+type (
+	ExportBundleService interface {
+		ExportBundle(ctx context.Context, userID model.UserID, withStats bool, writer bundle.Writer) error
+	}
+
+	ExportBundleHandler struct {
+		name    string
+		service ExportBundleService
+	}
+)
+
+func NewExportBundleHandler(service ExportBundleService, name string) *ExportBundleHandler {
+	return &ExportBundleHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *ExportBundleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = model.BundleFormatJSON
+	}
+
+	withStats := false
+	if value := r.URL.Query().Get("with_stats"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid with_stats")
+			return
+		}
+		withStats = parsed
+	}
+
+	writer, err := bundle.NewWriter(format, w)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", bundle.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="memcode-export.%s"`, format))
+
+	// После начала записи тела ошибку уже не передать кодом ответа, поэтому только логируем её
+	if err := h.service.ExportBundle(ctx, userID, withStats, writer); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Println("Failed to export bundle", h.name, userID, err.Error())
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+)
+
+// GetCategoryTree godoc
+// @Summary      Дерево категорий
+// @Description  Возвращает категории пользователя иерархией с числом упражнений в каждой категории и во всём её поддереве
+// @Tags         categories
+// @Produce      json
+// @Success      200      {array}   model.CategoryTreeNode
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /categories/tree [get]
+
+type (
+	GetCategoryTreeService interface {
+		GetCategoryTree(ctx context.Context, userID model.UserID) ([]*model.CategoryTreeNode, error)
+	}
+
+	GetCategoryTreeHandler struct {
+		name    string
+		service GetCategoryTreeService
+	}
+)
+
+func NewGetCategoryTreeHandler(service GetCategoryTreeService, name string) *GetCategoryTreeHandler {
+	return &GetCategoryTreeHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetCategoryTreeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	tree, err := h.service.GetCategoryTree(ctx, userID)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(tree)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
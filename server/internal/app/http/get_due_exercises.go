@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+)
+
+// GetDueExercises godoc
+// @Summary      Получить упражнения к повторению
+// @Description  Возвращает упражнения пользователя, которые нужно повторить сегодня
+// @Tags         user_exercises
+// @Accept       json
+// @Produce      json
+// @Param        page     query     int     false  "Номер страницы"
+// @Param        page_size query     int     false  "Размер страницы"
+// @Success      200      {object}  model.ExerciseListWithUserResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /user/exercises/due [get]
+
+type (
+	GetDueExercisesService interface {
+		GetDueExercises(ctx context.Context, userID model.UserID, page, pageSize int) (*model.ExerciseListWithUserResponse, error)
+	}
+
+	GetDueExercisesHandler struct {
+		name    string
+		service GetDueExercisesService
+	}
+)
+
+func NewGetDueExercisesHandler(service GetDueExercisesService, name string) *GetDueExercisesHandler {
+	return &GetDueExercisesHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetDueExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, "not user ID")
+		return
+	}
+
+	// Получаем параметры пагинации
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
+
+	page := 1
+	pageSize := 10
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	dueExercises, err := h.service.GetDueExercises(ctx, userID, page, pageSize)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(dueExercises)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
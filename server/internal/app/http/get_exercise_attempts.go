@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+)
+
+// GetExerciseAttempts godoc
+// @Summary      Получить историю попыток по упражнению
+// @Description  Возвращает попытки пользователя по упражнению, начиная с последних
+// @Tags         exercise_stats
+// @Accept       json
+// @Produce      json
+// @Param        exercise_id query     int     true   "ID упражнения"
+// @Param        page        query     int     false  "Номер страницы"
+// @Param        page_size   query     int     false  "Размер страницы"
+// @Success      200      {object}  model.ExerciseAttemptListResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /exercise_stat/history [get]
+
+type (
+	GetExerciseAttemptsService interface {
+		GetExerciseAttempts(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) (*model.ExerciseAttemptListResponse, error)
+	}
+
+	GetExerciseAttemptsHandler struct {
+		name    string
+		service GetExerciseAttemptsService
+	}
+)
+
+func NewGetExerciseAttemptsHandler(service GetExerciseAttemptsService, name string) *GetExerciseAttemptsHandler {
+	return &GetExerciseAttemptsHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetExerciseAttemptsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	exIDStr := r.URL.Query().Get("exercise_id")
+	if exIDStr == "" {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "exercise_id required")
+		return
+	}
+
+	exID, err := strconv.ParseInt(exIDStr, 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid exercise_id")
+		return
+	}
+
+	// Получаем параметры пагинации
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
+
+	page := 1
+	pageSize := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	attempts, err := h.service.GetExerciseAttempts(ctx, userID, exID, page, pageSize)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(attempts)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
@@ -12,7 +12,7 @@ import (
 
 type (
 	GetExercisesService interface {
-		GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) (*model.ExerciseListWithUserResponse, error)
+		GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) (*model.ExerciseListWithUserResponse, error)
 	}
 
 	GetExercisesHandler struct {
@@ -41,6 +41,12 @@ func (h *GetExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	pageSizeStr := r.URL.Query().Get("page_size")
 	language := r.URL.Query().Get("programming_language")
 	strCategoryID := r.URL.Query().Get("category_id")
+	query := r.URL.Query().Get("q")
+	tags, err := parseTagFilter(r.URL.Query().Get("tags"), r.URL.Query().Get("tag_mode"))
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	page := 1
 	pageSize := 10
@@ -62,7 +68,6 @@ func (h *GetExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		langPtr = &language
 	}
 	var categoryID int64
-	var err error
 
 	if strCategoryID != "" {
 		categoryID, err = strconv.ParseInt(strCategoryID, 10, 64)
@@ -73,7 +78,7 @@ func (h *GetExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	var exercises *model.ExerciseListWithUserResponse
 
-	exercises, err = h.service.GetExercisesFiltered(ctx, model.UserID(userID), langPtr, categoryID, page, pageSize)
+	exercises, err = h.service.GetExercisesFiltered(ctx, model.UserID(userID), langPtr, categoryID, query, tags, page, pageSize)
 
 	if err != nil {
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
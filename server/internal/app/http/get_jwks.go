@@ -0,0 +1,46 @@
+package http
+
+import (
+	"encoding/json"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+)
+
+// GetJWKS godoc
+// @Summary      Открытые ключи подписи токенов
+// @Description  JWKS с действующими ключами, которыми можно проверить access-токены
+// @Tags         auth
+// @Produce      json
+// @Success      200      {object}  model.JSONWebKeySet
+// @Router       /.well-known/jwks.json [get]
+
+type (
+	jwksProvider interface {
+		JWKS() model.JSONWebKeySet
+	}
+
+	GetJWKSHandler struct {
+		name     string
+		provider jwksProvider
+	}
+)
+
+func NewGetJWKSHandler(provider jwksProvider, name string) *GetJWKSHandler {
+	return &GetJWKSHandler{
+		name:     name,
+		provider: provider,
+	}
+}
+
+func (h *GetJWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := json.Marshal(h.provider.JWKS())
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Ключи меняются только при ротации, клиенты могут их кешировать
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
@@ -31,7 +31,7 @@ type (
 	}
 
 	SetUserAdminService interface {
-		SetUserAdmin(ctx context.Context, userID model.UserID, isAdmin bool) (*model.User, error)
+		SetUserAdmin(ctx context.Context, grantedBy model.UserID, userID model.UserID, isAdmin bool) (*model.User, error)
 	}
 
 	GetAllUsersHandler struct {
@@ -121,6 +121,11 @@ func NewSetUserAdminHandler(store *sessions.CookieStore, name string, service Se
 // ServeHTTP для назначения пользователя администратором
 func (h *SetUserAdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	grantedBy, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, "not user ID")
+		return
+	}
 	type reqBody struct {
 		UserID  int64 `json:"user_id"`
 		IsAdmin bool  `json:"is_admin"`
@@ -130,9 +135,9 @@ func (h *SetUserAdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	user, err := h.service.SetUserAdmin(ctx, model.UserID(body.UserID), body.IsAdmin)
+	user, err := h.service.SetUserAdmin(ctx, grantedBy, model.UserID(body.UserID), body.IsAdmin)
 	if err != nil {
-		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		sendRoleChangeError(w, err)
 		return
 	}
 	jsonData, err := json.Marshal(user)
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"time"
+)
+
+// GetUserActivity godoc
+// @Summary      Получить активность пользователя по дням
+// @Description  Возвращает число попыток, успехов и минут практики по дням, а также серии дней подряд
+// @Tags         user_stats
+// @Accept       json
+// @Produce      json
+// @Param        from     query     string  false  "Начало периода, YYYY-MM-DD"
+// @Param        to       query     string  false  "Конец периода, YYYY-MM-DD"
+// @Success      200      {object}  model.UserActivity
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /user/activity [get]
+
+type (
+	GetUserActivityService interface {
+		GetUserActivity(ctx context.Context, userID model.UserID, from, to time.Time) (*model.UserActivity, error)
+	}
+
+	GetUserActivityHandler struct {
+		name    string
+		service GetUserActivityService
+	}
+)
+
+func NewGetUserActivityHandler(service GetUserActivityService, name string) *GetUserActivityHandler {
+	return &GetUserActivityHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetUserActivityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	var from, to time.Time
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+		from = parsed
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+		to = parsed
+	}
+
+	activity, err := h.service.GetUserActivity(ctx, userID, from, to)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(activity)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
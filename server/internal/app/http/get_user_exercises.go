@@ -19,14 +19,17 @@ import (
 // @Param        page     query     int     false  "Номер страницы"
 // @Param        page_size query     int     false  "Размер страницы"
 // @Param        programming_language query string false "Язык программирования"
-// @Param        category_id query int false "ID категории"
+// @Param        category_id query int false "ID категории; упражнения подкатегорий тоже входят"
+// @Param        q query string false "Поиск по названию, описанию и коду; результаты сортируются по релевантности"
+// @Param        tags query string false "ID меток через запятую"
+// @Param        tag_mode query string false "any - хотя бы одна метка (по умолчанию), all - все метки"
 // @Success      200      {object}  model.ExerciseListWithUserResponse
 // @Failure      400      {object}  uhttp.ErrorResponse
 // @Router       /user_exercises [get]
 
 type (
 	GetUserExercisesService interface {
-		GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page int, pageSize int) (*model.ExerciseListWithUserResponse, error)
+		GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page int, pageSize int) (*model.ExerciseListWithUserResponse, error)
 	}
 
 	GetUserExercisesHandler struct {
@@ -56,6 +59,12 @@ func (h *GetUserExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	pageSizeStr := r.URL.Query().Get("page_size")
 	language := r.URL.Query().Get("programming_language")
 	strCategoryID := r.URL.Query().Get("category_id")
+	query := r.URL.Query().Get("q")
+	tags, err := parseTagFilter(r.URL.Query().Get("tags"), r.URL.Query().Get("tag_mode"))
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	page := 1
 	pageSize := 10
@@ -87,7 +96,7 @@ func (h *GetUserExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	userExercises, err := h.service.GetUserExercisesFiltered(ctx, userID, langPtr, categoryID, page, pageSize)
+	userExercises, err := h.service.GetUserExercisesFiltered(ctx, userID, langPtr, categoryID, query, tags, page, pageSize)
 	if err != nil {
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
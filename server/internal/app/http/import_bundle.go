@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/bundle"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strings"
+)
+
+const (
+	maxBundleSize = 10 << 20
+)
+
+// ImportBundle godoc
+// @Summary      Восстановить выгрузку
+// @Description  Создаёт категории и упражнения из выгрузки /export. Дубликаты пропускаются или переименовываются.
+// @Description  Общие категории связываются с общими категориями сервера, статистика приводится к допустимым значениям.
+// @Tags         export
+// @Accept       json
+// @Produce      json
+// @Param        bundle    body      model.Bundle  true   "Выгрузка"
+// @Param        format    query     string        false  "json или yaml, по умолчанию по Content-Type"
+// @Param        strategy  query     string        false  "skip или rename, по умолчанию skip"
+// @Success      200      {object}  model.BundleImportResult
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /import [post]
+
+type (
+	ImportBundleService interface {
+		ImportBundle(ctx context.Context, userID model.UserID, data *model.Bundle, strategy string) (*model.BundleImportResult, error)
+	}
+
+	ImportBundleHandler struct {
+		name    string
+		service ImportBundleService
+	}
+)
+
+func NewImportBundleHandler(service ImportBundleService, name string) *ImportBundleHandler {
+	return &ImportBundleHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *ImportBundleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = model.BundleFormatJSON
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+			format = model.BundleFormatYAML
+		}
+	}
+
+	strategy := r.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = model.ConflictStrategySkip
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBundleSize)
+	data, err := bundle.Decode(format, r.Body)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.ImportBundle(ctx, userID, data, strategy)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
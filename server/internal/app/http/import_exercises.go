@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/importer"
+	"inzarubin80/MemCode/internal/model"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ImportExercises godoc
+// @Summary      Импортировать упражнения из архива
+// @Description  Создаёт упражнения из файлов zip или tar.gz архива. Каждый файл или область
+// @Description  между "// memcode:start title=..." и "// memcode:end" становится упражнением.
+// @Tags         exercises
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        archive         formData  file    true   "Архив .zip или .tar.gz"
+// @Param        category_id     formData  int     true   "ID категории"
+// @Param        is_common       formData  bool    false  "Общие упражнения (только для админа)"
+// @Param        comparison_mode formData  string  false  "Режим сравнения ответа"
+// @Param        dry_run         formData  bool    false  "Только проверить архив, ничего не создавая"
+// @Success      200      {object}  model.ImportResult
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /exercises/import [post]
+
+type (
+	ImportExercisesService interface {
+		ImportExercises(ctx context.Context, userID model.UserID, roles model.Roles, request *model.ExerciseImportRequest) (*model.ImportResult, error)
+	}
+
+	ImportExercisesHandler struct {
+		name    string
+		service ImportExercisesService
+	}
+)
+
+func NewImportExercisesHandler(service ImportExercisesService, name string) *ImportExercisesHandler {
+	return &ImportExercisesHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *ImportExercisesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, importer.MaxArchiveSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid multipart form: "+err.Error())
+		return
+	}
+
+	categoryID, err := strconv.ParseInt(r.FormValue("category_id"), 10, 64)
+	if err != nil || categoryID <= 0 {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid category_id")
+		return
+	}
+
+	request := &model.ExerciseImportRequest{
+		CategoryID:     categoryID,
+		ComparisonMode: model.ComparisonMode(r.FormValue("comparison_mode")),
+	}
+	if request.ComparisonMode != "" && !model.IsSupportedComparisonMode(request.ComparisonMode) {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "unsupported comparison mode")
+		return
+	}
+	if value := r.FormValue("is_common"); value != "" {
+		if request.IsCommon, err = strconv.ParseBool(value); err != nil {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid is_common")
+			return
+		}
+	}
+	if value := r.FormValue("dry_run"); value != "" {
+		if request.DryRun, err = strconv.ParseBool(value); err != nil {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid dry_run")
+			return
+		}
+	}
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "archive is required")
+		return
+	}
+	defer file.Close()
+
+	request.ArchiveName = header.Filename
+	request.Archive, err = io.ReadAll(io.LimitReader(file, importer.MaxArchiveSize+1))
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(request.Archive) > importer.MaxArchiveSize {
+		uhttp.SendErrorResponse(w, http.StatusRequestEntityTooLarge, "archive is too large")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	result, err := h.service.ImportExercises(ctx, userID, roles, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidParameter):
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, model.ErrorForbidden):
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, model.ErrorNotFound):
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+		default:
+			uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
@@ -0,0 +1,316 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// Register godoc
+// @Summary      Регистрация по email и паролю
+// @Description  Создаёт пользователя и отправляет письмо для подтверждения email.
+// @Description  Для уже зарегистрированного email ответ тот же, а на адрес уходит уведомление о попытке регистрации.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body model.RegisterRequest true "Email, пароль и имя"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      503      {object}  uhttp.ErrorResponse
+// @Router       /user/register [post]
+
+// PasswordLogin godoc
+// @Summary      Вход по email и паролю
+// @Description  Вход доступен после подтверждения email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body model.PasswordLoginRequest true "Email и пароль"
+// @Success      200      {object}  model.AuthData
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Router       /user/login/password [post]
+
+// VerifyEmail godoc
+// @Summary      Подтвердить email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body tokenRequest true "Токен из письма"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /user/email/verify [post]
+
+// ResendVerificationEmail godoc
+// @Summary      Повторно отправить письмо подтверждения
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body emailRequest true "Email"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      503      {object}  uhttp.ErrorResponse
+// @Router       /user/email/resend [post]
+
+// ForgotPassword godoc
+// @Summary      Запросить сброс пароля
+// @Description  Отправляет письмо со ссылкой для сброса, если email зарегистрирован; ответ одинаков в обоих случаях
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body emailRequest true "Email"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      503      {object}  uhttp.ErrorResponse
+// @Router       /user/password/forgot [post]
+
+// ResetPassword godoc
+// @Summary      Задать новый пароль
+// @Description  Меняет пароль по токену из письма и завершает все сессии пользователя
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body resetPasswordRequest true "Токен и новый пароль"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /user/password/reset [post]
+
+type (
+	serviceRegister interface {
+		Register(ctx context.Context, request *model.RegisterRequest) error
+	}
+	RegisterHandler struct {
+		name    string
+		service serviceRegister
+	}
+
+	servicePasswordLogin interface {
+		LoginWithPassword(ctx context.Context, request *model.PasswordLoginRequest) (*model.AuthData, error)
+	}
+	PasswordLoginHandler struct {
+		name    string
+		service servicePasswordLogin
+		store   *sessions.CookieStore
+	}
+
+	serviceVerifyEmail interface {
+		VerifyEmail(ctx context.Context, token string) error
+	}
+	VerifyEmailHandler struct {
+		name    string
+		service serviceVerifyEmail
+	}
+
+	serviceResendVerificationEmail interface {
+		ResendVerificationEmail(ctx context.Context, email string) error
+	}
+	ResendVerificationEmailHandler struct {
+		name    string
+		service serviceResendVerificationEmail
+	}
+
+	serviceForgotPassword interface {
+		RequestPasswordReset(ctx context.Context, email string) error
+	}
+	ForgotPasswordHandler struct {
+		name    string
+		service serviceForgotPassword
+	}
+
+	serviceResetPassword interface {
+		ResetPassword(ctx context.Context, token string, password string) error
+	}
+	ResetPasswordHandler struct {
+		name    string
+		service serviceResetPassword
+	}
+
+	tokenRequest struct {
+		Token string `json:"token"`
+	}
+
+	emailRequest struct {
+		Email string `json:"email"`
+	}
+
+	resetPasswordRequest struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+)
+
+func NewRegisterHandler(service serviceRegister, name string) *RegisterHandler {
+	return &RegisterHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *RegisterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request model.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.Register(r.Context(), &request); err != nil {
+		sendLocalAuthError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func NewPasswordLoginHandler(service servicePasswordLogin, name string, store *sessions.CookieStore) *PasswordLoginHandler {
+	return &PasswordLoginHandler{
+		name:    name,
+		service: service,
+		store:   store,
+	}
+}
+
+func (h *PasswordLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request model.PasswordLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	authData, err := h.service.LoginWithPassword(r.Context(), &request)
+	if err != nil {
+		sendLocalAuthError(w, err)
+		return
+	}
+	sendAuthData(w, r, h.store, authData)
+}
+
+func NewVerifyEmailHandler(service serviceVerifyEmail, name string) *VerifyEmailHandler {
+	return &VerifyEmailHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *VerifyEmailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), request.Token); err != nil {
+		sendLocalAuthError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func NewResendVerificationEmailHandler(service serviceResendVerificationEmail, name string) *ResendVerificationEmailHandler {
+	return &ResendVerificationEmailHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *ResendVerificationEmailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request emailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.ResendVerificationEmail(r.Context(), request.Email); err != nil {
+		sendLocalAuthError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func NewForgotPasswordHandler(service serviceForgotPassword, name string) *ForgotPasswordHandler {
+	return &ForgotPasswordHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *ForgotPasswordHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request emailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), request.Email); err != nil {
+		sendLocalAuthError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func NewResetPasswordHandler(service serviceResetPassword, name string) *ResetPasswordHandler {
+	return &ResetPasswordHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *ResetPasswordHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), request.Token, request.Password); err != nil {
+		sendLocalAuthError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func sendLocalAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrorUnauthorized):
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, model.ErrorForbidden):
+		uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrorUnavailable):
+		uhttp.SendErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// sendAuthData сохраняет refresh-токен в cookie сессии и отдаёт данные входа
+func sendAuthData(w http.ResponseWriter, r *http.Request, store *sessions.CookieStore, authData *model.AuthData) {
+	session, _ := store.Get(r, defenitions.SessionAuthenticationName)
+
+	session.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   86400 * 7,             // Время жизни сессии (7 дней)
+		HttpOnly: true,                  // Запретить доступ через JavaScript
+		Secure:   true,                  // Требует HTTPS
+		SameSite: http.SameSiteNoneMode, // Разрешить cross-origin
+	}
+
+	session.Values[defenitions.Token] = string(authData.RefreshToken)
+	err := session.Save(r, w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponseLoginData, err := json.Marshal(authData)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonResponseLoginData)
+}
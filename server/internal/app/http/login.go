@@ -3,7 +3,7 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"inzarubin80/MemCode/internal/app/defenitions"
+	"errors"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
 	"io"
@@ -25,7 +25,7 @@ import (
 
 type (
 	serviceLogin interface {
-		Login(ctx context.Context, providerKey string, authorizationCode string) (*model.AuthData, error)
+		Login(ctx context.Context, providerKey string, authorizationCode string, flow *model.AuthFlow) (*model.AuthData, error)
 	}
 	LoginHandler struct {
 		name    string
@@ -33,10 +33,11 @@ type (
 		store   *sessions.CookieStore
 	}
 
-	
 	RequestLoginData struct {
 		AuthorizationCode string
 		ProviderKey       string
+		// State из адреса возврата провайдера; вход начинается через StartLogin
+		State string
 	}
 )
 
@@ -65,34 +66,21 @@ func (h *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authData, err := h.service.Login(ctx, loginData.ProviderKey, loginData.AuthorizationCode)
+	flow, err := takeAuthFlow(w, r, h.store, loginData.State)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	session, _ := h.store.Get(r, defenitions.SessionAuthenticationName)
-
-	session.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   86400 * 7,             // Время жизни сессии (7 дней)
-		HttpOnly: true,                  // Запретить доступ через JavaScript
-		Secure:   true,                  // Требует HTTPS
-		SameSite: http.SameSiteNoneMode, // Разрешить cross-origin
-	}
-
-	session.Values[defenitions.Token] = string(authData.RefreshToken)
-	err = session.Save(r, w)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	jsonResponseLoginData, err := json.Marshal(authData)
+	authData, err := h.service.Login(ctx, loginData.ProviderKey, loginData.AuthorizationCode, flow)
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	uhttp.SendSuccessfulResponse(w, jsonResponseLoginData)
+	sendAuthData(w, r, h.store, authData)
 
 }
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+const (
+	authFlowMaxAge = 10 * 60 // на вход у провайдера даётся 10 минут
+
+	authFlowProvider     = "provider"
+	authFlowState        = "state"
+	authFlowNonce        = "nonce"
+	authFlowCodeVerifier = "code_verifier"
+)
+
+// StartLogin godoc
+// @Summary      Начать вход через провайдера
+// @Description  Создаёт state, nonce и PKCE verifier, сохраняет их в cookie и возвращает адрес страницы входа провайдера.
+// @Description  После возврата с кодом фронтенд передаёт code и state в /user/login или /user/providers/link.
+// @Tags         auth
+// @Produce      json
+// @Param        provider   path      string  true  "Провайдер"
+// @Success      200      {object}  model.AuthFlowStart
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /user/login/{provider}/start [get]
+
+type (
+	serviceStartLogin interface {
+		StartLogin(ctx context.Context, providerKey string) (*model.AuthFlow, string, error)
+	}
+	StartLoginHandler struct {
+		name    string
+		service serviceStartLogin
+		store   *sessions.CookieStore
+	}
+)
+
+func NewStartLoginHandler(service serviceStartLogin, name string, store *sessions.CookieStore) *StartLoginHandler {
+	return &StartLoginHandler{
+		name:    name,
+		service: service,
+		store:   store,
+	}
+}
+
+func (h *StartLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flow, authURL, err := h.service.StartLogin(r.Context(), r.PathValue("provider"))
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	session, _ := h.store.Get(r, defenitions.SessionAuthFlowName)
+	session.Options = authFlowCookieOptions(authFlowMaxAge)
+	session.Values[authFlowProvider] = flow.Provider
+	session.Values[authFlowState] = flow.State
+	session.Values[authFlowNonce] = flow.Nonce
+	session.Values[authFlowCodeVerifier] = flow.CodeVerifier
+	if err := session.Save(r, w); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(&model.AuthFlowStart{AuthURL: authURL})
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
+
+// takeAuthFlow достаёт параметры входа из cookie и удаляет её: каждый state используется один раз.
+// Вход с любым провайдером начинается через StartLogin, поэтому без state код не принимается (защита от login CSRF).
+func takeAuthFlow(w http.ResponseWriter, r *http.Request, store *sessions.CookieStore, state string) (*model.AuthFlow, error) {
+	if state == "" {
+		return nil, errors.New("login state is required, start login again")
+	}
+
+	session, err := store.Get(r, defenitions.SessionAuthFlowName)
+	if err != nil {
+		return nil, fmt.Errorf("login state is invalid: %w", err)
+	}
+	flow := &model.AuthFlow{}
+	flow.Provider, _ = session.Values[authFlowProvider].(string)
+	flow.State, _ = session.Values[authFlowState].(string)
+	flow.Nonce, _ = session.Values[authFlowNonce].(string)
+	flow.CodeVerifier, _ = session.Values[authFlowCodeVerifier].(string)
+
+	session.Options = authFlowCookieOptions(-1)
+	if err := session.Save(r, w); err != nil {
+		return nil, err
+	}
+
+	if flow.State == "" {
+		return nil, errors.New("login state is expired, start login again")
+	}
+	if subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return nil, errors.New("login state mismatch")
+	}
+	return flow, nil
+}
+
+func authFlowCookieOptions(maxAge int) *sessions.Options {
+	return &sessions.Options{
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode, // фронтенд и API на разных доменах
+	}
+}
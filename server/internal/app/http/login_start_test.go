@@ -0,0 +1,78 @@
+package http
+
+import (
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+// startedFlowCookie сохраняет параметры входа так же, как StartLoginHandler, и возвращает cookie
+func startedFlowCookie(t *testing.T, store *sessions.CookieStore, state string) *http.Cookie {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/user/login/mock/start", nil)
+	session, _ := store.Get(request, defenitions.SessionAuthFlowName)
+	session.Options = authFlowCookieOptions(authFlowMaxAge)
+	session.Values[authFlowProvider] = "mock"
+	session.Values[authFlowState] = state
+	session.Values[authFlowNonce] = "nonce"
+	session.Values[authFlowCodeVerifier] = "verifier"
+	if err := session.Save(request, recorder); err != nil {
+		t.Fatal(err)
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	return cookies[0]
+}
+
+func TestTakeAuthFlow(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	cookie := startedFlowCookie(t, store, "state-123")
+
+	tests := []struct {
+		name    string
+		cookie  *http.Cookie
+		state   string
+		wantErr bool
+	}{
+		{name: "matching state", cookie: cookie, state: "state-123"},
+		{name: "missing state", cookie: cookie, state: "", wantErr: true},
+		{name: "state mismatch", cookie: cookie, state: "forged", wantErr: true},
+		{name: "login not started", state: "state-123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/user/login", nil)
+			if tt.cookie != nil {
+				request.AddCookie(tt.cookie)
+			}
+			recorder := httptest.NewRecorder()
+
+			flow, err := takeAuthFlow(recorder, request, store, tt.state)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if flow.Provider != "mock" || flow.Nonce != "nonce" || flow.CodeVerifier != "verifier" {
+				t.Fatalf("unexpected flow: %+v", flow)
+			}
+
+			// Cookie удаляется, чтобы state нельзя было использовать повторно
+			cleared := recorder.Result().Cookies()
+			if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+				t.Fatalf("auth flow cookie is not cleared: %+v", cleared)
+			}
+		})
+	}
+}
@@ -47,11 +47,21 @@ func (m *AuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	ctx = context.WithValue(ctx, defenitions.UserIDKey, claims.UserID)
 	ctx = context.WithValue(ctx, defenitions.IsAdminKey, claims.IsAdmin)
+	ctx = context.WithValue(ctx, defenitions.RolesKey, claimsRoles(claims))
 	newRequest := r.WithContext(ctx)
 	m.h.ServeHTTP(w, newRequest)
 
 }
 
+// claimsRoles возвращает роли из токена. В токенах, выпущенных до появления ролей, есть только признак is_admin.
+func claimsRoles(claims *model.Claims) model.Roles {
+	roles := claims.Roles
+	if claims.IsAdmin && !roles.Has(model.RoleAdmin) {
+		roles = append(roles, model.RoleAdmin)
+	}
+	return roles
+}
+
 func (m *AuthMiddleware) extractTokenFromHeader(r *http.Request) (string, error) {
 
 	token := ""
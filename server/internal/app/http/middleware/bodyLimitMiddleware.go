@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+type (
+	// BodyLimitMiddleware ограничивает размер тела любого запроса. Стоит снаружи остальных
+	// middleware, чтобы тело больше limit не читалось никем; обработчики могут задать лимит строже.
+	BodyLimitMiddleware struct {
+		h     http.Handler
+		limit int64
+	}
+)
+
+func NewBodyLimitMiddleware(h http.Handler, limit int64) *BodyLimitMiddleware {
+	return &BodyLimitMiddleware{h: h, limit: limit}
+}
+
+func (m *BodyLimitMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Заявленный размер проверяется сразу, без чтения тела
+	if r.ContentLength > m.limit {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, m.limit)
+	}
+	m.h.ServeHTTP(w, r)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	var read int
+	handler := NewBodyLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		read = len(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}), 8)
+
+	tests := []struct {
+		name     string
+		body     string
+		chunked  bool
+		wantCode int
+		wantRead int
+	}{
+		{name: "within limit", body: "12345678", wantCode: http.StatusOK, wantRead: 8},
+		{name: "declared too large", body: "123456789", wantCode: http.StatusRequestEntityTooLarge, wantRead: 0},
+		{name: "chunked too large", body: strings.Repeat("x", 100), chunked: true, wantCode: http.StatusRequestEntityTooLarge, wantRead: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read = 0
+			r := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantCode || read != tt.wantRead {
+				t.Fatalf("code = %d, read = %d; want %d, %d", w.Code, read, tt.wantCode, tt.wantRead)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const maxUserAgentLength = 512
+
+type (
+	// ClientMiddleware кладёт в контекст user agent и IP клиента, чтобы сервис сохранил их в сессии
+	ClientMiddleware struct {
+		h http.Handler
+		// Обратные прокси, которым разрешено передавать адрес клиента в X-Forwarded-For и X-Real-IP
+		trustedProxies []*net.IPNet
+	}
+)
+
+func NewClientMiddleware(h http.Handler, trustedProxies []*net.IPNet) *ClientMiddleware {
+	return &ClientMiddleware{h: h, trustedProxies: trustedProxies}
+}
+
+func (m *ClientMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	ctx := model.WithClientInfo(r.Context(), model.ClientInfo{UserAgent: userAgent, IPAddress: m.clientIP(r)})
+	m.h.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// clientIP учитывает заголовки прокси, только если запрос пришёл от доверенного прокси:
+// иначе клиент мог бы подставить в сессию любой адрес.
+// X-Forwarded-For разбирается справа налево до первого адреса, не принадлежащего доверенным прокси.
+func (m *ClientMiddleware) clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !m.trusted(remote) {
+		return remote
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if i == 0 || !m.trusted(hop) {
+				return hop
+			}
+		}
+		return remote
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return remote
+}
+
+func (m *ClientMiddleware) trusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range m.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
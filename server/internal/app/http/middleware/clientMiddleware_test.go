@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	m := NewClientMiddleware(nil, []*net.IPNet{proxies})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.5:1234", want: "203.0.113.5"},
+		{name: "spoofed header from client", remoteAddr: "203.0.113.5:1234", forwarded: []string{"1.2.3.4"}, realIP: "1.2.3.4", want: "203.0.113.5"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "client prepends fake hop", remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "proxy chain", remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.7, 10.0.0.2"}, want: "198.51.100.7"},
+		{name: "repeated header", remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4", "198.51.100.7"}, want: "198.51.100.7"},
+		{name: "garbage hop", remoteAddr: "10.0.0.1:1234", forwarded: []string{"unknown"}, want: "10.0.0.1"},
+		{name: "real ip from trusted proxy", remoteAddr: "10.0.0.1:1234", realIP: "198.51.100.7", want: "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/user/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := m.clientIP(r); got != tt.want {
+				t.Fatalf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
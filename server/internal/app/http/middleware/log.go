@@ -16,9 +16,20 @@ func NewLogMux(h http.Handler) http.Handler {
 
 }
 
+// redactedHeaders не печатаются: в них токены доступа и сессионные cookie
+var redactedHeaders = []string{"Authorization", "Cookie"}
+
+// ServeHTTP печатает строку запроса и заголовки. Тело не читается и не печатается:
+// в нём бывают пароли, токены сброса и загружаемые архивы
 func (m *LogMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
-	dumpR, err := httputil.DumpRequest(r, true)
+	logged := r.Clone(r.Context())
+	for _, header := range redactedHeaders {
+		if logged.Header.Get(header) != "" {
+			logged.Header.Set(header, "[redacted]")
+		}
+	}
+	dumpR, err := httputil.DumpRequest(logged, false)
 
 	if err != nil {
 		fmt.Println("Failed to dump request", err.Error())
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+)
+
+type (
+	// PermissionMiddleware пропускает запрос, только если роли пользователя дают нужное право.
+	// Должен стоять внутри AuthMiddleware, который кладёт RolesKey в контекст.
+	PermissionMiddleware struct {
+		h          http.Handler
+		permission model.Permission
+	}
+)
+
+func NewPermissionMiddleware(h http.Handler, permission model.Permission) *PermissionMiddleware {
+	return &PermissionMiddleware{h: h, permission: permission}
+}
+
+func (m *PermissionMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	roles, _ := r.Context().Value(defenitions.RolesKey).(model.Roles)
+	if !roles.Can(m.permission) {
+		http.Error(w, "Forbidden: permission "+string(m.permission)+" required", http.StatusForbidden)
+		return
+	}
+	m.h.ServeHTTP(w, r)
+}
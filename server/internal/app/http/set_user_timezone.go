@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+)
+
+// SetUserTimezone godoc
+// @Summary      Установить часовой пояс пользователя
+// @Description  Часовой пояс IANA, по которому считаются дни активности и серии
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        timezone body setUserTimezoneRequest true "Часовой пояс"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /user/timezone [post]
+
+type (
+	serviceSetUserTimezone interface {
+		SetUserTimezone(ctx context.Context, userID model.UserID, timezone string) error
+	}
+	SetUserTimezoneHandler struct {
+		name    string
+		service serviceSetUserTimezone
+	}
+
+	setUserTimezoneRequest struct {
+		Timezone string `json:"timezone"`
+	}
+)
+
+func NewSetUserTimezoneHandler(service serviceSetUserTimezone, name string) *SetUserTimezoneHandler {
+	return &SetUserTimezoneHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *SetUserTimezoneHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	var req setUserTimezoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := h.service.SetUserTimezone(ctx, userID, req.Timezone)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
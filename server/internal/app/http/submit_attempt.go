@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+)
+
+// SubmitAttempt godoc
+// @Summary      Отправить ответ на проверку
+// @Description  Сравнивает введённый код с эталоном на сервере и обновляет статистику.
+// @Description  Упражнения архивных категорий не принимаются (400).
+// @Tags         exercises
+// @Accept       json
+// @Produce      json
+// @Param        attempt body model.AttemptSubmission true "Ответ пользователя"
+// @Success      200      {object}  model.AttemptResult
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Router       /exercises/submit [post]
+
+type (
+	SubmitAttemptService interface {
+		SubmitAttempt(ctx context.Context, userID model.UserID, submission *model.AttemptSubmission) (*model.AttemptResult, error)
+	}
+
+	SubmitAttemptHandler struct {
+		name    string
+		service SubmitAttemptService
+	}
+)
+
+func NewSubmitAttemptHandler(service SubmitAttemptService, name string) *SubmitAttemptHandler {
+	return &SubmitAttemptHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *SubmitAttemptHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, model.MaxAttemptBodyBytes)
+	var req model.AttemptSubmission
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.SubmitAttempt(ctx, userID, &req)
+	if err != nil {
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
@@ -0,0 +1,400 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetTags godoc
+// @Summary      Метки пользователя
+// @Description  Возвращает личные и общие метки с числом упражнений по каждой
+// @Tags         tags
+// @Produce      json
+// @Success      200      {array}   model.TagWithCount
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /tags [get]
+
+// CreateTag godoc
+// @Summary      Создать метку
+// @Description  Создаёт личную метку; общую (is_common) может создать только редактор контента
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        tag body model.Tag true "Метка"
+// @Success      200      {object}  model.Tag
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Router       /tags/create [post]
+
+// RenameTag godoc
+// @Summary      Переименовать метку
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID метки"
+// @Param        tag  body      model.Tag  true  "Новое имя"
+// @Success      200      {object}  model.Tag
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /tags/{id} [put]
+
+// DeleteTag godoc
+// @Summary      Удалить метку
+// @Description  Удаляет метку и снимает её со всех упражнений
+// @Tags         tags
+// @Produce      json
+// @Param        id   path      int  true  "ID метки"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /tags/{id} [delete]
+
+// MergeTags godoc
+// @Summary      Объединить метки
+// @Description  Переносит упражнения с метки source_id на target_id и удаляет source_id
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        merge body model.TagMerge true "Исходная и целевая метки"
+// @Success      200      {object}  model.Tag
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /tags/merge [post]
+
+// AddExerciseTag godoc
+// @Summary      Поставить метку на упражнение
+// @Tags         tags
+// @Produce      json
+// @Param        id      path  int  true  "ID упражнения"
+// @Param        tag_id  path  int  true  "ID метки"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /exercises/{id}/tags/{tag_id} [post]
+
+// RemoveExerciseTag godoc
+// @Summary      Снять метку с упражнения
+// @Tags         tags
+// @Produce      json
+// @Param        id      path  int  true  "ID упражнения"
+// @Param        tag_id  path  int  true  "ID метки"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /exercises/{id}/tags/{tag_id} [delete]
+
+type (
+	serviceGetTags interface {
+		GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error)
+	}
+	GetTagsHandler struct {
+		name    string
+		service serviceGetTags
+	}
+
+	serviceCreateTag interface {
+		CreateTag(ctx context.Context, userID model.UserID, roles model.Roles, tag *model.Tag) (*model.Tag, error)
+	}
+	CreateTagHandler struct {
+		name    string
+		service serviceCreateTag
+	}
+
+	serviceRenameTag interface {
+		RenameTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64, name string) (*model.Tag, error)
+	}
+	RenameTagHandler struct {
+		name    string
+		service serviceRenameTag
+	}
+
+	serviceDeleteTag interface {
+		DeleteTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64) error
+	}
+	DeleteTagHandler struct {
+		name    string
+		service serviceDeleteTag
+	}
+
+	serviceMergeTags interface {
+		MergeTags(ctx context.Context, userID model.UserID, roles model.Roles, merge *model.TagMerge) (*model.Tag, error)
+	}
+	MergeTagsHandler struct {
+		name    string
+		service serviceMergeTags
+	}
+
+	serviceExerciseTag interface {
+		AddExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error
+		RemoveExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error
+	}
+	// ExerciseTagHandler ставит метку на упражнение или снимает её
+	ExerciseTagHandler struct {
+		name    string
+		service serviceExerciseTag
+		remove  bool
+	}
+)
+
+func NewGetTagsHandler(service serviceGetTags, name string) *GetTagsHandler {
+	return &GetTagsHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetTagsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	tags, err := h.service.GetTags(ctx, userID)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sendJSON(w, tags)
+}
+
+func NewCreateTagHandler(service serviceCreateTag, name string) *CreateTagHandler {
+	return &CreateTagHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *CreateTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	var tag model.Tag
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	createdTag, err := h.service.CreateTag(ctx, userID, roles, &tag)
+	if err != nil {
+		sendTagError(w, err)
+		return
+	}
+	sendJSON(w, createdTag)
+}
+
+func NewRenameTagHandler(service serviceRenameTag, name string) *RenameTagHandler {
+	return &RenameTagHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *RenameTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	tagID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var tag model.Tag
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	renamedTag, err := h.service.RenameTag(ctx, userID, roles, tagID, tag.Name)
+	if err != nil {
+		sendTagError(w, err)
+		return
+	}
+	sendJSON(w, renamedTag)
+}
+
+func NewDeleteTagHandler(service serviceDeleteTag, name string) *DeleteTagHandler {
+	return &DeleteTagHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *DeleteTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	tagID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	if err := h.service.DeleteTag(ctx, userID, roles, tagID); err != nil {
+		sendTagError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func NewMergeTagsHandler(service serviceMergeTags, name string) *MergeTagsHandler {
+	return &MergeTagsHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *MergeTagsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	var merge model.TagMerge
+	if err := json.NewDecoder(r.Body).Decode(&merge); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	target, err := h.service.MergeTags(ctx, userID, roles, &merge)
+	if err != nil {
+		sendTagError(w, err)
+		return
+	}
+	sendJSON(w, target)
+}
+
+func NewAddExerciseTagHandler(service serviceExerciseTag, name string) *ExerciseTagHandler {
+	return &ExerciseTagHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func NewRemoveExerciseTagHandler(service serviceExerciseTag, name string) *ExerciseTagHandler {
+	return &ExerciseTagHandler{
+		name:    name,
+		service: service,
+		remove:  true,
+	}
+}
+
+func (h *ExerciseTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	exerciseID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	tagID, err := strconv.ParseInt(r.PathValue("tag_id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid tag_id")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	if h.remove {
+		err = h.service.RemoveExerciseTag(ctx, userID, roles, exerciseID, tagID)
+	} else {
+		err = h.service.AddExerciseTag(ctx, userID, roles, exerciseID, tagID)
+	}
+	if err != nil {
+		sendTagError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+// parseTagFilter разбирает параметры списков tags=1,2,3 и tag_mode=any|all
+func parseTagFilter(tags string, mode string) (*model.TagFilter, error) {
+	if tags == "" {
+		return nil, nil
+	}
+
+	filter := &model.TagFilter{Mode: model.TagMatchAny}
+	switch model.TagMatchMode(mode) {
+	case "", model.TagMatchAny:
+	case model.TagMatchAll:
+		filter.Mode = model.TagMatchAll
+	default:
+		return nil, fmt.Errorf("invalid tag_mode %q", mode)
+	}
+
+	seen := make(map[int64]bool)
+	for _, value := range strings.Split(tags, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid tag id %q", value)
+		}
+		if !seen[id] {
+			seen[id] = true
+			filter.IDs = append(filter.IDs, id)
+		}
+	}
+	return filter, nil
+}
+
+func sendTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrorForbidden):
+		uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrorNotFound):
+		uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// sendJSON отправляет значение как JSON с кодом 200
+func sendJSON(w http.ResponseWriter, value any) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
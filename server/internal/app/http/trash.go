@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+)
+
+// GetTrash godoc
+// @Summary      Корзина
+// @Description  Возвращает удалённые упражнения и категории пользователя; модераторы видят и удалённый общий контент.
+// @Description  Упражнения и подкатегории, удалённые вместе с категорией, учтены в её счётчиках и отдельно не показываются.
+// @Tags         trash
+// @Produce      json
+// @Success      200      {array}   model.TrashItem
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /trash [get]
+
+// RestoreExerciseFromTrash godoc
+// @Summary      Восстановить упражнение
+// @Description  Возвращает упражнение из корзины. Если его категория тоже удалена, сначала нужно восстановить её.
+// @Tags         trash
+// @Produce      json
+// @Param        id   path      int  true  "ID упражнения"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /trash/exercises/{id}/restore [post]
+
+// RestoreCategoryFromTrash godoc
+// @Summary      Восстановить категорию
+// @Description  Возвращает категорию из корзины вместе с подкатегориями и упражнениями, удалёнными вместе с ней
+// @Tags         trash
+// @Produce      json
+// @Param        id   path      int  true  "ID категории"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /trash/categories/{id}/restore [post]
+
+type (
+	serviceGetTrash interface {
+		GetTrash(ctx context.Context, userID model.UserID, roles model.Roles) ([]*model.TrashItem, error)
+	}
+	GetTrashHandler struct {
+		name    string
+		service serviceGetTrash
+	}
+
+	serviceRestoreFromTrash interface {
+		RestoreExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64) error
+		RestoreCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64) error
+	}
+	// RestoreFromTrashHandler восстанавливает упражнение или, если category, категорию
+	RestoreFromTrashHandler struct {
+		name     string
+		service  serviceRestoreFromTrash
+		category bool
+	}
+)
+
+func NewGetTrashHandler(service serviceGetTrash, name string) *GetTrashHandler {
+	return &GetTrashHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetTrashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	items, err := h.service.GetTrash(ctx, userID, roles)
+	if err != nil {
+		sendTrashError(w, err)
+		return
+	}
+	sendJSON(w, items)
+}
+
+func NewRestoreExerciseFromTrashHandler(service serviceRestoreFromTrash, name string) *RestoreFromTrashHandler {
+	return &RestoreFromTrashHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func NewRestoreCategoryFromTrashHandler(service serviceRestoreFromTrash, name string) *RestoreFromTrashHandler {
+	return &RestoreFromTrashHandler{
+		name:     name,
+		service:  service,
+		category: true,
+	}
+}
+
+func (h *RestoreFromTrashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	if h.category {
+		err = h.service.RestoreCategory(ctx, userID, roles, id)
+	} else {
+		err = h.service.RestoreExercise(ctx, userID, roles, id)
+	}
+	if err != nil {
+		sendTrashError(w, err)
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func sendTrashError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrorForbidden):
+		uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrorNotFound):
+		uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -3,16 +3,18 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
+	"io"
 	"net/http"
 	"strconv"
 )
 
 // UpdateCategory godoc
 // @Summary      Обновить категорию
-// @Description  Обновляет существующую категорию по ID
+// @Description  Обновляет существующую категорию по ID. Без parent_id в теле категория остаётся у прежнего родителя
 // @Tags         categories
 // @Accept       json
 // @Produce      json
@@ -20,11 +22,12 @@ import (
 // @Param        category body model.Category true "Данные категории"
 // @Success      200      {object}  model.Category
 // @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
 // @Router       /categories/{id} [put]
 
 type (
 	UpdateCategoryService interface {
-		UpdateCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64, category *model.Category) (*model.Category, error)
+		UpdateCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64, category *model.Category) (*model.Category, error)
 	}
 
 	UpdateCategoryHandler struct {
@@ -62,11 +65,19 @@ func (h *UpdateCategoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
 	var category model.Category
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &category); err != nil || json.Unmarshal(raw, &fields) != nil {
 		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	// Родитель меняется, только если parent_id передан явно (null - перенос в корень)
+	_, category.ParentIDSet = fields["parent_id"]
 
 	// Устанавливаем ID из URL
 	category.ID = categoryID
@@ -78,9 +89,21 @@ func (h *UpdateCategoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	isAdmin, _ := ctx.Value(defenitions.IsAdminKey).(bool)
-	updatedCategory, err := h.service.UpdateCategory(ctx, userID, isAdmin, categoryID, &category)
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	updatedCategory, err := h.service.UpdateCategory(ctx, userID, roles, categoryID, &category)
 	if err != nil {
+		if errors.Is(err, model.ErrorForbidden) {
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
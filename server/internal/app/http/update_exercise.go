@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
@@ -20,11 +21,12 @@ import (
 // @Param        exercise body model.Exercise true "Данные упражнения"
 // @Success      200      {object}  model.Exercise
 // @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
 // @Router       /exercises/{id} [put]
 
 type (
 	UpdateExerciseService interface {
-		UpdateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error)
+		UpdateExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error)
 	}
 
 	UpdateExerciseHandler struct {
@@ -78,8 +80,22 @@ func (h *UpdateExerciseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	isAdmin, _ := ctx.Value(defenitions.IsAdminKey).(bool)
-	if _, err := h.service.UpdateExercise(ctx, model.UserID(userID), isAdmin, exerciseID, &exercise); err != nil {
+	// Валидация режима сравнения ответа
+	if exercise.ComparisonMode != "" && !model.IsSupportedComparisonMode(exercise.ComparisonMode) {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "unsupported comparison mode")
+		return
+	}
+
+	roles, _ := ctx.Value(defenitions.RolesKey).(model.Roles)
+	if _, err := h.service.UpdateExercise(ctx, model.UserID(userID), roles, exerciseID, &exercise); err != nil {
+		if errors.Is(err, model.ErrorForbidden) {
+			uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidParameter) {
+			uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
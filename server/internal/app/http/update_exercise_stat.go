@@ -1,15 +1,19 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"inzarubin80/MemCode/internal/app/defenitions"
 	"inzarubin80/MemCode/internal/app/uhttp"
 	"inzarubin80/MemCode/internal/model"
 	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
 )
 
 type UpdateExerciseStatService interface {
-	UpsertExerciseStat(userID model.UserID, exerciseID int64, attempts int, successAttempts int) (*model.ExerciseStat, error)
+	UpsertExerciseStat(ctx context.Context, userID model.UserID, update *model.ExerciseStatUpdate) (*model.ExerciseStat, error)
 }
 
 type UpdateExerciseStatHandler struct {
@@ -20,10 +24,20 @@ func NewUpdateExerciseStatHandler(service UpdateExerciseStatService) *UpdateExer
 	return &UpdateExerciseStatHandler{service: service}
 }
 
+// updateExerciseStatRequest - только телеметрия набора. Поля attempts и success_attempts
+// от старых клиентов игнорируются: попытки засчитывает только POST /api/exercises/submit.
 type updateExerciseStatRequest struct {
-	ExerciseID      int64 `json:"exercise_id"`
-	Attempts        int   `json:"attempts"`
-	SuccessAttempts int   `json:"success_attempts"`
+	ExerciseID int64 `json:"exercise_id"`
+	TypingTime int64 `json:"typing_time"` // в секундах
+	TypedChars int   `json:"typed_chars"`
+}
+
+func (req updateExerciseStatRequest) Validate() error {
+	return validation.ValidateStruct(&req,
+		validation.Field(&req.ExerciseID, validation.Required, validation.Min(int64(1))),
+		validation.Field(&req.TypingTime, validation.Min(int64(0)), validation.Max(int64(model.MaxAttemptTypingTime))),
+		validation.Field(&req.TypedChars, validation.Min(0), validation.Max(model.MaxAttemptTypedChars)),
+	)
 }
 
 func (h *UpdateExerciseStatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -40,8 +54,22 @@ func (h *UpdateExerciseStatHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	stat, err := h.service.UpsertExerciseStat(userID, req.ExerciseID, req.Attempts, req.SuccessAttempts)
+	if err := req.Validate(); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stat, err := h.service.UpsertExerciseStat(ctx, userID, &model.ExerciseStatUpdate{
+		UserID:     userID,
+		ExerciseID: req.ExerciseID,
+		TypingTime: req.TypingTime,
+		TypedChars: req.TypedChars,
+	})
 	if err != nil {
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
 		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
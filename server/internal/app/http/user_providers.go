@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// GetUserProviders godoc
+// @Summary      Привязанные способы входа
+// @Description  Возвращает провайдеров, через которых можно войти в аккаунт
+// @Tags         user
+// @Produce      json
+// @Success      200      {array}   model.LinkedProvider
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /user/providers [get]
+
+// LinkProvider godoc
+// @Summary      Привязать провайдера
+// @Description  Привязывает к текущему аккаунту ещё один OAuth-провайдер по коду авторизации
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        request body RequestLoginData true "Провайдер и код авторизации"
+// @Success      200      {array}   model.LinkedProvider
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /user/providers/link [post]
+
+// UnlinkProvider godoc
+// @Summary      Отвязать провайдера
+// @Description  Отвязывает провайдера от аккаунта; последний способ входа отвязать нельзя
+// @Tags         user
+// @Produce      json
+// @Param        provider   path      string  true  "Провайдер"
+// @Success      200      {array}   model.LinkedProvider
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /user/providers/{provider} [delete]
+
+type (
+	serviceGetUserProviders interface {
+		GetUserLinkedProviders(ctx context.Context, userID model.UserID) ([]*model.LinkedProvider, error)
+	}
+	GetUserProvidersHandler struct {
+		name    string
+		service serviceGetUserProviders
+	}
+
+	serviceLinkProvider interface {
+		LinkProvider(ctx context.Context, userID model.UserID, providerKey string, authorizationCode string, flow *model.AuthFlow) ([]*model.LinkedProvider, error)
+	}
+	LinkProviderHandler struct {
+		name    string
+		service serviceLinkProvider
+		store   *sessions.CookieStore
+	}
+
+	serviceUnlinkProvider interface {
+		UnlinkProvider(ctx context.Context, userID model.UserID, provider string) ([]*model.LinkedProvider, error)
+	}
+	UnlinkProviderHandler struct {
+		name    string
+		service serviceUnlinkProvider
+	}
+)
+
+func NewGetUserProvidersHandler(service serviceGetUserProviders, name string) *GetUserProvidersHandler {
+	return &GetUserProvidersHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetUserProvidersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	providers, err := h.service.GetUserLinkedProviders(ctx, userID)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sendLinkedProviders(w, providers)
+}
+
+func NewLinkProviderHandler(service serviceLinkProvider, name string, store *sessions.CookieStore) *LinkProviderHandler {
+	return &LinkProviderHandler{
+		name:    name,
+		service: service,
+		store:   store,
+	}
+}
+
+func (h *LinkProviderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	var request RequestLoginData
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	flow, err := takeAuthFlow(w, r, h.store, request.State)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	providers, err := h.service.LinkProvider(ctx, userID, request.ProviderKey, request.AuthorizationCode, flow)
+	if err != nil {
+		sendProviderError(w, err)
+		return
+	}
+	sendLinkedProviders(w, providers)
+}
+
+func NewUnlinkProviderHandler(service serviceUnlinkProvider, name string) *UnlinkProviderHandler {
+	return &UnlinkProviderHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *UnlinkProviderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	providers, err := h.service.UnlinkProvider(ctx, userID, r.PathValue("provider"))
+	if err != nil {
+		sendProviderError(w, err)
+		return
+	}
+	sendLinkedProviders(w, providers)
+}
+
+func sendProviderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrorNotFound):
+		uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func sendLinkedProviders(w http.ResponseWriter, providers []*model.LinkedProvider) {
+	jsonData, err := json.Marshal(providers)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+)
+
+// GetRoles godoc
+// @Summary      Получить список ролей
+// @Description  Возвращает все роли и права, которые они дают
+// @Tags         user
+// @Produce      json
+// @Success      200      {array}   model.RoleInfo
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Router       /roles [get]
+
+// GrantUserRole godoc
+// @Summary      Выдать роль пользователю
+// @Description  Выдаёт роль и отзывает токены пользователя, чтобы новые права вступили в силу
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        change body model.UserRoleChange true "Пользователь и роль"
+// @Success      200      {object}  model.User
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /users/roles/grant [post]
+
+// RevokeUserRole godoc
+// @Summary      Отозвать роль у пользователя
+// @Description  Отзывает роль и токены пользователя. Роль admin нельзя отозвать у последнего администратора
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        change body model.UserRoleChange true "Пользователь и роль"
+// @Success      200      {object}  model.User
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      403      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /users/roles/revoke [post]
+
+type (
+	serviceGetRoles interface {
+		GetRoles(ctx context.Context) ([]*model.RoleInfo, error)
+	}
+	GetRolesHandler struct {
+		name    string
+		service serviceGetRoles
+	}
+
+	serviceGrantUserRole interface {
+		GrantUserRole(ctx context.Context, grantedBy model.UserID, change *model.UserRoleChange) (*model.User, error)
+	}
+	GrantUserRoleHandler struct {
+		name    string
+		service serviceGrantUserRole
+	}
+
+	serviceRevokeUserRole interface {
+		RevokeUserRole(ctx context.Context, change *model.UserRoleChange) (*model.User, error)
+	}
+	RevokeUserRoleHandler struct {
+		name    string
+		service serviceRevokeUserRole
+	}
+)
+
+func NewGetRolesHandler(service serviceGetRoles, name string) *GetRolesHandler {
+	return &GetRolesHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GetRolesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.service.GetRoles(r.Context())
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(roles)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
+
+func NewGrantUserRoleHandler(service serviceGrantUserRole, name string) *GrantUserRoleHandler {
+	return &GrantUserRoleHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *GrantUserRoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	grantedBy, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	var change model.UserRoleChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.service.GrantUserRole(ctx, grantedBy, &change)
+	if err != nil {
+		sendRoleChangeError(w, err)
+		return
+	}
+	sendUser(w, user)
+}
+
+func NewRevokeUserRoleHandler(service serviceRevokeUserRole, name string) *RevokeUserRoleHandler {
+	return &RevokeUserRoleHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *RevokeUserRoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var change model.UserRoleChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.service.RevokeUserRole(r.Context(), &change)
+	if err != nil {
+		sendRoleChangeError(w, err)
+		return
+	}
+	sendUser(w, user)
+}
+
+func sendRoleChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidParameter):
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrorForbidden):
+		uhttp.SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrorNotFound):
+		uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func sendUser(w http.ResponseWriter, user *model.User) {
+	jsonData, err := json.Marshal(user)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
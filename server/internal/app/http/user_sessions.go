@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inzarubin80/MemCode/internal/app/defenitions"
+	"inzarubin80/MemCode/internal/app/uhttp"
+	"inzarubin80/MemCode/internal/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/sessions"
+)
+
+// GetUserSessions godoc
+// @Summary      Получить активные сессии
+// @Description  Возвращает активные refresh-токены пользователя с user agent и IP; сессия из cookie помечается как текущая
+// @Tags         user
+// @Produce      json
+// @Success      200      {array}   model.Session
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /user/sessions [get]
+
+// DeleteUserSession godoc
+// @Summary      Завершить сессию
+// @Description  Отзывает refresh-токен сессии
+// @Tags         user
+// @Produce      json
+// @Param        id   path      int  true  "ID сессии"
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      400      {object}  uhttp.ErrorResponse
+// @Failure      404      {object}  uhttp.ErrorResponse
+// @Router       /user/sessions/{id} [delete]
+
+// LogoutEverywhere godoc
+// @Summary      Выйти на всех устройствах
+// @Description  Завершает все сессии пользователя и отзывает выданные access-токены
+// @Tags         user
+// @Produce      json
+// @Success      200      {object}  uhttp.SuccessResponse
+// @Failure      401      {object}  uhttp.ErrorResponse
+// @Router       /user/sessions [delete]
+
+type (
+	serviceGetUserSessions interface {
+		GetUserSessions(ctx context.Context, userID model.UserID, currentToken string) ([]*model.Session, error)
+	}
+	GetUserSessionsHandler struct {
+		name    string
+		service serviceGetUserSessions
+		store   *sessions.CookieStore
+	}
+
+	serviceDeleteUserSession interface {
+		DeleteUserSession(ctx context.Context, userID model.UserID, sessionID int64) error
+	}
+	DeleteUserSessionHandler struct {
+		name    string
+		service serviceDeleteUserSession
+	}
+
+	serviceLogoutEverywhere interface {
+		LogoutEverywhere(ctx context.Context, userID model.UserID) error
+	}
+	LogoutEverywhereHandler struct {
+		name    string
+		service serviceLogoutEverywhere
+		store   *sessions.CookieStore
+	}
+)
+
+func NewGetUserSessionsHandler(service serviceGetUserSessions, name string, store *sessions.CookieStore) *GetUserSessionsHandler {
+	return &GetUserSessionsHandler{
+		name:    name,
+		service: service,
+		store:   store,
+	}
+}
+
+func (h *GetUserSessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	// Cookie может отсутствовать, тогда текущая сессия просто не помечается
+	currentToken := ""
+	if session, err := h.store.Get(r, defenitions.SessionAuthenticationName); err == nil {
+		currentToken, _ = session.Values[defenitions.Token].(string)
+	}
+
+	userSessions, err := h.service.GetUserSessions(ctx, userID, currentToken)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonData, err := json.Marshal(userSessions)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	uhttp.SendSuccessfulResponse(w, jsonData)
+}
+
+func NewDeleteUserSessionHandler(service serviceDeleteUserSession, name string) *DeleteUserSessionHandler {
+	return &DeleteUserSessionHandler{
+		name:    name,
+		service: service,
+	}
+}
+
+func (h *DeleteUserSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	err = h.service.DeleteUserSession(ctx, userID, sessionID)
+	if err != nil {
+		if errors.Is(err, model.ErrorNotFound) {
+			uhttp.SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
+
+func NewLogoutEverywhereHandler(service serviceLogoutEverywhere, name string, store *sessions.CookieStore) *LogoutEverywhereHandler {
+	return &LogoutEverywhereHandler{
+		name:    name,
+		service: service,
+		store:   store,
+	}
+}
+
+func (h *LogoutEverywhereHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(defenitions.UserIDKey).(model.UserID)
+	if !ok {
+		uhttp.SendErrorResponse(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	err := h.service.LogoutEverywhere(ctx, userID)
+	if err != nil {
+		uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Удаляем cookie с refresh-токеном и в текущем браузере
+	if session, err := h.store.Get(r, defenitions.SessionAuthenticationName); err == nil {
+		session.Options.MaxAge = -1
+		if err := session.Save(r, w); err != nil {
+			uhttp.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	uhttp.SendSuccessfulResponse(w, []byte("{}"))
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	authinterface "inzarubin80/MemCode/internal/app/authinterface"
+	"inzarubin80/MemCode/internal/app/icons"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// providersFile - файл с OpenID Connect провайдерами (AUTH_PROVIDERS_FILE).
+	// Значения вида ${NAME} подставляются из переменных окружения, чтобы секреты не хранились в файле.
+	providersFile struct {
+		Providers map[string]providerFileEntry `yaml:"providers"`
+	}
+
+	providerFileEntry struct {
+		Issuer       string   `yaml:"issuer"`
+		ClientID     string   `yaml:"client_id"`
+		ClientSecret string   `yaml:"client_secret"`
+		Scopes       []string `yaml:"scopes"`
+		// По умолчанию APP_ROOT + /auth/callback?provider=<ключ>, как у встроенных провайдеров
+		RedirectURL string `yaml:"redirect_url"`
+		IconSVG     string `yaml:"icon_svg"`
+	}
+)
+
+// loadProvidersFile читает провайдеров из файла; провайдер с тем же ключом, что и встроенный, заменяет его
+func loadProvidersFile(path string, appRoot string) (authinterface.MapProviderOauthConf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file providersFile
+	if err := yaml.UnmarshalStrict([]byte(os.ExpandEnv(string(data))), &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	provaders := make(authinterface.MapProviderOauthConf, len(file.Providers))
+	for key, entry := range file.Providers {
+		if entry.Issuer == "" || entry.ClientID == "" {
+			return nil, fmt.Errorf("%s: provider %s: issuer and client_id are required", path, key)
+		}
+
+		scopes := entry.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+		redirectURL := entry.RedirectURL
+		if redirectURL == "" {
+			redirectURL = appRoot + "/auth/callback?provider=" + key
+		}
+		iconSVG := entry.IconSVG
+		if iconSVG == "" {
+			iconSVG = icons.GetProviderIcon(key)
+		}
+
+		provaders[key] = &authinterface.ProviderOauthConf{
+			Oauth2Config: &oauth2.Config{
+				ClientID:     entry.ClientID,
+				ClientSecret: entry.ClientSecret,
+				RedirectURL:  redirectURL,
+				Scopes:       scopes,
+			},
+			Issuer:  entry.Issuer,
+			IconSVG: iconSVG,
+		}
+	}
+	return provaders, nil
+}
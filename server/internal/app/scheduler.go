@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type (
+	// Task - периодическая фоновая задача
+	Task struct {
+		Name     string
+		Interval time.Duration
+		Run      func(ctx context.Context) error
+	}
+
+	// Scheduler запускает зарегистрированные задачи по расписанию.
+	// Перед каждым запуском берётся advisory lock Postgres, поэтому на нескольких репликах
+	// задача в один момент выполняется только на одной из них.
+	Scheduler struct {
+		pool   *pgxpool.Pool
+		tasks  []Task
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	}
+)
+
+func NewScheduler(pool *pgxpool.Pool) *Scheduler {
+	return &Scheduler{pool: pool}
+}
+
+// Register добавляет задачу; вызывается до Start
+func (s *Scheduler) Register(task Task) {
+	s.tasks = append(s.tasks, task)
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, task := range s.tasks {
+		if task.Interval <= 0 {
+			fmt.Println("scheduler: task", task.Name, "disabled")
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(ctx, task)
+	}
+}
+
+// Stop останавливает планировщик и дожидается завершения выполняющихся задач
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runOnce(ctx, task)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, task Task) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Advisory lock принадлежит сессии, поэтому захват и освобождение идут через одно соединение
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		fmt.Println("scheduler:", task.Name, "acquire connection:", err.Error())
+		return
+	}
+	defer conn.Release()
+
+	key := taskLockKey(task.Name)
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		fmt.Println("scheduler:", task.Name, "lock:", err.Error())
+		return
+	}
+	if !locked {
+		return
+	}
+	defer func() {
+		// Контекст задачи уже может быть отменён, а замок нужно отпустить в любом случае
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			fmt.Println("scheduler:", task.Name, "unlock:", err.Error())
+		}
+	}()
+
+	started := time.Now()
+	if err := task.Run(ctx); err != nil {
+		fmt.Println("scheduler:", task.Name, "failed:", err.Error())
+		return
+	}
+	fmt.Println("scheduler:", task.Name, "done in", time.Since(started).Round(time.Millisecond))
+}
+
+func taskLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("memcode:scheduler:" + name))
+	return int64(h.Sum64())
+}
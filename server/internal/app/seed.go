@@ -0,0 +1,18 @@
+package app
+
+import (
+	"context"
+	_ "embed"
+	"inzarubin80/MemCode/internal/seed"
+	tp "inzarubin80/MemCode/internal/transaction_provider"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed seed_data.json
+var seedData []byte
+
+// Seed загружает общие категории и упражнения из seed_data.json; запуск можно повторять
+func Seed(ctx context.Context, dbConn *pgxpool.Pool) (*seed.Report, error) {
+	return seed.Run(ctx, tp.NewTransactionProvider(dbConn), seedData)
+}
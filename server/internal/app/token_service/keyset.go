@@ -0,0 +1,157 @@
+package tokenservice
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	pemExtension     = ".pem"
+	retiredExtension = ".retired.pem"
+)
+
+type (
+	signingKey struct {
+		id         string
+		method     jwt.SigningMethod
+		privateKey crypto.PrivateKey // nil, если в файле только открытый ключ
+		publicKey  crypto.PublicKey
+	}
+
+	// KeySet - набор ключей подписи из каталога PEM-файлов.
+	// kid ключа - имя файла без расширения; новым считается последний по имени,
+	// поэтому файлы удобно называть по дате: 2025-07-28.pem.
+	// Файл может содержать закрытый ключ (RSA или Ed25519) или только открытый - такой ключ
+	// годится лишь для проверки. Файлы *.retired.pem пропускаются: ключ выведен из оборота.
+	KeySet struct {
+		keys []*signingKey
+	}
+)
+
+func LoadKeySet(dir string) (*KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read keys dir: %w", err)
+	}
+
+	keySet := &KeySet{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, pemExtension) || strings.HasSuffix(name, retiredExtension) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseSigningKey(strings.TrimSuffix(name, pemExtension), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		keySet.keys = append(keySet.keys, key)
+	}
+
+	sort.Slice(keySet.keys, func(i, j int) bool {
+		return keySet.keys[i].id > keySet.keys[j].id
+	})
+	if keySet.current() == nil {
+		return nil, fmt.Errorf("no private key in %s", dir)
+	}
+	return keySet, nil
+}
+
+// current возвращает самый новый ключ, которым можно подписывать
+func (k *KeySet) current() *signingKey {
+	for _, key := range k.keys {
+		if key.privateKey != nil {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k *KeySet) byID(id string) *signingKey {
+	for _, key := range k.keys {
+		if key.id == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// JWKS возвращает открытые части всех действующих ключей
+func (k *KeySet) JWKS() model.JSONWebKeySet {
+	jwks := model.JSONWebKeySet{Keys: []model.JSONWebKey{}}
+	for _, key := range k.keys {
+		jwk := model.JSONWebKey{
+			Kid: key.id,
+			Use: "sig",
+			Alg: key.method.Alg(),
+		}
+		switch publicKey := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+func parseSigningKey(id string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: id}
+	switch value := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.privateKey, key.publicKey = jwt.SigningMethodRS256, value, &value.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.publicKey = jwt.SigningMethodRS256, value
+	case ed25519.PrivateKey:
+		key.method, key.privateKey, key.publicKey = jwt.SigningMethodEdDSA, value, value.Public()
+	case ed25519.PublicKey:
+		key.method, key.publicKey = jwt.SigningMethodEdDSA, value
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
@@ -0,0 +1,143 @@
+package tokenservice
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKey сохраняет DER-ключ в PEM-файл каталога
+func writeKey(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRSAKey(t *testing.T, dir, name string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, name, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	return key
+}
+
+func writeEd25519Key(t *testing.T, dir, name string) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, name, "PRIVATE KEY", der)
+	return key
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "2025-01-01.pem")
+	edKey := writeEd25519Key(t, dir, "2025-06-01.pem")
+	writeRSAKey(t, dir, "2024-01-01.retired.pem")
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Ключ только для проверки: новее остальных, но подписывать им нельзя
+	publicDER, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "2025-12-01.pem", "PUBLIC KEY", publicDER)
+
+	keys, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.keys) != 3 {
+		t.Fatalf("loaded %d keys, want 3 (retired and non-PEM files skipped)", len(keys.keys))
+	}
+	if current := keys.current(); current.id != "2025-06-01" {
+		t.Fatalf("current key = %s, want newest private key 2025-06-01", current.id)
+	}
+	if keys.byID("2024-01-01.retired") != nil || keys.byID("2024-01-01") != nil {
+		t.Fatal("retired key was loaded")
+	}
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	t.Run("only public keys", func(t *testing.T) {
+		dir := t.TempDir()
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeKey(t, dir, "2025-01-01.pem", "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&key.PublicKey))
+		if _, err := LoadKeySet(dir); err == nil {
+			t.Fatal("expected error for key set without private key")
+		}
+	})
+	t.Run("broken file", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "2025-01-01.pem"), []byte("garbage"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadKeySet(dir); err == nil {
+			t.Fatal("expected error for file without PEM block")
+		}
+	})
+	t.Run("missing dir", func(t *testing.T) {
+		if _, err := LoadKeySet(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Fatal("expected error for missing dir")
+		}
+	})
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "2025-01-01.pem")
+	edKey := writeEd25519Key(t, dir, "2025-06-01.pem")
+
+	keys, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(jwks.Keys))
+	}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "sig" {
+			t.Errorf("key %s use = %q, want sig", jwk.Kid, jwk.Use)
+		}
+		switch jwk.Kid {
+		case "2025-01-01":
+			if jwk.Kty != "RSA" || jwk.Alg != "RS256" {
+				t.Errorf("unexpected RSA jwk: %+v", jwk)
+			}
+			if jwk.N != base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()) || jwk.E != "AQAB" {
+				t.Errorf("RSA jwk modulus or exponent mismatch: %+v", jwk)
+			}
+		case "2025-06-01":
+			if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Alg != "EdDSA" {
+				t.Errorf("unexpected Ed25519 jwk: %+v", jwk)
+			}
+			if jwk.X != base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey)) {
+				t.Errorf("Ed25519 jwk x mismatch: %+v", jwk)
+			}
+		default:
+			t.Errorf("unexpected kid %s", jwk.Kid)
+		}
+	}
+}
@@ -13,8 +13,11 @@ import (
 type (
 	tokenService struct {
 		secretKey []byte
-		duration  time.Duration
-		tokenType string
+		keys      *KeySet // если задан, токены подписываются асимметричным ключом
+		// До этого момента при заданном keys ещё принимаются HS256-токены без kid, выпущенные до перехода на ключи
+		legacyUntil time.Time
+		duration    time.Duration
+		tokenType   string
 	}
 )
 
@@ -27,7 +30,21 @@ func NewtokenService(secretKey []byte, duration time.Duration, tokenType string)
 
 }
 
-func (a *tokenService) GenerateToken(userID model.UserID, isAdmin bool) (string, error) {
+// NewKeySetTokenService подписывает токены самым новым ключом из набора.
+// HS256-токены, подписанные secretKey до перехода на ключи, принимаются только до legacyUntil:
+// нулевое значение отключает их сразу, иначе утёкший общий секрет продолжал бы выпускать токены.
+// Без набора ключей сервис работает на HS256, как NewtokenService.
+func NewKeySetTokenService(keys *KeySet, secretKey []byte, legacyUntil time.Time, duration time.Duration, tokenType string) *tokenService {
+	return &tokenService{
+		secretKey:   secretKey,
+		keys:        keys,
+		legacyUntil: legacyUntil,
+		duration:    duration,
+		tokenType:   tokenType,
+	}
+}
+
+func (a *tokenService) GenerateToken(userID model.UserID, roles model.Roles) (string, error) {
 	// Генерируем случайные байты для уникальности токена
 	randomBytes := make([]byte, 16)
 	if _, err := rand.Read(randomBytes); err != nil {
@@ -37,14 +54,23 @@ func (a *tokenService) GenerateToken(userID model.UserID, isAdmin bool) (string,
 
 	claims := &model.Claims{
 		UserID:    userID,
-		IsAdmin:   isAdmin,
+		IsAdmin:   roles.Has(model.RoleAdmin),
+		Roles:     roles,
 		TokenType: a.tokenType,
 		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: time.Now().Add(a.duration).Unix(),
 			Id:        randomHex, // Добавляем случайный ID для уникальности
 		},
 	}
 
+	if a.keys != nil {
+		key := a.keys.current()
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header["kid"] = key.id
+		return token.SignedString(key.privateKey)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(a.secretKey)
 	if err != nil {
@@ -54,14 +80,17 @@ func (a *tokenService) GenerateToken(userID model.UserID, isAdmin bool) (string,
 	return tokenString, nil
 }
 
+// JWKS возвращает открытые ключи для проверки токенов другими сервисами
+func (a *tokenService) JWKS() model.JSONWebKeySet {
+	if a.keys == nil {
+		return model.JSONWebKeySet{Keys: []model.JSONWebKey{}}
+	}
+	return a.keys.JWKS()
+}
+
 func (a *tokenService) ValidateToken(tokenString string) (*model.Claims, error) {
 
-	token, err := jwt.ParseWithClaims(tokenString, &model.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return a.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &model.Claims{}, a.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -73,3 +102,28 @@ func (a *tokenService) ValidateToken(tokenString string) (*model.Claims, error)
 
 	return nil, fmt.Errorf("invalid token")
 }
+
+// verificationKey выбирает ключ проверки по kid; токены без kid проверяются общим секретом,
+// а при наборе ключей - только до legacyUntil
+func (a *tokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if a.keys != nil {
+		if kid, ok := token.Header["kid"].(string); ok {
+			key := a.keys.byID(kid)
+			if key == nil {
+				return nil, fmt.Errorf("unknown key id: %s", kid)
+			}
+			if token.Method.Alg() != key.method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.publicKey, nil
+		}
+		if !time.Now().Before(a.legacyUntil) {
+			return nil, fmt.Errorf("token without key id is no longer accepted")
+		}
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(a.secretKey) == 0 {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return a.secretKey, nil
+}
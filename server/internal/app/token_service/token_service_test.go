@@ -0,0 +1,182 @@
+package tokenservice
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var testSecret = []byte("access-secret")
+
+func testKeySet(t *testing.T) *KeySet {
+	t.Helper()
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "2025-01-01.pem")
+	writeEd25519Key(t, dir, "2025-06-01.pem")
+	keys, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func testClaims() *model.Claims {
+	return &model.Claims{
+		UserID:    42,
+		TokenType: model.Access_Token_Type,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		},
+	}
+}
+
+// legacyToken - HS256-токен без kid, как до перехода на ключи
+func legacyToken(t *testing.T) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestKeySetTokenServiceRoundTrip(t *testing.T) {
+	keys := testKeySet(t)
+	service := NewKeySetTokenService(keys, testSecret, time.Time{}, time.Minute, model.Access_Token_Type)
+
+	tokenString, err := service.GenerateToken(7, model.Roles{model.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := new(jwt.Parser).ParseUnverified(tokenString, &model.Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "2025-06-01" || parsed.Method.Alg() != "EdDSA" {
+		t.Fatalf("token signed with kid %v alg %s, want newest key 2025-06-01 EdDSA", parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	claims, err := service.ValidateToken(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || !claims.IsAdmin {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestKeySetTokenServiceOlderKey(t *testing.T) {
+	keys := testKeySet(t)
+	service := NewKeySetTokenService(keys, nil, time.Time{}, time.Minute, model.Access_Token_Type)
+
+	// Токен, подписанный прежним ключом, проверяется ключом из своего kid
+	older := keys.byID("2025-01-01")
+	token := jwt.NewWithClaims(older.method, testClaims())
+	token.Header["kid"] = older.id
+	tokenString, err := token.SignedString(older.privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ValidateToken(tokenString); err != nil {
+		t.Fatalf("token signed with older key rejected: %v", err)
+	}
+}
+
+func TestKeySetTokenServiceRejects(t *testing.T) {
+	keys := testKeySet(t)
+	service := NewKeySetTokenService(keys, testSecret, time.Time{}, time.Minute, model.Access_Token_Type)
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, testClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		errMsg string
+	}{
+		{
+			// Открытый ключ RSA, использованный как HMAC-секрет, не должен подойти
+			name:   "HS256 with RSA kid",
+			token:  sign(jwt.SigningMethodHS256, "2025-01-01", testSecret),
+			errMsg: "unexpected signing method",
+		},
+		{
+			name:   "RS256 with Ed25519 kid",
+			token:  sign(jwt.SigningMethodRS256, "2025-06-01", keys.byID("2025-01-01").privateKey),
+			errMsg: "unexpected signing method",
+		},
+		{
+			name:   "unknown kid",
+			token:  sign(jwt.SigningMethodEdDSA, "2030-01-01", keys.byID("2025-06-01").privateKey),
+			errMsg: "unknown key id",
+		},
+		{
+			name:   "legacy HS256 without opt-in",
+			token:  legacyToken(t),
+			errMsg: "no longer accepted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ValidateToken(tt.token)
+			if err == nil {
+				t.Fatal("expected token to be rejected")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("error %q does not mention %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestKeySetTokenServiceLegacyCutoff(t *testing.T) {
+	keys := testKeySet(t)
+	token := legacyToken(t)
+
+	before := NewKeySetTokenService(keys, testSecret, time.Now().Add(time.Hour), time.Minute, model.Access_Token_Type)
+	if _, err := before.ValidateToken(token); err != nil {
+		t.Fatalf("legacy token rejected before cutoff: %v", err)
+	}
+
+	after := NewKeySetTokenService(keys, testSecret, time.Now().Add(-time.Second), time.Minute, model.Access_Token_Type)
+	if _, err := after.ValidateToken(token); err == nil {
+		t.Fatal("legacy token accepted after cutoff")
+	}
+}
+
+func TestTokenServiceWithoutKeys(t *testing.T) {
+	service := NewKeySetTokenService(nil, testSecret, time.Time{}, time.Minute, model.Access_Token_Type)
+
+	tokenString, err := service.GenerateToken(7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ValidateToken(tokenString); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ValidateToken(legacyToken(t)); err != nil {
+		t.Fatalf("HS256 token rejected without key set: %v", err)
+	}
+	if jwks := service.JWKS(); len(jwks.Keys) != 0 {
+		t.Fatalf("JWKS without key set has %d keys", len(jwks.Keys))
+	}
+
+	other := NewtokenService(testSecret, time.Minute, model.Refresh_Token_Type)
+	if _, err := other.ValidateToken(tokenString); err == nil {
+		t.Fatal("token of another type accepted")
+	}
+}
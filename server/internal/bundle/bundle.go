@@ -0,0 +1,138 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// Writer пишет выгрузку по частям: сначала заголовок с категориями, затем упражнения страницами
+	Writer interface {
+		WriteHeader(header *model.BundleHeader) error
+		WriteExercises(exercises []*model.BundleExercise) error
+		Close() error
+	}
+
+	jsonWriter struct {
+		w       io.Writer
+		written int
+	}
+
+	yamlWriter struct {
+		w       io.Writer
+		written int
+	}
+)
+
+// NewWriter создаёт Writer для формата json или yaml
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case model.BundleFormatJSON:
+		return &jsonWriter{w: w}, nil
+	case model.BundleFormatYAML:
+		return &yamlWriter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %s", model.ErrInvalidParameter, format)
+	}
+}
+
+// ContentType возвращает MIME-тип формата выгрузки
+func ContentType(format string) string {
+	if format == model.BundleFormatYAML {
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+// Decode читает выгрузку целиком
+func Decode(format string, r io.Reader) (*model.Bundle, error) {
+	var bundle model.Bundle
+	switch format {
+	case model.BundleFormatJSON:
+		if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidParameter, err)
+		}
+	case model.BundleFormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&bundle); err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidParameter, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %s", model.ErrInvalidParameter, format)
+	}
+	return &bundle, nil
+}
+
+func (jw *jsonWriter) WriteHeader(header *model.BundleHeader) error {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// Дописываем массив упражнений внутрь того же объекта
+	data = bytes.TrimSuffix(data, []byte("}"))
+	_, err = fmt.Fprintf(jw.w, `%s,"exercises":[`, data)
+	return err
+}
+
+func (jw *jsonWriter) WriteExercises(exercises []*model.BundleExercise) error {
+	for _, exercise := range exercises {
+		data, err := json.Marshal(exercise)
+		if err != nil {
+			return err
+		}
+		if jw.written > 0 {
+			data = append([]byte(","), data...)
+		}
+		if _, err := jw.w.Write(data); err != nil {
+			return err
+		}
+		jw.written++
+	}
+	return nil
+}
+
+func (jw *jsonWriter) Close() error {
+	_, err := io.WriteString(jw.w, "]}\n")
+	return err
+}
+
+func (yw *yamlWriter) WriteHeader(header *model.BundleHeader) error {
+	data, err := yaml.Marshal(header)
+	if err != nil {
+		return err
+	}
+	_, err = yw.w.Write(data)
+	return err
+}
+
+// WriteExercises дописывает элементы последовательности exercises; YAML допускает
+// элементы списка на том же отступе, что и ключ
+func (yw *yamlWriter) WriteExercises(exercises []*model.BundleExercise) error {
+	if len(exercises) == 0 {
+		return nil
+	}
+	data, err := yaml.Marshal(exercises)
+	if err != nil {
+		return err
+	}
+	if yw.written == 0 {
+		data = append([]byte("exercises:\n"), data...)
+	}
+	if _, err := yw.w.Write(data); err != nil {
+		return err
+	}
+	yw.written += len(exercises)
+	return nil
+}
+
+func (yw *yamlWriter) Close() error {
+	if yw.written == 0 {
+		_, err := io.WriteString(yw.w, "exercises: []\n")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package grading
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+	"sync"
+)
+
+type (
+	// Normalizer приводит код к построчному виду, пригодному для сравнения в заданном режиме
+	Normalizer interface {
+		Normalize(code string, mode model.ComparisonMode) []string
+	}
+)
+
+var (
+	mx          sync.RWMutex
+	normalizers = map[model.ProgrammingLanguage]Normalizer{
+		model.LanguagePython:     pythonNormalizer,
+		model.LanguageJavaScript: cLikeNormalizer,
+		model.LanguageJava:       cLikeNormalizer,
+		model.LanguageCpp:        cLikeNormalizer,
+		model.LanguageCSharp:     cLikeNormalizer,
+		model.LanguageGo:         goNormalizer,
+		model.LanguageRust:       cLikeNormalizer,
+		model.LanguageKotlin:     cLikeNormalizer,
+		model.LanguageSwift:      cLikeNormalizer,
+		model.LanguageTypeScript: cLikeNormalizer,
+		model.Language1C:         oneCNormalizer,
+	}
+)
+
+// Register подключает нормализатор для языка, заменяя существующий
+func Register(language model.ProgrammingLanguage, normalizer Normalizer) {
+	mx.Lock()
+	defer mx.Unlock()
+	normalizers[language] = normalizer
+}
+
+// NormalizerFor возвращает нормализатор языка; для неизвестных языков используется C-подобный
+func NormalizerFor(language model.ProgrammingLanguage) Normalizer {
+	mx.RLock()
+	defer mx.RUnlock()
+	if normalizer, ok := normalizers[language]; ok {
+		return normalizer
+	}
+	return cLikeNormalizer
+}
+
+// Compare сравнивает ответ пользователя с эталоном и возвращает построчные расхождения.
+// Пустой результат означает, что ответ верный.
+// В режимах, отличных от strict, номера строк относятся к нормализованному коду.
+func Compare(language model.ProgrammingLanguage, mode model.ComparisonMode, expected, actual string) []model.LineDiff {
+	if !model.IsSupportedComparisonMode(mode) {
+		mode = model.DefaultComparisonMode
+	}
+
+	normalizer := NormalizerFor(language)
+	expectedLines := normalizer.Normalize(expected, mode)
+	actualLines := normalizer.Normalize(actual, mode)
+
+	// В режиме token переносы строк не важны, сравнивается только поток лексем
+	if mode == model.ComparisonModeToken && strings.Join(expectedLines, " ") == strings.Join(actualLines, " ") {
+		return []model.LineDiff{}
+	}
+
+	return diffLines(expectedLines, actualLines)
+}
+
+func diffLines(expectedLines, actualLines []string) []model.LineDiff {
+	maxLen := len(expectedLines)
+	if len(actualLines) > maxLen {
+		maxLen = len(actualLines)
+	}
+
+	lineDiffs := []model.LineDiff{}
+	for i := 0; i < maxLen; i++ {
+		switch {
+		case i >= len(expectedLines):
+			lineDiffs = append(lineDiffs, model.LineDiff{Line: i + 1, Type: model.LineDiffExtra, Actual: actualLines[i]})
+		case i >= len(actualLines):
+			lineDiffs = append(lineDiffs, model.LineDiff{Line: i + 1, Type: model.LineDiffMissing, Expected: expectedLines[i]})
+		case expectedLines[i] != actualLines[i]:
+			lineDiffs = append(lineDiffs, model.LineDiff{Line: i + 1, Type: model.LineDiffMismatch, Expected: expectedLines[i], Actual: actualLines[i]})
+		}
+	}
+	return lineDiffs
+}
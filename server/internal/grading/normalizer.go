@@ -0,0 +1,244 @@
+package grading
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+	"unicode"
+)
+
+type (
+	blockComment struct {
+		start string
+		end   string
+	}
+
+	// languageNormalizer описывает синтаксис языка, важный для сравнения: комментарии, строки и отступы
+	languageNormalizer struct {
+		lineComments      []string
+		blockComments     []blockComment
+		quotes            string
+		rawQuotes         string // кавычки из quotes, внутри которых обратный слеш не экранирует
+		backslashEscapes  bool
+		significantIndent bool
+	}
+)
+
+var (
+	cLikeNormalizer = &languageNormalizer{
+		lineComments:     []string{"//"},
+		blockComments:    []blockComment{{start: "/*", end: "*/"}},
+		quotes:           "\"'`",
+		backslashEscapes: true,
+	}
+
+	// В Go строка в обратных кавычках сырая: обратный слеш в ней не экранирует
+	goNormalizer = &languageNormalizer{
+		lineComments:     []string{"//"},
+		blockComments:    []blockComment{{start: "/*", end: "*/"}},
+		quotes:           "\"'`",
+		rawQuotes:        "`",
+		backslashEscapes: true,
+	}
+
+	// В Python отступы определяют структуру программы
+	pythonNormalizer = &languageNormalizer{
+		lineComments:      []string{"#"},
+		quotes:            "\"'",
+		backslashEscapes:  true,
+		significantIndent: true,
+	}
+
+	// В 1С кавычки внутри строки удваиваются, обратный слеш не экранирует
+	oneCNormalizer = &languageNormalizer{
+		lineComments: []string{"//"},
+		quotes:       "\"",
+	}
+)
+
+// Normalize реализует Normalizer.
+// strict: учитывается всё, кроме концевых пробелов и пустых строк в конце;
+// ignore_whitespace: лексемы строки склеиваются через один пробел, пустые строки отбрасываются;
+// ignore_comments и token: то же самое после удаления комментариев.
+func (n *languageNormalizer) Normalize(code string, mode model.ComparisonMode) []string {
+	lines := splitLines(code)
+	if mode == model.ComparisonModeStrict {
+		return lines
+	}
+
+	if mode == model.ComparisonModeIgnoreComments || mode == model.ComparisonModeToken {
+		lines = splitLines(n.stripComments(strings.Join(lines, "\n")))
+	}
+
+	normalized := []string{}
+	for _, line := range lines {
+		tokens := n.tokenize(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		normalizedLine := strings.Join(tokens, " ")
+		if n.significantIndent {
+			normalizedLine = indentOf(line) + normalizedLine
+		}
+		normalized = append(normalized, normalizedLine)
+	}
+	return normalized
+}
+
+// stripComments удаляет комментарии, не трогая строковые литералы и сохраняя переносы строк
+func (n *languageNormalizer) stripComments(code string) string {
+	var b strings.Builder
+	for i := 0; i < len(code); {
+		c := code[i]
+
+		if strings.IndexByte(n.quotes, c) >= 0 {
+			end := n.literalEnd(code, i)
+			b.WriteString(code[i:end])
+			i = end
+			continue
+		}
+
+		if prefix, ok := hasAnyPrefix(code[i:], n.lineComments); ok {
+			i += len(prefix)
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		if comment, ok := n.blockCommentAt(code[i:]); ok {
+			i += len(comment.start)
+			for i < len(code) && !strings.HasPrefix(code[i:], comment.end) {
+				if code[i] == '\n' {
+					b.WriteByte('\n')
+				}
+				i++
+			}
+			i += len(comment.end)
+			if i > len(code) {
+				i = len(code)
+			}
+			continue
+		}
+
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// literalEnd возвращает позицию сразу после строкового литерала, начинающегося в start
+func (n *languageNormalizer) literalEnd(code string, start int) int {
+	quote := code[start]
+	escapes := n.escapes(rune(quote))
+	i := start + 1
+	for i < len(code) && code[i] != quote {
+		if escapes && code[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	if i < len(code) {
+		i++
+	}
+	if i > len(code) {
+		i = len(code)
+	}
+	return i
+}
+
+// tokenize разбивает строку на лексемы: слова, строковые литералы и отдельные символы
+func (n *languageNormalizer) tokenize(line string) []string {
+	tokens := []string{}
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune(n.quotes, r):
+			escapes := n.escapes(r)
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if escapes && runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(runes) {
+				j++
+			}
+			if j > len(runes) {
+				j = len(runes)
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+// escapes сообщает, экранирует ли обратный слеш внутри литерала с кавычкой quote
+func (n *languageNormalizer) escapes(quote rune) bool {
+	return n.backslashEscapes && !strings.ContainsRune(n.rawQuotes, quote)
+}
+
+func (n *languageNormalizer) blockCommentAt(code string) (blockComment, bool) {
+	for _, comment := range n.blockComments {
+		if strings.HasPrefix(code, comment.start) {
+			return comment, true
+		}
+	}
+	return blockComment{}, false
+}
+
+// splitLines разбивает код на строки без учёта CRLF, концевых пробелов и пустых строк в конце
+func splitLines(code string) []string {
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// indentOf возвращает отступ строки, табуляция считается за четыре пробела
+func indentOf(line string) string {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return strings.Repeat(" ", width)
+		}
+	}
+	return strings.Repeat(" ", width)
+}
+
+func hasAnyPrefix(s string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,175 @@
+package grading
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		language model.ProgrammingLanguage
+		mode     model.ComparisonMode
+		code     string
+		want     []string
+	}{
+		{
+			name:     "strict: концевые пробелы, CRLF и пустые строки в конце",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeStrict,
+			code:     "a := 1  \r\n\tb := 2\r\n\r\n",
+			want:     []string{"a := 1", "\tb := 2"},
+		},
+		{
+			name:     "strict: комментарии сохраняются",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeStrict,
+			code:     "x := 1 // one",
+			want:     []string{"x := 1 // one"},
+		},
+		{
+			name:     "ignore_whitespace: пробелы между лексемами и пустые строки",
+			language: model.LanguageJava,
+			mode:     model.ComparisonModeIgnoreWhitespace,
+			code:     "int   a=b+1;\n\n    return a;",
+			want:     []string{"int a = b + 1 ;", "return a ;"},
+		},
+		{
+			name:     "ignore_whitespace: пробелы внутри строки сохраняются",
+			language: model.LanguageJavaScript,
+			mode:     model.ComparisonModeIgnoreWhitespace,
+			code:     `log("a  b")`,
+			want:     []string{`log ( "a  b" )`},
+		},
+		{
+			name:     "ignore_whitespace: комментарии сохраняются",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeIgnoreWhitespace,
+			code:     "x := 1 // one",
+			want:     []string{"x : = 1 / / one"},
+		},
+		{
+			name:     "ignore_comments: строчные и блочные комментарии",
+			language: model.LanguageCpp,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "int a; // one\n/* two\nthree */ int b;",
+			want:     []string{"int a ;", "int b ;"},
+		},
+		{
+			name:     "ignore_comments: маркеры комментариев внутри строк",
+			language: model.LanguageCSharp,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     `s = "// not /* a comment"; // comment`,
+			want:     []string{`s = "// not /* a comment" ;`},
+		},
+		{
+			name:     "ignore_comments: экранированная кавычка не закрывает строку",
+			language: model.LanguageJava,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     `s = "a\" // b"; // c`,
+			want:     []string{`s = "a\" // b" ;`},
+		},
+		{
+			name:     "ignore_comments: сырая строка Go с обратным слешем в конце",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "p := `C:\\dir\\` // path\nq := 1",
+			want:     []string{"p : = `C:\\dir\\`", "q : = 1"},
+		},
+		{
+			name:     "ignore_comments: многострочная сырая строка Go",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "s := `a\\\n// b`",
+			want:     []string{"s : = `a\\", "/ / b `"},
+		},
+		{
+			name:     "ignore_whitespace: сырая строка Go - одна лексема",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeIgnoreWhitespace,
+			code:     "re := `\\d+`+`x y`",
+			want:     []string{"re : = `\\d+` + `x y`"},
+		},
+		{
+			name:     "ignore_comments: в шаблонной строке JavaScript слеш экранирует",
+			language: model.LanguageJavaScript,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "s = `a\\` // b`; // c",
+			want:     []string{"s = `a\\` // b` ;"},
+		},
+		{
+			name:     "token: переносы строк не важны",
+			language: model.LanguageGo,
+			mode:     model.ComparisonModeToken,
+			code:     "f(a, // first\n  b)",
+			want:     []string{"f ( a ,", "b )"},
+		},
+		{
+			name:     "python: отступ значим",
+			language: model.LanguagePython,
+			mode:     model.ComparisonModeIgnoreWhitespace,
+			code:     "if x:\n\ty  =  1",
+			want:     []string{"if x :", "    y = 1"},
+		},
+		{
+			name:     "python: комментарии через решётку",
+			language: model.LanguagePython,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "s = '#1'  # comment\n# line",
+			want:     []string{"s = '#1'"},
+		},
+		{
+			name:     "1С: удвоенные кавычки и обратный слеш без экранирования",
+			language: model.Language1C,
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "П = \"C:\\\"; // путь\nС = \"а\"\"б\";",
+			want:     []string{"П = \"C:\\\" ;", "С = \"а\" \"б\" ;"},
+		},
+		{
+			name:     "неизвестный язык: без комментариев в стиле C",
+			language: model.ProgrammingLanguage("brainfuck"),
+			mode:     model.ComparisonModeIgnoreComments,
+			code:     "x // y",
+			want:     []string{"x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizerFor(tt.language).Normalize(tt.code, tt.mode)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareModes(t *testing.T) {
+	const expected = "func f() {\n\treturn 1 // one\n}"
+	tests := []struct {
+		name   string
+		mode   model.ComparisonMode
+		actual string
+		equal  bool
+	}{
+		{"strict: тот же код с CRLF", model.ComparisonModeStrict, "func f() {\r\n\treturn 1 // one\r\n}\r\n", true},
+		{"strict: другой отступ", model.ComparisonModeStrict, "func f() {\n  return 1 // one\n}", false},
+		{"ignore_whitespace: другой отступ и пробелы", model.ComparisonModeIgnoreWhitespace, "func f(){\n\n    return 1 //  one\n}", true},
+		{"ignore_whitespace: без комментария", model.ComparisonModeIgnoreWhitespace, "func f() {\n\treturn 1\n}", false},
+		{"ignore_comments: без комментария", model.ComparisonModeIgnoreComments, "func f() {\n\treturn 1\n}", true},
+		{"ignore_comments: другие переносы строк", model.ComparisonModeIgnoreComments, "func f() { return 1 }", false},
+		{"token: другие переносы строк", model.ComparisonModeToken, "func f() { return 1 }", true},
+		{"token: другое значение", model.ComparisonModeToken, "func f() { return 2 }", false},
+		{"неизвестный режим сравнивается по умолчанию", model.ComparisonMode("fuzzy"), "func f(){\n return 1 // one\n}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := Compare(model.LanguageGo, tt.mode, expected, tt.actual)
+			if equal := len(diffs) == 0; equal != tt.equal {
+				t.Errorf("Compare(%q) equal = %v, want %v (diffs %+v)", tt.actual, equal, tt.equal, diffs)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package grading
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"math"
+	"strings"
+)
+
+const (
+	MaxScore = 100
+)
+
+// Score оценивает ответ от 0 до 100 по расстоянию Левенштейна между нормализованными
+// эталоном и ответом. Верный с точки зрения Compare ответ всегда получает 100.
+func Score(language model.ProgrammingLanguage, mode model.ComparisonMode, expected, actual string) int {
+	if len(Compare(language, mode, expected, actual)) == 0 {
+		return MaxScore
+	}
+
+	if !model.IsSupportedComparisonMode(mode) {
+		mode = model.DefaultComparisonMode
+	}
+
+	normalizer := NormalizerFor(language)
+	expectedRunes := []rune(strings.Join(normalizer.Normalize(expected, mode), "\n"))
+	actualRunes := []rune(strings.Join(normalizer.Normalize(actual, mode), "\n"))
+
+	maxLen := len(expectedRunes)
+	if len(actualRunes) > maxLen {
+		maxLen = len(actualRunes)
+	}
+	if maxLen == 0 {
+		return MaxScore
+	}
+
+	distance := levenshtein(expectedRunes, actualRunes)
+	score := int(math.Floor(float64(MaxScore) * (1 - float64(distance)/float64(maxLen))))
+
+	// Неверный ответ не может получить полный балл из-за округления
+	if score >= MaxScore {
+		score = MaxScore - 1
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// levenshtein считает редакционное расстояние битово-параллельным алгоритмом Майерса
+// (блочный вариант Хюрё): столбец матрицы хранится разностями соседних ячеек в словах по 64 бита,
+// поэтому работа - O(len(a)/64 * len(b)) вместо O(len(a) * len(b)).
+func levenshtein(a, b []rune) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return len(b)
+	}
+
+	blocks := (len(a) + 63) / 64
+	// peq[c] - маска позиций символа c в a
+	peq := map[rune][]uint64{}
+	for i, c := range a {
+		mask, ok := peq[c]
+		if !ok {
+			mask = make([]uint64, blocks)
+			peq[c] = mask
+		}
+		mask[i/64] |= 1 << (i % 64)
+	}
+	noMatch := make([]uint64, blocks)
+
+	// Вертикальные разности столбца: pv - +1, mv - -1. В нулевом столбце D[i][0] = i
+	pv := make([]uint64, blocks)
+	mv := make([]uint64, blocks)
+	for i := range pv {
+		pv[i] = ^uint64(0)
+	}
+	lastBit := uint64(1) << ((len(a) - 1) % 64)
+	distance := len(a)
+
+	for _, c := range b {
+		eqs, ok := peq[c]
+		if !ok {
+			eqs = noMatch
+		}
+		// Верхняя строка D[0][j] = j, поэтому в первый блок входит разность +1
+		hin := 1
+		for block := 0; block < blocks; block++ {
+			highBit := uint64(1) << 63
+			if block == blocks-1 {
+				highBit = lastBit
+			}
+			hin = advanceBlock(&pv[block], &mv[block], eqs[block], hin, highBit)
+		}
+		distance += hin
+	}
+	return distance
+}
+
+// advanceBlock переводит блок из 64 строк в следующий столбец. hin - горизонтальная разность
+// над блоком (-1, 0 или +1), возвращается разность в строке highBit
+func advanceBlock(pv, mv *uint64, eq uint64, hin int, highBit uint64) int {
+	p, m := *pv, *mv
+	xv := eq | m
+	if hin < 0 {
+		eq |= 1
+	}
+	xh := (((eq & p) + p) ^ p) | eq
+	ph := m | ^(xh | p)
+	mh := p & xh
+
+	hout := 0
+	if ph&highBit != 0 {
+		hout = 1
+	} else if mh&highBit != 0 {
+		hout = -1
+	}
+
+	ph <<= 1
+	mh <<= 1
+	if hin < 0 {
+		mh |= 1
+	} else if hin > 0 {
+		ph |= 1
+	}
+	*pv = mh | ^(xv | ph)
+	*mv = ph & xv
+	return hout
+}
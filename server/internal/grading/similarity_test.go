@@ -0,0 +1,110 @@
+package grading
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// naiveLevenshtein - эталонная реализация по полной матрице
+func naiveLevenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"привет", "привед", 1},
+		{strings.Repeat("a", 64), strings.Repeat("a", 65), 1},
+		{strings.Repeat("ab", 100), strings.Repeat("ba", 100), 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshteinMatchesNaive(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	alphabet := []rune("ab{}() \\nx")
+	randomString := func(n int) []rune {
+		s := make([]rune, n)
+		for i := range s {
+			s[i] = alphabet[random.Intn(len(alphabet))]
+		}
+		return s
+	}
+
+	// Длины вокруг границ блоков по 64 бита
+	lengths := []int{1, 2, 63, 64, 65, 127, 128, 129, 200}
+	for _, n := range lengths {
+		for _, m := range lengths {
+			a, b := randomString(n), randomString(m)
+			if got, want := levenshtein(a, b), naiveLevenshtein(a, b); got != want {
+				t.Fatalf("levenshtein(len %d, len %d) = %d, want %d", n, m, got, want)
+			}
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name             string
+		mode             model.ComparisonMode
+		expected, actual string
+		want             int
+	}{
+		{"оба пустые", model.ComparisonModeStrict, "", "", MaxScore},
+		{"пустой ответ", model.ComparisonModeStrict, "abc", "", 0},
+		{"ответ к пустому эталону", model.ComparisonModeStrict, "", "abc", 0},
+		{"верный ответ", model.ComparisonModeStrict, "x := 1", "x := 1\n", MaxScore},
+		{"верный с точностью до комментариев", model.ComparisonModeIgnoreComments, "x := 1", "x := 1 // one", MaxScore},
+		{"полностью другой ответ", model.ComparisonModeStrict, "aaaa", "bbbb", 0},
+		{"половина символов неверна", model.ComparisonModeStrict, "abcd", "abxy", 50},
+		{"неверный ответ не получает 100 из-за округления", model.ComparisonModeStrict, strings.Repeat("a", 1000), strings.Repeat("a", 999) + "b", MaxScore - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(model.LanguageGo, tt.mode, tt.expected, tt.actual); got != tt.want {
+				t.Errorf("Score(%q, %q) = %d, want %d", tt.expected, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreLongAnswerIsBounded(t *testing.T) {
+	expected := strings.Repeat("fmt.Println(value)\n", 800)
+	actual := strings.Repeat("fmt.Printf(\"%v\", other)\n", 1600)
+
+	start := time.Now()
+	Score(model.LanguageGo, model.ComparisonModeStrict, expected, actual)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Score took %v on answers near the length limit", elapsed)
+	}
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// Ограничения на содержимое архива
+	MaxArchiveSize = 10 << 20
+	MaxFiles       = 500
+	MaxFileSize    = 64 << 10
+	// MaxUnpackedSize ограничивает объём распакованных данных всего архива,
+	// включая пропущенные записи, которые всё равно приходится распаковывать
+	MaxUnpackedSize = 64 << 20
+)
+
+var (
+	ErrUnsupportedArchive = errors.New("unsupported archive format, expected .zip or .tar.gz")
+	ErrArchiveTooLarge    = fmt.Errorf("archive unpacks to more than %d bytes", MaxUnpackedSize)
+)
+
+type (
+	// File исходный файл из архива; Err заполняется, если файл не удалось прочитать
+	File struct {
+		Path    string
+		Content []byte
+		Err     error
+	}
+)
+
+// ReadArchive распаковывает zip или tar.gz в памяти. Формат определяется по имени архива.
+// Каталоги, ссылки и другие особые записи, скрытые файлы и служебные каталоги вроде .git пропускаются.
+func ReadArchive(name string, data []byte) ([]File, error) {
+	lowerName := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lowerName, ".zip"):
+		return readZip(data)
+	case strings.HasSuffix(lowerName, ".tar.gz"), strings.HasSuffix(lowerName, ".tgz"):
+		return readTarGz(data)
+	default:
+		return nil, ErrUnsupportedArchive
+	}
+}
+
+func readZip(data []byte) ([]File, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("read zip: %w", err)
+	}
+
+	files := []File{}
+	unpacked := &unpackLimiter{remaining: MaxUnpackedSize}
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() || isHidden(entry.Name) {
+			continue
+		}
+		if len(files) >= MaxFiles {
+			return nil, fmt.Errorf("archive contains more than %d files", MaxFiles)
+		}
+
+		file := File{Path: cleanPath(entry.Name)}
+		rc, err := entry.Open()
+		if err != nil {
+			file.Err = err
+		} else {
+			unpacked.r = rc
+			file.Content, file.Err = readLimited(unpacked)
+			rc.Close()
+		}
+		if errors.Is(file.Err, ErrArchiveTooLarge) {
+			return nil, file.Err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func readTarGz(data []byte) ([]File, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("read gzip: %w", err)
+	}
+	defer gz.Close()
+
+	files := []File{}
+	reader := tar.NewReader(&unpackLimiter{r: gz, remaining: MaxUnpackedSize})
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || isHidden(header.Name) {
+			continue
+		}
+		if len(files) >= MaxFiles {
+			return nil, fmt.Errorf("archive contains more than %d files", MaxFiles)
+		}
+
+		file := File{Path: cleanPath(header.Name)}
+		file.Content, file.Err = readLimited(reader)
+		if errors.Is(file.Err, ErrArchiveTooLarge) {
+			return nil, file.Err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > MaxFileSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", MaxFileSize)
+	}
+	return content, nil
+}
+
+// unpackLimiter считает распакованные байты всего архива и возвращает ErrArchiveTooLarge,
+// когда их становится больше MaxUnpackedSize
+type unpackLimiter struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *unpackLimiter) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if l.remaining <= 0 {
+		// Лимит исчерпан: архив допустим, только если данных больше нет
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrArchiveTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func cleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// isHidden сообщает, есть ли в пути файл или каталог, начинающийся с точки
+func isHidden(name string) bool {
+	for _, part := range strings.Split(cleanPath(name), "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
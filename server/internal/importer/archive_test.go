@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadArchiveFixtures(t *testing.T) {
+	want := map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"src/util.py": "def util():\n    return 1\n",
+	}
+
+	// В архивах кроме этих файлов лежат каталог, скрытый файл, .git/config и символическая ссылка
+	for _, name := range []string{"sample.zip", "sample.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := ReadArchive(name, data)
+			if err != nil {
+				t.Fatalf("ReadArchive: %v", err)
+			}
+
+			got := map[string]string{}
+			for _, file := range files {
+				if file.Err != nil {
+					t.Errorf("%s: %v", file.Path, file.Err)
+				}
+				got[file.Path] = string(file.Content)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("files = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestReadArchiveFormatByName(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "sample.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadArchive("SAMPLE.TGZ", data); err != nil {
+		t.Errorf("ReadArchive(.TGZ): %v", err)
+	}
+	if _, err := ReadArchive("sample.rar", data); !errors.Is(err, ErrUnsupportedArchive) {
+		t.Errorf("ReadArchive(.rar) error = %v, want %v", err, ErrUnsupportedArchive)
+	}
+	if _, err := ReadArchive("sample.zip", data); err == nil {
+		t.Error("ReadArchive accepted tar.gz content named .zip")
+	}
+}
+
+func TestReadArchiveFileLimits(t *testing.T) {
+	large := map[string][]byte{
+		"small.go": []byte("package small\n"),
+		"large.go": bytes.Repeat([]byte("a"), MaxFileSize+1),
+	}
+	tooMany := map[string][]byte{}
+	for i := 0; i <= MaxFiles; i++ {
+		tooMany[fmt.Sprintf("file%d.go", i)] = []byte("package file\n")
+	}
+
+	for _, format := range []struct {
+		name  string
+		build func(t *testing.T, files map[string][]byte) []byte
+	}{
+		{"archive.zip", buildZip},
+		{"archive.tar.gz", buildTarGz},
+	} {
+		t.Run(format.name, func(t *testing.T) {
+			files, err := ReadArchive(format.name, format.build(t, large))
+			if err != nil {
+				t.Fatalf("ReadArchive: %v", err)
+			}
+			for _, file := range files {
+				switch file.Path {
+				case "small.go":
+					if file.Err != nil {
+						t.Errorf("small.go: %v", file.Err)
+					}
+				case "large.go":
+					if file.Err == nil {
+						t.Error("large.go: file over MaxFileSize was accepted")
+					}
+				}
+			}
+
+			if _, err := ReadArchive(format.name, format.build(t, tooMany)); err == nil {
+				t.Errorf("archive with %d files was accepted", len(tooMany))
+			}
+		})
+	}
+}
+
+func TestReadTarGzCountsSkippedEntries(t *testing.T) {
+	// Скрытый файл пропускается, но tar.Next всё равно распаковывает его целиком
+	data := buildTarGz(t, map[string][]byte{
+		".bomb":   make([]byte, MaxUnpackedSize),
+		"main.go": []byte("package main\n"),
+	})
+	if len(data) > MaxArchiveSize {
+		t.Fatalf("test archive is %d bytes, larger than MaxArchiveSize", len(data))
+	}
+
+	if _, err := ReadArchive("bomb.tar.gz", data); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("ReadArchive error = %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
+
+func TestUnpackLimiterAllowsExactLimit(t *testing.T) {
+	limiter := &unpackLimiter{r: bytes.NewReader(make([]byte, 10)), remaining: 10}
+	if data, err := io.ReadAll(limiter); err != nil || len(data) != 10 {
+		t.Fatalf("ReadAll = %d bytes, %v; want 10 bytes", len(data), err)
+	}
+
+	limiter = &unpackLimiter{r: bytes.NewReader(make([]byte, 11)), remaining: 10}
+	if _, err := io.ReadAll(limiter); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("ReadAll error = %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	regionStart = "memcode:start"
+	regionEnd   = "memcode:end"
+
+	maxTitleLength = 255
+)
+
+var extensionLanguages = map[string]model.ProgrammingLanguage{
+	".py":    model.LanguagePython,
+	".js":    model.LanguageJavaScript,
+	".mjs":   model.LanguageJavaScript,
+	".jsx":   model.LanguageJavaScript,
+	".java":  model.LanguageJava,
+	".cpp":   model.LanguageCpp,
+	".cc":    model.LanguageCpp,
+	".cxx":   model.LanguageCpp,
+	".hpp":   model.LanguageCpp,
+	".h":     model.LanguageCpp,
+	".cs":    model.LanguageCSharp,
+	".go":    model.LanguageGo,
+	".rs":    model.LanguageRust,
+	".kt":    model.LanguageKotlin,
+	".kts":   model.LanguageKotlin,
+	".swift": model.LanguageSwift,
+	".ts":    model.LanguageTypeScript,
+	".tsx":   model.LanguageTypeScript,
+	".bsl":   model.Language1C,
+	".os":    model.Language1C,
+}
+
+type (
+	// Snippet фрагмент кода, из которого будет создано упражнение
+	Snippet struct {
+		Title    string
+		Language model.ProgrammingLanguage
+		Code     string
+	}
+)
+
+// LanguageByPath определяет язык по расширению файла
+func LanguageByPath(filePath string) (model.ProgrammingLanguage, bool) {
+	language, ok := extensionLanguages[strings.ToLower(path.Ext(filePath))]
+	if !ok || !model.IsSupportedLanguage(language) {
+		return "", false
+	}
+	return language, true
+}
+
+// Extract разбивает файл на фрагменты. Если в файле есть области
+// "// memcode:start title=..." ... "// memcode:end", каждая область становится
+// отдельным фрагментом, иначе фрагментом становится весь файл.
+func Extract(filePath string, content []byte, language model.ProgrammingLanguage) ([]Snippet, error) {
+	if !utf8.Valid(content) {
+		return nil, fmt.Errorf("file is not valid UTF-8")
+	}
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	snippets := []Snippet{}
+	var (
+		inRegion  bool
+		startLine int
+		title     string
+		region    []string
+	)
+	for i, line := range lines {
+		directive, attrs, ok := parseDirective(line, language)
+		if !ok {
+			if inRegion {
+				region = append(region, line)
+			}
+			continue
+		}
+
+		switch directive {
+		case regionStart:
+			if inRegion {
+				return nil, fmt.Errorf("line %d: nested %s, region from line %d is not closed", i+1, regionStart, startLine)
+			}
+			inRegion, startLine, region = true, i+1, nil
+			title = attrs["title"]
+		case regionEnd:
+			if !inRegion {
+				return nil, fmt.Errorf("line %d: %s without %s", i+1, regionEnd, regionStart)
+			}
+			code := dedent(region)
+			if code == "" {
+				return nil, fmt.Errorf("line %d: region is empty", startLine)
+			}
+			if title == "" {
+				title = fmt.Sprintf("%s #%d", baseName(filePath), len(snippets)+1)
+			}
+			snippets = append(snippets, Snippet{Title: truncateTitle(title), Language: language, Code: code})
+			inRegion = false
+		}
+	}
+	if inRegion {
+		return nil, fmt.Errorf("line %d: region is not closed", startLine)
+	}
+
+	if len(snippets) > 0 {
+		return snippets, nil
+	}
+
+	code := strings.TrimRight(text, "\n\t ")
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("file is empty")
+	}
+	return []Snippet{{Title: truncateTitle(baseName(filePath)), Language: language, Code: code}}, nil
+}
+
+// parseDirective распознаёт строку-комментарий с меткой memcode и её атрибуты key=value
+func parseDirective(line string, language model.ProgrammingLanguage) (string, map[string]string, bool) {
+	commentPrefix := "//"
+	if language == model.LanguagePython {
+		commentPrefix = "#"
+	}
+
+	trimmed, ok := strings.CutPrefix(strings.TrimSpace(line), commentPrefix)
+	if !ok {
+		return "", nil, false
+	}
+	trimmed = strings.TrimSpace(trimmed)
+
+	var directive string
+	switch {
+	case strings.HasPrefix(trimmed, regionStart):
+		directive = regionStart
+	case strings.HasPrefix(trimmed, regionEnd):
+		directive = regionEnd
+	default:
+		return "", nil, false
+	}
+
+	attrs := map[string]string{}
+	rest := strings.TrimSpace(trimmed[len(directive):])
+	if value, ok := strings.CutPrefix(rest, "title="); ok {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		attrs["title"] = strings.TrimSpace(value)
+	}
+	return directive, attrs, true
+}
+
+// dedent убирает общий отступ строк области и пустые строки по краям
+func dedent(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	prefix, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = strings.TrimRight(strings.TrimPrefix(line, prefix), " \t")
+	}
+	return strings.Join(result, "\n")
+}
+
+func baseName(filePath string) string {
+	name := path.Base(filePath)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+func truncateTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) > maxTitleLength {
+		return string(runes[:maxTitleLength])
+	}
+	return title
+}
@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	KindLog  = "log"
+	KindFile = "file"
+)
+
+// ErrNotConfigured - MAILER не задан: приложение работает без писем, вход по паролю недоступен
+var ErrNotConfigured = errors.New("MAILER is not set")
+
+type (
+	// Mailer отправляет служебные письма. Для офлайн-установок есть FileMailer (сохраняет письма в каталог),
+	// для разработки - LogMailer (печатает письмо в лог вместе с токенами из ссылок).
+	Mailer interface {
+		Send(ctx context.Context, message *model.MailMessage) error
+	}
+
+	LogMailer struct{}
+
+	FileMailer struct {
+		dir string
+	}
+)
+
+// New создаёт почтальона по названию; без названия возвращает ErrNotConfigured.
+// log доступен только при devMode: письма содержат токены подтверждения email и сброса пароля, и в логах им не место.
+func New(kind string, dir string, devMode bool) (Mailer, error) {
+	switch kind {
+	case "":
+		return nil, ErrNotConfigured
+	case KindLog:
+		if !devMode {
+			return nil, errors.New("mailer log prints tokens to stdout and is allowed only with DEV_MODE=true")
+		}
+		return LogMailer{}, nil
+	case KindFile:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		return &FileMailer{dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", kind)
+	}
+}
+
+func (LogMailer) Send(ctx context.Context, message *model.MailMessage) error {
+	fmt.Println("mail to:", message.To, "subject:", message.Subject)
+	fmt.Println(message.Body)
+	return nil
+}
+
+// Send сохраняет письмо в файл .eml, который открывается любым почтовым клиентом
+func (m *FileMailer) Send(ctx context.Context, message *model.MailMessage) error {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s_%s.eml", now.Format("20060102T150405.000000000"), sanitizeFileName(message.To))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(message.Body)
+
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(b.String()), 0o644)
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,16 @@
+package model
+
+type (
+	// AuthFlow - одноразовые параметры входа через провайдера: выдаются при старте входа,
+	// хранятся в cookie браузера и проверяются при обмене кода авторизации
+	AuthFlow struct {
+		Provider     string `json:"provider"`
+		State        string `json:"state"`
+		Nonce        string `json:"nonce"`
+		CodeVerifier string `json:"code_verifier"`
+	}
+
+	AuthFlowStart struct {
+		AuthURL string `json:"auth_url"`
+	}
+)
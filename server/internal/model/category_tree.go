@@ -0,0 +1,21 @@
+package model
+
+const (
+	// Что делать с подкатегориями при удалении категории
+	CategoryDeleteReject   CategoryDeletePolicy = "reject"   // отказать, если подкатегории есть
+	CategoryDeleteCascade  CategoryDeletePolicy = "cascade"  // удалить всё поддерево
+	CategoryDeleteReparent CategoryDeletePolicy = "reparent" // поднять подкатегории к родителю удаляемой
+)
+
+type (
+	CategoryDeletePolicy string
+
+	// CategoryTreeNode - категория с подкатегориями. ExerciseCount - упражнения самой категории,
+	// TotalExerciseCount - вместе со всеми подкатегориями.
+	CategoryTreeNode struct {
+		Category
+		ExerciseCount      int64               `json:"exercise_count"`
+		TotalExerciseCount int64               `json:"total_exercise_count"`
+		Children           []*CategoryTreeNode `json:"children"`
+	}
+)
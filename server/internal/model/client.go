@@ -0,0 +1,22 @@
+package model
+
+import "context"
+
+type clientInfoKey struct{}
+
+// ClientInfo user agent и IP клиента, которые сохраняются в сессии
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// WithClientInfo кладёт сведения о клиенте в контекст запроса
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext возвращает сведения о клиенте; если их нет, поля пустые
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
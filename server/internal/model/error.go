@@ -5,3 +5,7 @@ import "errors"
 var ErrorNotFound = errors.New("not found")
 var ErrorTargetTaskNotEmpty = errors.New("target task not empty")
 var ErrInvalidParameter = errors.New("Invalid parameter value")
+var ErrorForbidden = errors.New("forbidden")
+var ErrorUnauthorized = errors.New("unauthorized")
+var ErrorAlreadyExists = errors.New("already exists")
+var ErrorUnavailable = errors.New("unavailable")
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	// Провайдер учётных записей с входом по email и паролю
+	LocalProvider = "local"
+
+	AuthTokenPurposeVerifyEmail   = "verify_email"
+	AuthTokenPurposeResetPassword = "reset_password"
+
+	MinPasswordLength = 8
+	// bcrypt учитывает только первые 72 байта пароля
+	MaxPasswordBytes = 72
+
+	VerifyEmailTokenTTL   = 48 * time.Hour
+	ResetPasswordTokenTTL = time.Hour
+)
+
+type (
+	LocalCredentials struct {
+		UserID          UserID
+		Email           string
+		PasswordHash    string
+		EmailVerifiedAt *time.Time
+	}
+
+	AuthToken struct {
+		TokenHash string
+		UserID    UserID
+		Purpose   string
+		ExpiresAt time.Time
+	}
+
+	RegisterRequest struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Name     string `json:"name"`
+	}
+
+	PasswordLoginRequest struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	MailMessage struct {
+		To      string
+		Subject string
+		Body    string
+	}
+)
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"strings"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/golang-jwt/jwt"
 )
 
@@ -10,6 +12,9 @@ const (
 	Access_Token_Type  = "access_token"
 	Refresh_Token_Type = "refresh_Token"
 
+	// Типы событий безопасности
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+
 	// Константы для языков программирования
 	LanguagePython     = "python"
 	LanguageJavaScript = "javascript"
@@ -33,11 +38,53 @@ const (
 	CategoryStatusActive   = "active"
 	CategoryStatusInactive = "inactive"
 	CategoryStatusArchived = "archived"
+
+	// Режимы сравнения ответа с эталоном
+	ComparisonModeStrict           = "strict"
+	ComparisonModeIgnoreWhitespace = "ignore_whitespace"
+	ComparisonModeIgnoreComments   = "ignore_comments"
+	ComparisonModeToken            = "token"
+	DefaultComparisonMode          = ComparisonModeIgnoreWhitespace
+
+	// Типы расхождений при построчной проверке ответа
+	LineDiffMissing  = "missing"
+	LineDiffExtra    = "extra"
+	LineDiffMismatch = "mismatch"
+
+	// Формат переносимой выгрузки пользовательских данных
+	BundleVersion    = 1
+	BundleFormatJSON = "json"
+	BundleFormatYAML = "yaml"
+
+	// Что делать при импорте, если категория или упражнение с таким именем уже есть
+	ConflictStrategySkip   = "skip"
+	ConflictStrategyRename = "rename"
+
+	// Результат импорта отдельного файла
+	ImportFileImported = "imported"
+	ImportFileSkipped  = "skipped"
+	ImportFileFailed   = "failed"
+
+	// Границы значений, присылаемых клиентом за одну попытку
+	MaxAttemptTypingTime = 4 * 60 * 60 // 4 часа в секундах
+	MaxAttemptTypedChars = 100000
+	MaxAttemptBodyBytes  = 1 << 20
+	// Ответ длиннее эталона больше чем в MaxAnswerLengthRatio раз (с запасом MaxAnswerLengthSlack байт
+	// для коротких эталонов) не оценивается: сравнение строк квадратично по длине
+	MaxAnswerLengthRatio = 2
+	MaxAnswerLengthSlack = 1024
+	// Наибольшая длина кода упражнения в байтах: от неё зависит стоимость оценки ответа
+	MaxExerciseCodeLength = 16 << 10
+
+	// Период истории активности по умолчанию и наибольший допустимый
+	DefaultActivityDays = 365
+	MaxActivityDays     = 366
 )
 
 type (
 	UserID                  int64
 	ProgrammingLanguage     string
+	ComparisonMode          string
 	ExerciseStatus          string
 	CategoryID              int64
 	UserProfileFromProvider struct {
@@ -51,9 +98,10 @@ type (
 	}
 
 	User struct {
-		ID                 UserID `json:"user_id"`
-		Name               string `json:"name"`
-		IsAdmin            bool `json:"is_admin"`
+		ID      UserID `json:"user_id"`
+		Name    string `json:"name"`
+		IsAdmin bool   `json:"is_admin"`
+		Roles   Roles  `json:"roles"`
 	}
 
 	UserAuthProviders struct {
@@ -72,6 +120,7 @@ type (
 	Claims struct {
 		UserID    UserID `json:"user_id"`
 		IsAdmin   bool   `json:"is_admin"`
+		Roles     Roles  `json:"roles,omitempty"`
 		TokenType string `json:"token_type"` // Добавляем поле для типа токена
 		jwt.StandardClaims
 	}
@@ -90,6 +139,8 @@ type (
 		SuccessfulAttempts  *int                `json:"successful_attempts,omitempty"`
 		IsCommon            bool                `json:"is_common"`
 		CategoryName        string 				`json:"category_name"`
+		ComparisonMode      ComparisonMode      `json:"comparison_mode"`
+		Tags                []*Tag              `json:"tags,omitempty"`
 	}
 
 	Category struct {
@@ -105,6 +156,8 @@ type (
 		UpdatedAt           time.Time           `json:"updated_at"`
 		IsActive            bool                `json:"is_active"`
 		IsCommon            bool                `json:"is_common"`
+		ParentID            *int64              `json:"parent_id"` // родительская категория, nil у корневых
+		ParentIDSet         bool                `json:"-"`         // parent_id передан в запросе на изменение
 	}
 
 	CategoryListResponse struct {
@@ -123,6 +176,10 @@ type (
 		SuccessfulAttempts int    `json:"successful_attempts"`
 		TotalTypingTime    int64  `json:"total_typing_time"` // в секундах
 		TotalTypedChars    int    `json:"total_typed_chars"`
+		AverageScore       int    `json:"average_score"` // средняя оценка попыток 0-100
+		BestScore          int    `json:"best_score"`
+		LastScore          int    `json:"last_score"`
+		CharsPerMinute     int    `json:"chars_per_minute"` // скорость набора
 	}
 
 	// UserStats хранит агрегированную статистику пользователя
@@ -133,15 +190,172 @@ type (
 		AverageScore       int    `json:"average_score"`
 		TotalTime          int64  `json:"total_time"`
 		TotalAttempts      int    `json:"total_attempts"`
+		TotalTypedChars    int64  `json:"total_typed_chars"`
+		CharsPerMinute     int    `json:"chars_per_minute"`
+		CurrentStreak      int    `json:"current_streak"` // дней подряд с попытками
+		LongestStreak      int    `json:"longest_streak"`
+	}
+
+	// DailyActivity активность пользователя за один день в его часовом поясе
+	DailyActivity struct {
+		Date      string `json:"date"` // YYYY-MM-DD
+		Attempts  int    `json:"attempts"`
+		Successes int    `json:"successes"`
+		Minutes   int    `json:"minutes"`
 	}
 
+	UserActivity struct {
+		From          string           `json:"from"`
+		To            string           `json:"to"`
+		Timezone      string           `json:"timezone"`
+		Days          []*DailyActivity `json:"days"`
+		CurrentStreak int              `json:"current_streak"`
+		LongestStreak int              `json:"longest_streak"`
+	}
+
+	// ExerciseStatUpdate - телеметрия набора от клиента; результат попытки сюда не входит
 	ExerciseStatUpdate struct {
-		UserID          UserID `json:"user_id"`
-		ExerciseID      int64  `json:"exercise_id"`
-		Attempts        int    `json:"attempts"`         // всегда 1
-		SuccessAttempts int    `json:"success_attempts"` // 1 если успешно, 0 если нет
-		TypingTime      int64  `json:"typing_time"`      // в секундах
-		TypedChars      int    `json:"typed_chars"`
+		UserID     UserID `json:"user_id"`
+		ExerciseID int64  `json:"exercise_id"`
+		TypingTime int64  `json:"typing_time"` // в секундах
+		TypedChars int    `json:"typed_chars"`
+	}
+
+	// LineDiff описывает расхождение ответа пользователя с эталоном в одной строке
+	LineDiff struct {
+		Line     int    `json:"line"`
+		Type     string `json:"type"` // missing, extra, mismatch
+		Expected string `json:"expected"`
+		Actual   string `json:"actual"`
+	}
+
+	// BundleHeader заголовок выгрузки: версия формата и категории пользователя
+	BundleHeader struct {
+		Version    int               `json:"version" yaml:"version"`
+		ExportedAt time.Time         `json:"exported_at" yaml:"exported_at"`
+		Categories []*BundleCategory `json:"categories" yaml:"categories"`
+	}
+
+	// Bundle переносимая выгрузка категорий и упражнений пользователя
+	Bundle struct {
+		BundleHeader `yaml:",inline"`
+		Exercises    []*BundleExercise `json:"exercises" yaml:"exercises"`
+	}
+
+	BundleCategory struct {
+		ID                  int64               `json:"id" yaml:"id"`
+		Name                string              `json:"name" yaml:"name"`
+		Description         string              `json:"description,omitempty" yaml:"description,omitempty"`
+		ProgrammingLanguage ProgrammingLanguage `json:"programming_language" yaml:"programming_language"`
+		Color               string              `json:"color,omitempty" yaml:"color,omitempty"`
+		Icon                string              `json:"icon,omitempty" yaml:"icon,omitempty"`
+		Status              string              `json:"status,omitempty" yaml:"status,omitempty"`
+		ParentID            *int64              `json:"parent_id,omitempty" yaml:"parent_id,omitempty"` // ID родителя из той же выгрузки
+		// Общая категория: при импорте сопоставляется с существующей общей по имени и языку
+		IsCommon bool `json:"is_common,omitempty" yaml:"is_common,omitempty"`
+	}
+
+	BundleExercise struct {
+		ID                  int64               `json:"id" yaml:"id"`
+		CategoryID          int64               `json:"category_id" yaml:"category_id"`
+		Title               string              `json:"title" yaml:"title"`
+		Description         string              `json:"description,omitempty" yaml:"description,omitempty"`
+		ProgrammingLanguage ProgrammingLanguage `json:"programming_language" yaml:"programming_language"`
+		CodeToRemember      string              `json:"code_to_remember" yaml:"code_to_remember"`
+		ComparisonMode      ComparisonMode      `json:"comparison_mode,omitempty" yaml:"comparison_mode,omitempty"`
+		Stat                *BundleExerciseStat `json:"stat,omitempty" yaml:"stat,omitempty"`
+		Tags                []string            `json:"tags,omitempty" yaml:"tags,omitempty"` // названия меток
+	}
+
+	BundleExerciseStat struct {
+		TotalAttempts      int   `json:"total_attempts" yaml:"total_attempts"`
+		SuccessfulAttempts int   `json:"successful_attempts" yaml:"successful_attempts"`
+		TotalTypingTime    int64 `json:"total_typing_time" yaml:"total_typing_time"`
+		TotalTypedChars    int   `json:"total_typed_chars" yaml:"total_typed_chars"`
+		TotalScore         int64 `json:"total_score" yaml:"total_score"`
+		BestScore          int   `json:"best_score" yaml:"best_score"`
+		LastScore          int   `json:"last_score" yaml:"last_score"`
+	}
+
+	// BundleImportResult итог восстановления выгрузки; ID из выгрузки сопоставлены с новыми
+	BundleImportResult struct {
+		CategoriesCreated int             `json:"categories_created"`
+		CategoriesSkipped int             `json:"categories_skipped"`
+		CategoriesRenamed int             `json:"categories_renamed"`
+		CategoriesLinked  int             `json:"categories_linked"` // сопоставлены с общими категориями
+		ExercisesCreated  int             `json:"exercises_created"`
+		ExercisesSkipped  int             `json:"exercises_skipped"`
+		ExercisesRenamed  int             `json:"exercises_renamed"`
+		TagsCreated       int             `json:"tags_created"`
+		CategoryIDs       map[int64]int64 `json:"category_ids"`
+		ExerciseIDs       map[int64]int64 `json:"exercise_ids"`
+	}
+
+	// ExerciseImportRequest параметры массового импорта упражнений из архива
+	ExerciseImportRequest struct {
+		CategoryID     int64          `json:"category_id"`
+		IsCommon       bool           `json:"is_common"`
+		ComparisonMode ComparisonMode `json:"comparison_mode"`
+		DryRun         bool           `json:"dry_run"`
+		ArchiveName    string         `json:"archive_name"`
+		Archive        []byte         `json:"-"`
+	}
+
+	ImportFileResult struct {
+		Path      string      `json:"path"`
+		Status    string      `json:"status"` // imported, skipped, failed
+		Error     string      `json:"error,omitempty"`
+		Exercises []*Exercise `json:"exercises,omitempty"`
+	}
+
+	ImportResult struct {
+		DryRun     bool                `json:"dry_run"`
+		CategoryID int64               `json:"category_id"`
+		Imported   int                 `json:"imported"` // число упражнений
+		Failed     int                 `json:"failed"`   // число файлов с ошибками
+		Files      []*ImportFileResult `json:"files"`
+	}
+
+	// AttemptSubmission ответ пользователя, присланный на проверку
+	AttemptSubmission struct {
+		ExerciseID int64  `json:"exercise_id"`
+		Code       string `json:"code"`
+		TypingTime int64  `json:"typing_time"` // в секундах
+		TypedChars int    `json:"typed_chars"`
+	}
+
+	// ExerciseAttempt запись об одной попытке решения задачи
+	ExerciseAttempt struct {
+		ID         int64     `json:"id"`
+		UserID     UserID    `json:"user_id"`
+		ExerciseID int64     `json:"exercise_id"`
+		IsCorrect  bool      `json:"is_correct"`
+		Score      int       `json:"score"`
+		TypingTime int64     `json:"typing_time"` // в секундах
+		TypedChars int       `json:"typed_chars"`
+		CodeHash   string    `json:"code_hash"`
+		Revision   *int      `json:"revision"` // ревизия упражнения, с которой сравнивался ответ
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	ExerciseAttemptListResponse struct {
+		Attempts []*ExerciseAttempt `json:"attempts"`
+		Total    int                `json:"total"`
+		Page     int                `json:"page"`
+		PageSize int                `json:"page_size"`
+		HasNext  bool               `json:"has_next"`
+		HasPrev  bool               `json:"has_prev"`
+	}
+
+	// AttemptResult результат серверной проверки попытки
+	AttemptResult struct {
+		ExerciseID int64           `json:"exercise_id"`
+		IsCorrect  bool            `json:"is_correct"`
+		Score      int             `json:"score"` // 0-100, доля совпадения с эталоном
+		Revision   int             `json:"revision"`
+		Errors     []LineDiff      `json:"errors"`
+		Stat       *ExerciseStat   `json:"stat"`
+		Review     *ExerciseReview `json:"review"`
 	}
 
 	// UserExercise представляет связь пользователя с упражнением
@@ -161,8 +375,29 @@ type (
 	}
 
 	ExerciseDetailse struct {
-		UserIfo  UserInfo `json:"user_info"`
-		Exercise Exercise `json:"exercise"`
+		UserIfo  UserInfo        `json:"user_info"`
+		Exercise Exercise        `json:"exercise"`
+		Review   *ExerciseReview `json:"review,omitempty"`
+		Search   *SearchMatch    `json:"search,omitempty"`
+	}
+
+	// SearchMatch - ранг и подсветка совпадений, заполняются при поиске по q.
+	// Текст в Title и Snippet экранирован как HTML, совпадения обёрнуты в <mark>.
+	SearchMatch struct {
+		Rank    float64 `json:"rank"`
+		Title   string  `json:"title"`
+		Snippet string  `json:"snippet"`
+	}
+
+	// ExerciseReview хранит состояние интервального повторения (SM-2) по задаче
+	ExerciseReview struct {
+		UserID         UserID     `json:"user_id"`
+		ExerciseID     int64      `json:"exercise_id"`
+		EaseFactor     float64    `json:"ease_factor"`
+		IntervalDays   int        `json:"interval_days"`
+		Repetitions    int        `json:"repetitions"`
+		DueAt          time.Time  `json:"due_at"`
+		LastReviewedAt *time.Time `json:"last_reviewed_at"`
 	}
 
 	ExerciseListWithUserResponse struct {
@@ -183,6 +418,36 @@ type (
 		Revoked   bool      `json:"revoked"`
 		UserAgent string    `json:"user_agent"`
 		IPAddress string    `json:"ip_address"`
+		FamilyID  string    `json:"family_id"`
+		ParentID  *int64    `json:"parent_id,omitempty"`
+	}
+
+	// SecurityEvent - запись журнала событий безопасности
+	SecurityEvent struct {
+		ID        int64     `json:"id"`
+		UserID    UserID    `json:"user_id"`
+		EventType string    `json:"event_type"`
+		Details   string    `json:"details"`
+		UserAgent string    `json:"user_agent"`
+		IPAddress string    `json:"ip_address"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// Session - активный refresh-токен пользователя без самого токена
+	Session struct {
+		ID        int64     `json:"id"`
+		IssuedAt  time.Time `json:"issued_at"`
+		ExpiresAt time.Time `json:"expires_at"`
+		UserAgent string    `json:"user_agent"`
+		IPAddress string    `json:"ip_address"`
+		Current   bool      `json:"current"`
+	}
+
+	// LinkedProvider - способ входа, привязанный к аккаунту пользователя
+	LinkedProvider struct {
+		Provider string    `json:"provider"`
+		Name     string    `json:"name"`
+		LinkedAt time.Time `json:"linked_at"`
 	}
 	
 
@@ -205,6 +470,59 @@ func GetSupportedLanguages() []ProgrammingLanguage {
 	}
 }
 
+// GetComparisonModes возвращает список режимов сравнения ответа
+func GetComparisonModes() []ComparisonMode {
+	return []ComparisonMode{
+		ComparisonModeStrict,
+		ComparisonModeIgnoreWhitespace,
+		ComparisonModeIgnoreComments,
+		ComparisonModeToken,
+	}
+}
+
+// IsSupportedComparisonMode проверяет, известен ли режим сравнения
+func IsSupportedComparisonMode(mode ComparisonMode) bool {
+	for _, supportedMode := range GetComparisonModes() {
+		if supportedMode == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLanguage приводит название языка в свободной форме ("Python", "C++", "1С") к константе Language*
+func NormalizeLanguage(name string) (ProgrammingLanguage, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	switch normalized {
+	case "c++":
+		normalized = LanguageCpp
+	case "c#", "c sharp":
+		normalized = LanguageCSharp
+	case "js":
+		normalized = LanguageJavaScript
+	case "ts":
+		normalized = LanguageTypeScript
+	case "golang":
+		normalized = LanguageGo
+	case "1с": // кириллическая "С"
+		normalized = Language1C
+	}
+
+	language := ProgrammingLanguage(normalized)
+	if !IsSupportedLanguage(language) {
+		return "", false
+	}
+	return language, true
+}
+
+// CharsPerMinute считает скорость набора; без учтённого времени скорость равна нулю
+func CharsPerMinute(typedChars int64, typingTime int64) int {
+	if typingTime <= 0 || typedChars <= 0 {
+		return 0
+	}
+	return int(typedChars * 60 / typingTime)
+}
+
 // IsSupportedLanguage проверяет, поддерживается ли язык программирования
 func IsSupportedLanguage(language ProgrammingLanguage) bool {
 	supported := GetSupportedLanguages()
@@ -215,3 +533,12 @@ func IsSupportedLanguage(language ProgrammingLanguage) bool {
 	}
 	return false
 }
+
+// Validate проверяет поля ответа до проверки кода; длину кода проверяет сервис
+func (submission AttemptSubmission) Validate() error {
+	return validation.ValidateStruct(&submission,
+		validation.Field(&submission.ExerciseID, validation.Required, validation.Min(int64(1))),
+		validation.Field(&submission.TypingTime, validation.Min(int64(0)), validation.Max(int64(MaxAttemptTypingTime))),
+		validation.Field(&submission.TypedChars, validation.Min(0), validation.Max(MaxAttemptTypedChars)),
+	)
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+const (
+	// Типы строк в сравнении кода двух ревизий
+	RevisionDiffEqual   = "equal"
+	RevisionDiffAdded   = "added"
+	RevisionDiffRemoved = "removed"
+
+	// Построчное сравнение строит квадратичную таблицу, поэтому код длиннее не сравнивается
+	MaxRevisionDiffLines = 1000
+)
+
+type (
+	// ExerciseRevision - снимок содержимого упражнения. Категория в ревизию не входит:
+	// перенос в другую категорию не меняет то, что пользователь запоминает.
+	ExerciseRevision struct {
+		ID                  int64               `json:"id"`
+		ExerciseID          int64               `json:"exercise_id"`
+		Revision            int                 `json:"revision"`
+		Title               string              `json:"title"`
+		Description         string              `json:"description"`
+		ProgrammingLanguage ProgrammingLanguage `json:"programming_language"`
+		CodeToRemember      string              `json:"code_to_remember"`
+		ComparisonMode      ComparisonMode      `json:"comparison_mode"`
+		EditedBy            UserID              `json:"edited_by"`
+		CreatedAt           time.Time           `json:"created_at"`
+	}
+
+	ExerciseRevisionListResponse struct {
+		Revisions []*ExerciseRevision `json:"revisions"`
+		Total     int                 `json:"total"`
+		Page      int                 `json:"page"`
+		PageSize  int                 `json:"page_size"`
+		HasNext   bool                `json:"has_next"`
+		HasPrev   bool                `json:"has_prev"`
+	}
+
+	// RevisionDiffLine - строка кода в сравнении ревизий. OldLine и NewLine - номера строк
+	// в старой и новой версии, у добавленных строк OldLine пустой, у удалённых - NewLine.
+	RevisionDiffLine struct {
+		Type    string `json:"type"` // equal, added, removed
+		OldLine int    `json:"old_line,omitempty"`
+		NewLine int    `json:"new_line,omitempty"`
+		Text    string `json:"text"`
+	}
+
+	// ExerciseRevisionDiff - разница между ревизиями From и To: изменённые поля и построчное сравнение кода
+	ExerciseRevisionDiff struct {
+		ExerciseID    int64              `json:"exercise_id"`
+		From          *ExerciseRevision  `json:"from"`
+		To            *ExerciseRevision  `json:"to"`
+		ChangedFields []string           `json:"changed_fields"`
+		Code          []RevisionDiffLine `json:"code"`
+	}
+)
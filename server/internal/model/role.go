@@ -0,0 +1,85 @@
+package model
+
+type (
+	// Role - роль пользователя, набор ролей задаётся таблицей roles
+	Role string
+
+	// Permission - отдельное право, которое проверяет сервис
+	Permission string
+
+	Roles []Role
+
+	RoleInfo struct {
+		Name        Role         `json:"name"`
+		Description string       `json:"description"`
+		Permissions []Permission `json:"permissions"`
+	}
+
+	UserRoleChange struct {
+		UserID UserID `json:"user_id"`
+		Role   Role   `json:"role"`
+	}
+)
+
+const (
+	RoleAdmin         Role = "admin"
+	RoleContentEditor Role = "content_editor"
+	RoleModerator     Role = "moderator"
+)
+
+const (
+	// Создание и изменение общих (is_common) задач и категорий
+	PermissionManageCommonContent Permission = "manage_common_content"
+	// Удаление общих задач и категорий
+	PermissionModerateContent Permission = "moderate_content"
+	PermissionViewUsers       Permission = "view_users"
+	PermissionManageRoles     Permission = "manage_roles"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionManageCommonContent,
+		PermissionModerateContent,
+		PermissionViewUsers,
+		PermissionManageRoles,
+	},
+	RoleContentEditor: {
+		PermissionManageCommonContent,
+		PermissionModerateContent,
+	},
+	RoleModerator: {
+		PermissionModerateContent,
+		PermissionViewUsers,
+	},
+}
+
+func IsKnownRole(role Role) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions возвращает права роли; у неизвестной роли прав нет
+func RolePermissions(role Role) []Permission {
+	return rolePermissions[role]
+}
+
+func (r Roles) Has(role Role) bool {
+	for _, value := range r {
+		if value == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Can сообщает, даёт ли хотя бы одна из ролей указанное право
+func (r Roles) Can(permission Permission) bool {
+	for _, role := range r {
+		for _, value := range rolePermissions[role] {
+			if value == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
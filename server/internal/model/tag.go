@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	TagMatchAny TagMatchMode = "any"
+	TagMatchAll TagMatchMode = "all"
+
+	MaxTagNameLength = 64
+)
+
+type (
+	// TagMatchMode - как фильтр по нескольким меткам отбирает упражнения: хотя бы одна метка или все
+	TagMatchMode string
+
+	// Tag - метка упражнения. Личные метки видит только владелец, общие (UserID = 0) - все пользователи.
+	Tag struct {
+		ID        int64     `json:"id"`
+		UserID    UserID    `json:"user_id"`
+		Name      string    `json:"name"`
+		IsCommon  bool      `json:"is_common"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// TagWithCount - метка и число видимых пользователю активных упражнений с ней
+	TagWithCount struct {
+		Tag
+		ExerciseCount int `json:"exercise_count"`
+	}
+
+	TagFilter struct {
+		IDs  []int64
+		Mode TagMatchMode
+	}
+
+	// TagMerge - перенос упражнений с метки SourceID на TargetID с удалением SourceID
+	TagMerge struct {
+		SourceID int64 `json:"source_id"`
+		TargetID int64 `json:"target_id"`
+	}
+)
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+const (
+	TrashItemExercise = "exercise"
+	TrashItemCategory = "category"
+)
+
+type (
+	// TrashItem - удалённое упражнение или категория, которые можно восстановить.
+	// Записи, удалённые вместе с категорией, отдельно не показываются: они учтены в ExerciseCount
+	// и CategoryCount категории и восстанавливаются вместе с ней.
+	TrashItem struct {
+		Type          string    `json:"type"` // exercise, category
+		ID            int64     `json:"id"`
+		Title         string    `json:"title"`
+		CategoryID    int64     `json:"category_id,omitempty"`
+		IsCommon      bool      `json:"is_common"`
+		DeletedAt     time.Time `json:"deleted_at"`
+		DeletedBy     UserID    `json:"deleted_by"`
+		ExerciseCount int       `json:"exercise_count,omitempty"`
+		CategoryCount int       `json:"category_count,omitempty"`
+	}
+)
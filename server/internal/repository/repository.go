@@ -9,7 +9,6 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type (
@@ -33,12 +32,8 @@ type (
 )
 
 func NewPokerRepository(capacity int, conn DBTX) *Repository {
-	pool, ok := conn.(*pgxpool.Pool)
-	if !ok {
-		panic("connection must be *pgxpool.Pool")
-	}
-
-	queries := sqlc_repository.New(pool)
+	// conn может быть как пулом, так и транзакцией из TransactionProvider
+	queries := sqlc_repository.New(conn)
 
 	return &Repository{
 		conn:         conn,
@@ -49,20 +44,32 @@ func NewPokerRepository(capacity int, conn DBTX) *Repository {
 }
 
 // Методы упражнений - делегируем к ExerciseRepository
+// CreateExercise создаёт упражнение и его первую ревизию
 func (r *Repository) CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error) {
-	return r.exerciseRepo.CreateExercise(ctx, userID, isAdmin, exercise)
+	created, err := r.exerciseRepo.CreateExercise(ctx, userID, isAdmin, exercise)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.recordExerciseRevision(ctx, created.ID, userID); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 func (r *Repository) GetExercise(ctx context.Context, userID model.UserID, exerciseID int64) (*model.Exercise, error) {
 	return r.exerciseRepo.GetExercise(ctx, userID, exerciseID)
 }
 
+// UpdateExercise обновляет упражнение и пишет новую ревизию, если изменилось содержимое
 func (r *Repository) UpdateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error) {
-	return r.exerciseRepo.UpdateExercise(ctx, userID, isAdmin, exerciseID, exercise)
-}
-
-func (r *Repository) DeleteExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64) error {
-	return r.exerciseRepo.DeleteExercise(ctx, userID, isAdmin, exerciseID)
+	updated, err := r.exerciseRepo.UpdateExercise(ctx, userID, isAdmin, exerciseID, exercise)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.recordExerciseRevision(ctx, updated.ID, userID); err != nil {
+		return nil, err
+	}
+	return updated, nil
 }
 
 // Методы категорий - делегируем к CategoryRepository
@@ -71,31 +78,41 @@ func (r *Repository) CreateCategory(ctx context.Context, userID model.UserID, is
 }
 
 func (r *Repository) GetCategories(ctx context.Context, userID model.UserID) ([]*model.Category, int, error) {
-	return r.categoryRepo.GetCategories(ctx, userID)
+	categories, total, err := r.categoryRepo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.fillCategoryParents(ctx, categories...); err != nil {
+		return nil, 0, err
+	}
+	return categories, total, nil
 }
 
 func (r *Repository) GetCategory(ctx context.Context, userID model.UserID, categoryID int64) (*model.Category, error) {
-	return r.categoryRepo.GetCategory(ctx, userID, categoryID)
+	category, err := r.categoryRepo.GetCategory(ctx, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.fillCategoryParents(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
 }
 
 func (r *Repository) UpdateCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64, category *model.Category) (*model.Category, error) {
 	return r.categoryRepo.UpdateCategory(ctx, userID, isAdmin, categoryID, category)
 }
 
-func (r *Repository) DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error {
-	return r.categoryRepo.DeleteCategory(ctx, userID, isAdmin, categoryID)
-}
-
 func (r *Repository) CountExercisesByCategory(ctx context.Context, categoryID int64) (int64, error) {
 	return r.categoryRepo.CountExercisesByCategory(ctx, categoryID)
 }
 
-func (r *Repository) GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
-	return r.exerciseRepo.GetExercisesFiltered(ctx, userID, language, categoryID, page, pageSize)
+func (r *Repository) GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+	return r.exerciseRepo.GetExercisesFiltered(ctx, userID, language, categoryID, query, tags, page, pageSize)
 }
 
-func (r *Repository) UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts int, successful int) (*model.ExerciseStat, error) {
-	return r.exerciseRepo.UpsertExerciseStat(ctx, userID, exerciseID, attempts, successful, 0, 0)
+func (r *Repository) UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts int, successful int, typingTime int64, typedChars int, score int) (*model.ExerciseStat, error) {
+	return r.exerciseRepo.UpsertExerciseStat(ctx, userID, exerciseID, attempts, successful, typingTime, typedChars, score)
 }
 
 func (r *Repository) GetExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseStat, error) {
@@ -106,8 +123,8 @@ func (r *Repository) GetUserStats(ctx context.Context, userID model.UserID) (*mo
 	return r.exerciseRepo.GetUserStats(ctx, userID)
 }
 
-func (r *Repository) GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
-	return r.exerciseRepo.GetUserExercisesFiltered(ctx, userID, language, categoryID, page, pageSize)
+func (r *Repository) GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+	return r.exerciseRepo.GetUserExercisesFiltered(ctx, userID, language, categoryID, query, tags, page, pageSize)
 }
 
 func (r *Repository) AddUserExercise(ctx context.Context, userID model.UserID, exerciseID int64) error {
@@ -132,8 +149,8 @@ func (r *Repository) IsUserExercise(ctx context.Context, userID model.UserID, ex
 
 // Методы для refresh-токенов
 func (r *Repository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
-	_, err := r.conn.Exec(ctx, `INSERT INTO refresh_tokens (user_id, token, issued_at, expires_at, revoked, user_agent, ip_address) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		token.UserID, token.Token, token.IssuedAt, token.ExpiresAt, token.Revoked, token.UserAgent, token.IPAddress)
+	_, err := r.conn.Exec(ctx, `INSERT INTO refresh_tokens (user_id, token, issued_at, expires_at, revoked, user_agent, ip_address, family_id, parent_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		token.UserID, token.Token, token.IssuedAt, token.ExpiresAt, token.Revoked, token.UserAgent, token.IPAddress, token.FamilyID, token.ParentID)
 
 	// Проверяем на ошибку дублирования
 	if err != nil {
@@ -147,17 +164,37 @@ func (r *Repository) CreateRefreshToken(ctx context.Context, token *model.Refres
 }
 
 func (r *Repository) GetRefreshTokenByToken(ctx context.Context, token string) (*model.RefreshToken, error) {
-	row := r.conn.QueryRow(ctx, `SELECT id, user_id, token, issued_at, expires_at, revoked, user_agent, ip_address FROM refresh_tokens WHERE token = $1`, token)
+	row := r.conn.QueryRow(ctx, `SELECT id, user_id, token, issued_at, expires_at, revoked, COALESCE(user_agent, ''), COALESCE(ip_address, ''), family_id, parent_id FROM refresh_tokens WHERE token = $1`, token)
 	var rt model.RefreshToken
-	err := row.Scan(&rt.ID, &rt.UserID, &rt.Token, &rt.IssuedAt, &rt.ExpiresAt, &rt.Revoked, &rt.UserAgent, &rt.IPAddress)
+	err := row.Scan(&rt.ID, &rt.UserID, &rt.Token, &rt.IssuedAt, &rt.ExpiresAt, &rt.Revoked, &rt.UserAgent, &rt.IPAddress, &rt.FamilyID, &rt.ParentID)
 	if err != nil {
 		return nil, err
 	}
 	return &rt, nil
 }
 
-func (r *Repository) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := r.conn.Exec(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE token = $1`, token)
+// RevokeRefreshToken отзывает токен и возвращает false, если он уже был отозван раньше.
+// Так из двух одновременных обменов одного токена успешным будет только один.
+func (r *Repository) RevokeRefreshToken(ctx context.Context, token string) (bool, error) {
+	tag, err := r.conn.Exec(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE token = $1 AND revoked = FALSE`, token)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RevokeRefreshTokenFamily отзывает все токены семейства и возвращает число отозванных
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) (int64, error) {
+	tag, err := r.conn.Exec(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE family_id = $1 AND revoked = FALSE`, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *Repository) CreateSecurityEvent(ctx context.Context, event *model.SecurityEvent) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO security_events (user_id, event_type, details, user_agent, ip_address, created_at) VALUES ($1, $2, $3, $4, $5, NOW())`,
+		event.UserID, event.EventType, event.Details, event.UserAgent, event.IPAddress)
 	return err
 }
 
@@ -171,8 +208,52 @@ func (r *Repository) DeleteAllUserRefreshTokens(ctx context.Context, userID mode
 	return err
 }
 
-// CleanupExpiredTokens удаляет истекшие токены
+// GetActiveRefreshTokens возвращает неотозванные и неистекшие refresh-токены пользователя, новые первыми
+func (r *Repository) GetActiveRefreshTokens(ctx context.Context, userID model.UserID) ([]*model.RefreshToken, error) {
+	rows, err := r.conn.Query(ctx, `SELECT id, user_id, token, issued_at, expires_at, revoked, COALESCE(user_agent, ''), COALESCE(ip_address, ''), family_id, parent_id
+		FROM refresh_tokens
+		WHERE user_id = $1 AND revoked = FALSE AND expires_at > NOW()
+		ORDER BY issued_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*model.RefreshToken{}
+	for rows.Next() {
+		var rt model.RefreshToken
+		if err := rows.Scan(&rt.ID, &rt.UserID, &rt.Token, &rt.IssuedAt, &rt.ExpiresAt, &rt.Revoked, &rt.UserAgent, &rt.IPAddress, &rt.FamilyID, &rt.ParentID); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, &rt)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeUserSession отзывает семейство токенов сессии. Строки остаются до истечения срока,
+// чтобы повторное предъявление токена завершённой сессии попало в журнал безопасности
+func (r *Repository) RevokeUserSession(ctx context.Context, userID model.UserID, tokenID int64) error {
+	tag, err := r.conn.Exec(ctx, `UPDATE refresh_tokens SET revoked = TRUE
+		WHERE user_id = $2 AND revoked = FALSE
+			AND family_id = (SELECT family_id FROM refresh_tokens WHERE id = $1 AND user_id = $2)`, tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: session %d", model.ErrorNotFound, tokenID)
+	}
+	return nil
+}
+
+// RevokeAllUserRefreshTokens отзывает все токены пользователя, не удаляя их (см. RevokeUserSession)
+func (r *Repository) RevokeAllUserRefreshTokens(ctx context.Context, userID model.UserID) error {
+	_, err := r.conn.Exec(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE`, userID)
+	return err
+}
+
+// CleanupExpiredTokens удаляет истекшие токены.
+// Отозванные токены хранятся до истечения срока, чтобы распознать их повторное предъявление.
 func (r *Repository) CleanupExpiredTokens(ctx context.Context) error {
-	_, err := r.conn.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW() OR revoked = TRUE`)
+	_, err := r.conn.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
 	return err
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"inzarubin80/MemCode/internal/model"
+)
+
+func (r *Repository) GetUserTimezone(ctx context.Context, userID model.UserID) (string, error) {
+	var timezone string
+	err := r.conn.QueryRow(ctx, `SELECT timezone FROM users WHERE user_id = $1`, userID).Scan(&timezone)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %v", model.ErrorNotFound, err)
+		}
+		return "", err
+	}
+	return timezone, nil
+}
+
+// SetUserTimezone сохраняет пояс, только если его знает Postgres: база часовых поясов Go может отличаться
+func (r *Repository) SetUserTimezone(ctx context.Context, userID model.UserID, timezone string) error {
+	var known bool
+	err := r.conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = $1)`, timezone).Scan(&known)
+	if err != nil {
+		return err
+	}
+	if !known {
+		return fmt.Errorf("%w: unknown timezone %s", model.ErrInvalidParameter, timezone)
+	}
+
+	tag, err := r.conn.Exec(ctx, `UPDATE users SET timezone = $2 WHERE user_id = $1`, userID, timezone)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: user %d", model.ErrorNotFound, userID)
+	}
+	return nil
+}
+
+// GetUserDailyActivity группирует попытки пользователя по дням в его часовом поясе.
+// Возвращаются только дни, в которые была хотя бы одна попытка.
+func (r *Repository) GetUserDailyActivity(ctx context.Context, userID model.UserID, timezone string, from, to time.Time) ([]*model.DailyActivity, error) {
+	rows, err := r.conn.Query(ctx, `SELECT
+			(created_at AT TIME ZONE $2)::date AS day,
+			COUNT(*) AS attempts,
+			COUNT(*) FILTER (WHERE is_correct) AS successes,
+			COALESCE(SUM(typing_time), 0)::bigint AS typing_time
+		FROM exercise_attempts
+		WHERE user_id = $1
+			AND (created_at AT TIME ZONE $2)::date BETWEEN $3::date AND $4::date
+		GROUP BY day
+		ORDER BY day`, userID, timezone, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := []*model.DailyActivity{}
+	for rows.Next() {
+		var (
+			day        time.Time
+			typingTime int64
+			item       model.DailyActivity
+		)
+		if err := rows.Scan(&day, &item.Attempts, &item.Successes, &typingTime); err != nil {
+			return nil, err
+		}
+		item.Date = day.Format(time.DateOnly)
+		item.Minutes = int((typingTime + 30) / 60)
+		activity = append(activity, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// GetUserStreaks считает текущую и самую длинную серию дней подряд с попытками.
+// Текущая серия не прерывается, пока не закончился день после последней попытки.
+func (r *Repository) GetUserStreaks(ctx context.Context, userID model.UserID, timezone string) (current int, longest int, err error) {
+	row := r.conn.QueryRow(ctx, `WITH days AS (
+			SELECT DISTINCT (created_at AT TIME ZONE $2)::date AS day
+			FROM exercise_attempts
+			WHERE user_id = $1
+		), streaks AS (
+			SELECT MAX(day) AS last_day, COUNT(*) AS length
+			FROM (SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS grp FROM days) grouped
+			GROUP BY grp
+		)
+		SELECT
+			COALESCE(MAX(length) FILTER (WHERE last_day >= (NOW() AT TIME ZONE $2)::date - 1), 0)::int,
+			COALESCE(MAX(length), 0)::int
+		FROM streaks`, userID, timezone)
+	err = row.Scan(&current, &longest)
+	return current, longest, err
+}
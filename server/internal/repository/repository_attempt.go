@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"inzarubin80/MemCode/internal/model"
+	sqlc_repository "inzarubin80/MemCode/internal/repository_sqlc"
+)
+
+// AddExerciseTypingTime добавляет к статистике время набора и число символов.
+// Попытки, успехи и оценки не меняются: их пишет только SubmitAttempt.
+func (r *Repository) AddExerciseTypingTime(ctx context.Context, userID model.UserID, exerciseID int64, typingTime int64, typedChars int) (*model.ExerciseStat, error) {
+	var stat sqlc_repository.ExerciseStat
+	err := r.conn.QueryRow(ctx, `INSERT INTO exercise_stats (user_id, exercise_id, total_typing_time, total_typed_chars, created_at, updated_at)
+		SELECT $1, e.id, $3, $4, NOW(), NOW()
+		FROM exercises e WHERE e.id = $2 AND e.is_active = TRUE AND e.user_id IN ($1, 0)
+		ON CONFLICT (user_id, exercise_id) DO UPDATE SET
+			total_typing_time = exercise_stats.total_typing_time + EXCLUDED.total_typing_time,
+			total_typed_chars = exercise_stats.total_typed_chars + EXCLUDED.total_typed_chars,
+			updated_at = NOW()
+		RETURNING user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at, total_score, best_score, last_score`,
+		userID, exerciseID, typingTime, typedChars).
+		Scan(&stat.UserID, &stat.ExerciseID, &stat.TotalAttempts, &stat.SuccessfulAttempts, &stat.TotalTypingTime, &stat.TotalTypedChars,
+			&stat.CreatedAt, &stat.UpdatedAt, &stat.TotalScore, &stat.BestScore, &stat.LastScore)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: exercise %d", model.ErrorNotFound, exerciseID)
+		}
+		return nil, err
+	}
+	return convertDBExerciseStatToModel(&stat), nil
+}
+
+func (r *Repository) CreateExerciseAttempt(ctx context.Context, attempt *model.ExerciseAttempt) (*model.ExerciseAttempt, error) {
+	// Если ревизия не указана (результат прислан клиентом), записываем текущую
+	row := r.conn.QueryRow(ctx, `INSERT INTO exercise_attempts (user_id, exercise_id, is_correct, score, typing_time, typed_chars, code_hash, revision, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, (SELECT revision FROM exercises WHERE id = $2)), NOW())
+		RETURNING id, revision, created_at`,
+		attempt.UserID, attempt.ExerciseID, attempt.IsCorrect, attempt.Score, attempt.TypingTime, attempt.TypedChars, attempt.CodeHash, attempt.Revision)
+
+	created := *attempt
+	if err := row.Scan(&created.ID, &created.Revision, &created.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetExerciseAttempts возвращает историю попыток пользователя по задаче, начиная с последних
+func (r *Repository) GetExerciseAttempts(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) ([]*model.ExerciseAttempt, int, error) {
+	var total int
+	row := r.conn.QueryRow(ctx, `SELECT COUNT(*) FROM exercise_attempts WHERE user_id = $1 AND exercise_id = $2`, userID, exerciseID)
+	if err := row.Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	rows, err := r.conn.Query(ctx, `SELECT id, user_id, exercise_id, is_correct, score, typing_time, typed_chars, code_hash, revision, created_at
+		FROM exercise_attempts
+		WHERE user_id = $1 AND exercise_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, userID, exerciseID, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	attempts := []*model.ExerciseAttempt{}
+	for rows.Next() {
+		var attempt model.ExerciseAttempt
+		err := rows.Scan(&attempt.ID, &attempt.UserID, &attempt.ExerciseID, &attempt.IsCorrect, &attempt.Score,
+			&attempt.TypingTime, &attempt.TypedChars, &attempt.CodeHash, &attempt.Revision, &attempt.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return attempts, total, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+
+	"inzarubin80/MemCode/internal/model"
+)
+
+const bundleCategoryColumns = `id, name, COALESCE(description, ''), programming_language,
+			COALESCE(color, ''), COALESCE(icon, ''), COALESCE(status, ''), user_id = 0, parent_id`
+
+// GetOwnedCategories возвращает категории, созданные пользователем, и общие категории,
+// в которых есть его упражнения: без них выгрузка ссылалась бы на отсутствующие категории
+func (r *Repository) GetOwnedCategories(ctx context.Context, userID model.UserID) ([]*model.BundleCategory, error) {
+	return r.queryBundleCategories(ctx, `SELECT `+bundleCategoryColumns+`
+		FROM categories c
+		WHERE c.is_active = TRUE AND (c.user_id = $1 OR (c.user_id = 0 AND EXISTS (
+			SELECT 1 FROM exercises e WHERE e.category_id = c.id AND e.user_id = $1 AND e.is_active = TRUE)))
+		ORDER BY c.id`, userID)
+}
+
+// GetCommonCategories возвращает все активные общие категории
+func (r *Repository) GetCommonCategories(ctx context.Context) ([]*model.BundleCategory, error) {
+	return r.queryBundleCategories(ctx, `SELECT `+bundleCategoryColumns+`
+		FROM categories
+		WHERE user_id = 0 AND is_active = TRUE
+		ORDER BY id`)
+}
+
+func (r *Repository) queryBundleCategories(ctx context.Context, query string, args ...interface{}) ([]*model.BundleCategory, error) {
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*model.BundleCategory{}
+	for rows.Next() {
+		var category model.BundleCategory
+		err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.ProgrammingLanguage,
+			&category.Color, &category.Icon, &category.Status, &category.IsCommon, &category.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+	return categories, rows.Err()
+}
+
+// GetOwnedExercises возвращает страницу упражнений пользователя с ID больше afterID.
+// Статистика заполняется только при withStats и только если пользователь решал упражнение.
+func (r *Repository) GetOwnedExercises(ctx context.Context, userID model.UserID, afterID int64, limit int, withStats bool) ([]*model.BundleExercise, error) {
+	rows, err := r.conn.Query(ctx, `SELECT e.id, e.category_id, e.title, COALESCE(e.description, ''), e.programming_language,
+			e.code_to_remember, e.comparison_mode,
+			es.total_attempts, es.successful_attempts, es.total_typing_time, es.total_typed_chars,
+			es.total_score, es.best_score, es.last_score
+		FROM exercises e
+		LEFT JOIN exercise_stats es ON es.exercise_id = e.id AND es.user_id = e.user_id
+		WHERE e.user_id = $1 AND e.is_active = TRUE AND e.id > $2
+		ORDER BY e.id
+		LIMIT $3`, userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []*model.BundleExercise{}
+	for rows.Next() {
+		var (
+			exercise           model.BundleExercise
+			totalAttempts      *int
+			successfulAttempts *int
+			totalTypingTime    *int64
+			totalTypedChars    *int
+			totalScore         *int64
+			bestScore          *int
+			lastScore          *int
+		)
+		err := rows.Scan(&exercise.ID, &exercise.CategoryID, &exercise.Title, &exercise.Description, &exercise.ProgrammingLanguage,
+			&exercise.CodeToRemember, &exercise.ComparisonMode,
+			&totalAttempts, &successfulAttempts, &totalTypingTime, &totalTypedChars,
+			&totalScore, &bestScore, &lastScore)
+		if err != nil {
+			return nil, err
+		}
+		if withStats && totalAttempts != nil {
+			exercise.Stat = &model.BundleExerciseStat{
+				TotalAttempts:      *totalAttempts,
+				SuccessfulAttempts: *successfulAttempts,
+				TotalTypingTime:    *totalTypingTime,
+				TotalTypedChars:    *totalTypedChars,
+				TotalScore:         *totalScore,
+				BestScore:          *bestScore,
+				LastScore:          *lastScore,
+			}
+		}
+		exercises = append(exercises, &exercise)
+	}
+	return exercises, rows.Err()
+}
+
+// RestoreExerciseStat переносит статистику из выгрузки (значения проверяются сервисом);
+// уже накопленная статистика не перезаписывается
+func (r *Repository) RestoreExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, stat *model.BundleExerciseStat) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO exercise_stats (user_id, exercise_id, total_attempts, successful_attempts,
+			total_typing_time, total_typed_chars, total_score, best_score, last_score, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		ON CONFLICT (user_id, exercise_id) DO NOTHING`,
+		userID, exerciseID, stat.TotalAttempts, stat.SuccessfulAttempts,
+		stat.TotalTypingTime, stat.TotalTypedChars, stat.TotalScore, stat.BestScore, stat.LastScore)
+	return err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+)
+
+// Ключ advisory-блокировки, под которой меняется структура дерева категорий.
+// Без неё два встречных переноса в разных транзакциях могут вместе образовать цикл.
+const categoryTreeLockKey = 7_203_150_001
+
+// subtreeCTE - категория $1 и все её потомки
+const subtreeCTE = `
+WITH RECURSIVE subtree AS (
+	SELECT id FROM categories WHERE id = $1
+	UNION
+	SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)`
+
+// fillCategoryParents дописывает parent_id, которого нет в запросах sqlc
+func (r *Repository) fillCategoryParents(ctx context.Context, categories ...*model.Category) error {
+	ids := make([]int64, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := r.conn.Query(ctx, `SELECT id, parent_id FROM categories WHERE id = ANY($1) AND parent_id IS NOT NULL`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	parents := make(map[int64]int64)
+	for rows.Next() {
+		var id, parentID int64
+		if err := rows.Scan(&id, &parentID); err != nil {
+			return err
+		}
+		parents[id] = parentID
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		if parentID, ok := parents[category.ID]; ok {
+			category.ParentID = &parentID
+		}
+	}
+	return nil
+}
+
+// CountExercisesByCategories считает видимые пользователю активные упражнения по категориям,
+// так же как CountExercisesByCategory, но для всех категорий одним запросом
+func (r *Repository) CountExercisesByCategories(ctx context.Context, userID model.UserID) (map[int64]int64, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT e.category_id, COUNT(*)
+		FROM exercises e
+		JOIN categories c ON c.id = e.category_id AND c.is_active = TRUE
+		WHERE e.is_active = TRUE AND e.user_id IN ($1, 0)
+		GROUP BY e.category_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var categoryID, count int64
+		if err := rows.Scan(&categoryID, &count); err != nil {
+			return nil, err
+		}
+		counts[categoryID] = count
+	}
+	return counts, rows.Err()
+}
+
+// LockCategoryTree берёт блокировку дерева категорий до конца транзакции.
+// Вне транзакции блокировка сразу снимается и ничего не защищает.
+func (r *Repository) LockCategoryTree(ctx context.Context) error {
+	_, err := r.conn.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(categoryTreeLockKey))
+	return err
+}
+
+// SetCategoryParent переносит категорию под parentID (nil - в корень).
+// Перенос под саму категорию или её потомка отклоняется с ErrInvalidParameter.
+func (r *Repository) SetCategoryParent(ctx context.Context, categoryID int64, parentID *int64) error {
+	tag, err := r.conn.Exec(ctx, subtreeCTE+`
+		UPDATE categories SET parent_id = $2, updated_at = NOW()
+		WHERE id = $1 AND ($2::bigint IS NULL OR $2 NOT IN (SELECT id FROM subtree))`, categoryID, parentID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: category %d cannot be moved under itself or its subcategory", model.ErrInvalidParameter, categoryID)
+	}
+	return nil
+}
+
+func (r *Repository) CountChildCategories(ctx context.Context, categoryID int64) (int, error) {
+	var count int
+	err := r.conn.QueryRow(ctx, `SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND is_active = TRUE`, categoryID).Scan(&count)
+	return count, err
+}
+
+// DeleteCategoryDescendants переносит в корзину все подкатегории вместе с упражнениями их владельца,
+// саму категорию не трогает. Всё перенесённое восстанавливается вместе с категорией.
+func (r *Repository) DeleteCategoryDescendants(ctx context.Context, categoryID int64, deletedBy model.UserID) error {
+	_, err := r.conn.Exec(ctx, subtreeCTE+`,
+		deleted AS (
+			UPDATE categories SET is_active = FALSE, deleted_at = NOW(), deleted_by = $2, deleted_with_category_id = $1, updated_at = NOW()
+			WHERE id IN (SELECT id FROM subtree) AND id <> $1 AND is_active = TRUE
+			RETURNING id, user_id
+		)
+		UPDATE exercises SET is_active = FALSE, deleted_at = NOW(), deleted_by = $2, deleted_with_category_id = $1
+		WHERE (category_id, user_id) IN (SELECT id, user_id FROM deleted) AND is_active = TRUE`, categoryID, deletedBy)
+	return err
+}
+
+// ReparentChildCategories переносит прямых потомков категории к её родителю
+func (r *Repository) ReparentChildCategories(ctx context.Context, categoryID int64) error {
+	_, err := r.conn.Exec(ctx, `
+		UPDATE categories SET parent_id = (SELECT parent_id FROM categories WHERE id = $1), updated_at = NOW()
+		WHERE parent_id = $1 AND is_active = TRUE`, categoryID)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"inzarubin80/MemCode/internal/model"
 	sqlc_repository "inzarubin80/MemCode/internal/repository_sqlc"
+	"math"
 )
 
 type ExerciseRepository struct {
@@ -32,6 +33,7 @@ func (r *ExerciseRepository) CreateExercise(ctx context.Context, userID model.Us
 		ProgrammingLanguage: string(exercise.ProgrammingLanguage),
 		CodeToRemember:      exercise.CodeToRemember,
 		IsCommon:            &exercise.IsCommon,
+		ComparisonMode:      string(exercise.ComparisonMode),
 	}
 
 	sqlcExercise, err := r.queries.CreateExercise(ctx, params)
@@ -51,6 +53,7 @@ func (r *ExerciseRepository) CreateExercise(ctx context.Context, userID model.Us
 		UpdatedAt:           sqlcExercise.UpdatedAt.Time,
 		IsActive:            *sqlcExercise.IsActive,
 		IsCommon:            *sqlcExercise.IsCommon,
+		ComparisonMode:      model.ComparisonMode(sqlcExercise.ComparisonMode),
 	}, nil
 }
 
@@ -74,6 +77,7 @@ func (r *ExerciseRepository) GetExercise(ctx context.Context, userID model.UserI
 		UpdatedAt:           sqlcExercise.UpdatedAt.Time,
 		IsActive:            *sqlcExercise.IsActive,
 		IsCommon:            *sqlcExercise.IsCommon,
+		ComparisonMode:      model.ComparisonMode(sqlcExercise.ComparisonMode),
 	}, nil
 }
 
@@ -93,6 +97,7 @@ func (r *ExerciseRepository) UpdateExercise(ctx context.Context, userID model.Us
 		ProgrammingLanguage: string(exercise.ProgrammingLanguage),
 		IsCommon:            &exercise.IsCommon,
 		Column9:             isAdmin,
+		ComparisonMode:      string(exercise.ComparisonMode),
 	}
 
 	sqlcExercise, err := r.queries.UpdateExercise(ctx, params)
@@ -112,6 +117,7 @@ func (r *ExerciseRepository) UpdateExercise(ctx context.Context, userID model.Us
 		UpdatedAt:           sqlcExercise.UpdatedAt.Time,
 		IsActive:            *sqlcExercise.IsActive,
 		IsCommon:            *sqlcExercise.IsCommon,
+		ComparisonMode:      model.ComparisonMode(sqlcExercise.ComparisonMode),
 	}, nil
 }
 
@@ -130,11 +136,14 @@ func (r *ExerciseRepository) DeleteExercise(ctx context.Context, userID model.Us
 	return nil
 }
 
-func (r *ExerciseRepository) GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+func (r *ExerciseRepository) GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
 	var langValue string
 	if language != nil && *language != "" {
 		langValue = *language
 	}
+	if query != "" || categoryID != 0 || (tags != nil && len(tags.IDs) > 0) {
+		return r.searchExercises(ctx, searchExercises, userID, langValue, categoryID, query, tags, page, pageSize)
+	}
 
 	// Получаем общее количество
 	countParams := &sqlc_repository.CountExercisesFilteredParams{
@@ -183,6 +192,7 @@ func (r *ExerciseRepository) GetExercisesFiltered(ctx context.Context, userID mo
 				IsActive:            isActive,
 				IsCommon:            *row.IsCommon,
 				CategoryName:        row.CategoryName,
+				ComparisonMode:      model.ComparisonMode(row.ComparisonMode),
 			},
 			UserIfo: model.UserInfo{
 				IsSolved:       row.IsSolved,
@@ -202,17 +212,10 @@ func (r *ExerciseRepository) GetExerciseStat(ctx context.Context, userID model.U
 	if err != nil {
 		return nil, err
 	}
-	return &model.ExerciseStat{
-		UserID:             model.UserID(stat.UserID),
-		ExerciseID:         stat.ExerciseID,
-		TotalAttempts:      int(stat.TotalAttempts),
-		SuccessfulAttempts: int(stat.SuccessfulAttempts),
-		TotalTypingTime:    stat.TotalTypingTime,
-		TotalTypedChars:    int(stat.TotalTypedChars),
-	}, nil
+	return convertDBExerciseStatToModel(stat), nil
 }
 
-func (r *ExerciseRepository) UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts, successAttempts int, typingTime int64, typedChars int) (*model.ExerciseStat, error) {
+func (r *ExerciseRepository) UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts, successAttempts int, typingTime int64, typedChars int, score int) (*model.ExerciseStat, error) {
 	params := sqlc_repository.UpsertExerciseStatParams{
 		UserID:             int64(userID),
 		ExerciseID:         exerciseID,
@@ -220,11 +223,20 @@ func (r *ExerciseRepository) UpsertExerciseStat(ctx context.Context, userID mode
 		SuccessfulAttempts: int32(successAttempts),
 		TotalTypingTime:    typingTime,
 		TotalTypedChars:    int32(typedChars),
+		TotalScore:         int64(score),
 	}
 	stat, err := r.queries.UpsertExerciseStat(ctx, &params)
 	if err != nil {
 		return nil, err
 	}
+	return convertDBExerciseStatToModel(stat), nil
+}
+
+func convertDBExerciseStatToModel(stat *sqlc_repository.ExerciseStat) *model.ExerciseStat {
+	averageScore := 0
+	if stat.TotalAttempts > 0 {
+		averageScore = int(math.Round(float64(stat.TotalScore) / float64(stat.TotalAttempts)))
+	}
 	return &model.ExerciseStat{
 		UserID:             model.UserID(stat.UserID),
 		ExerciseID:         stat.ExerciseID,
@@ -232,7 +244,11 @@ func (r *ExerciseRepository) UpsertExerciseStat(ctx context.Context, userID mode
 		SuccessfulAttempts: int(stat.SuccessfulAttempts),
 		TotalTypingTime:    stat.TotalTypingTime,
 		TotalTypedChars:    int(stat.TotalTypedChars),
-	}, nil
+		AverageScore:       averageScore,
+		BestScore:          int(stat.BestScore),
+		LastScore:          int(stat.LastScore),
+		CharsPerMinute:     model.CharsPerMinute(int64(stat.TotalTypedChars), stat.TotalTypingTime),
+	}
 }
 
 func (r *ExerciseRepository) GetUserStats(ctx context.Context, userID model.UserID) (*model.UserStats, error) {
@@ -249,14 +265,19 @@ func (r *ExerciseRepository) GetUserStats(ctx context.Context, userID model.User
 		AverageScore:       int(stats.AverageScore),
 		TotalTime:          stats.TotalTime,
 		TotalAttempts:      int(stats.TotalAttempts),
+		TotalTypedChars:    stats.TotalTypedChars,
+		CharsPerMinute:     model.CharsPerMinute(stats.TotalTypedChars, stats.TotalTime),
 	}, nil
 }
 
-func (r *ExerciseRepository) GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+func (r *ExerciseRepository) GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
 	var langValue string
 	if language != nil && *language != "" {
 		langValue = *language
 	}
+	if query != "" || categoryID != 0 || (tags != nil && len(tags.IDs) > 0) {
+		return r.searchExercises(ctx, searchUserExercises, userID, langValue, categoryID, query, tags, page, pageSize)
+	}
 
 	// Получаем общее количество
 	countParams := &sqlc_repository.CountUserExercisesFilteredParams{
@@ -311,6 +332,7 @@ func (r *ExerciseRepository) GetUserExercisesFiltered(ctx context.Context, userI
 				IsActive:            isActive,
 				IsCommon:            isCommon,
 				CategoryName:        row.CategoryName,
+				ComparisonMode:      model.ComparisonMode(row.ComparisonMode),
 			},
 			UserIfo: model.UserInfo{
 				IsSolved:       row.IsSolved,
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"inzarubin80/MemCode/internal/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *Repository) CreateLocalCredentials(ctx context.Context, credentials *model.LocalCredentials) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO local_credentials (user_id, email, password_hash, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())`,
+		credentials.UserID, credentials.Email, credentials.PasswordHash, credentials.EmailVerifiedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%w: email already registered", model.ErrorAlreadyExists)
+		}
+	}
+	return err
+}
+
+func (r *Repository) GetLocalCredentialsByEmail(ctx context.Context, email string) (*model.LocalCredentials, error) {
+	var credentials model.LocalCredentials
+	err := r.conn.QueryRow(ctx, `SELECT user_id, email, password_hash, email_verified_at FROM local_credentials WHERE email = $1`, email).
+		Scan(&credentials.UserID, &credentials.Email, &credentials.PasswordHash, &credentials.EmailVerifiedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", model.ErrorNotFound, err)
+		}
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+func (r *Repository) GetLocalCredentials(ctx context.Context, userID model.UserID) (*model.LocalCredentials, error) {
+	var credentials model.LocalCredentials
+	err := r.conn.QueryRow(ctx, `SELECT user_id, email, password_hash, email_verified_at FROM local_credentials WHERE user_id = $1`, userID).
+		Scan(&credentials.UserID, &credentials.Email, &credentials.PasswordHash, &credentials.EmailVerifiedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", model.ErrorNotFound, err)
+		}
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+func (r *Repository) SetLocalPasswordHash(ctx context.Context, userID model.UserID, passwordHash string) error {
+	_, err := r.conn.Exec(ctx, `UPDATE local_credentials SET password_hash = $2, updated_at = NOW() WHERE user_id = $1`, userID, passwordHash)
+	return err
+}
+
+func (r *Repository) MarkLocalEmailVerified(ctx context.Context, userID model.UserID) error {
+	_, err := r.conn.Exec(ctx, `UPDATE local_credentials SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *Repository) CreateAuthToken(ctx context.Context, token *model.AuthToken) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO auth_tokens (token_hash, user_id, purpose, expires_at, created_at) VALUES ($1, $2, $3, $4, NOW())`,
+		token.TokenHash, token.UserID, token.Purpose, token.ExpiresAt)
+	return err
+}
+
+// ConsumeAuthToken помечает токен использованным и возвращает его владельца.
+// Использованный, просроченный или неизвестный токен даёт ErrorNotFound.
+func (r *Repository) ConsumeAuthToken(ctx context.Context, tokenHash string, purpose string) (model.UserID, error) {
+	var userID model.UserID
+	err := r.conn.QueryRow(ctx, `UPDATE auth_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash, purpose).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%w: %v", model.ErrorNotFound, err)
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// DeleteUserAuthTokens удаляет неиспользованные токены пользователя с указанным назначением
+func (r *Repository) DeleteUserAuthTokens(ctx context.Context, userID model.UserID, purpose string) error {
+	_, err := r.conn.Exec(ctx, `DELETE FROM auth_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userID, purpose)
+	return err
+}
+
+func (r *Repository) DeleteLocalCredentials(ctx context.Context, userID model.UserID) error {
+	_, err := r.conn.Exec(ctx, `DELETE FROM local_credentials WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"inzarubin80/MemCode/internal/model"
+)
+
+// IsExerciseArchived сообщает, лежит ли упражнение в архивной категории.
+// Такие упражнения можно открыть, но не решать: архив скрыт из практики целиком.
+func (r *Repository) IsExerciseArchived(ctx context.Context, exerciseID int64) (bool, error) {
+	var archived bool
+	err := r.conn.QueryRow(ctx, `SELECT c.status IS NOT DISTINCT FROM $2
+		FROM exercises e
+		JOIN categories c ON c.id = e.category_id
+		WHERE e.id = $1`, exerciseID, model.CategoryStatusArchived).Scan(&archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("%w: exercise %d", model.ErrorNotFound, exerciseID)
+		}
+		return false, err
+	}
+	return archived, nil
+}
+
+func (r *Repository) GetExerciseReview(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseReview, error) {
+	row := r.conn.QueryRow(ctx, `SELECT user_id, exercise_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+		FROM exercise_reviews WHERE user_id = $1 AND exercise_id = $2`, userID, exerciseID)
+
+	var review model.ExerciseReview
+	err := row.Scan(&review.UserID, &review.ExerciseID, &review.EaseFactor, &review.IntervalDays, &review.Repetitions, &review.DueAt, &review.LastReviewedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", model.ErrorNotFound, err)
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *Repository) UpsertExerciseReview(ctx context.Context, review *model.ExerciseReview) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO exercise_reviews (user_id, exercise_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (user_id, exercise_id) DO UPDATE SET
+			ease_factor = EXCLUDED.ease_factor,
+			interval_days = EXCLUDED.interval_days,
+			repetitions = EXCLUDED.repetitions,
+			due_at = EXCLUDED.due_at,
+			last_reviewed_at = EXCLUDED.last_reviewed_at,
+			updated_at = NOW()`,
+		review.UserID, review.ExerciseID, review.EaseFactor, review.IntervalDays, review.Repetitions, review.DueAt, review.LastReviewedAt)
+	return err
+}
+
+// UpdateUserExerciseProgress обновляет прогресс по задаче из списка пользователя, если она в нём есть
+func (r *Repository) UpdateUserExerciseProgress(ctx context.Context, userID model.UserID, exerciseID int64, isCorrect bool, score int) error {
+	_, err := r.conn.Exec(ctx, `UPDATE user_exercises SET
+			attempts_count = COALESCE(attempts_count, 0) + 1,
+			completed_at = CASE WHEN $3::boolean THEN COALESCE(completed_at, NOW()) ELSE completed_at END,
+			score = $4,
+			updated_at = NOW()
+		WHERE user_id = $1 AND exercise_id = $2`, userID, exerciseID, isCorrect, score)
+	return err
+}
+
+// GetDueExercises возвращает задачи из списка пользователя, которые пора повторить до момента until.
+// Задачи, которые ещё ни разу не проверялись, считаются готовыми к повторению.
+// Задачи из архивных категорий в повторение не попадают, хотя в списках остаются (см. IsExerciseArchived).
+func (r *Repository) GetDueExercises(ctx context.Context, userID model.UserID, until time.Time, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+	var total int
+	row := r.conn.QueryRow(ctx, `SELECT COUNT(*) FROM user_exercises ue
+		JOIN exercises e ON e.id = ue.exercise_id AND e.is_active = TRUE
+		JOIN categories c ON c.id = e.category_id AND c.is_active = TRUE AND c.status IS DISTINCT FROM $3
+		LEFT JOIN exercise_reviews rv ON rv.exercise_id = ue.exercise_id AND rv.user_id = ue.user_id
+		WHERE ue.user_id = $1 AND (rv.due_at IS NULL OR rv.due_at < $2)`, userID, until, model.CategoryStatusArchived)
+	if err := row.Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	rows, err := r.conn.Query(ctx, `SELECT
+			e.id, e.user_id, e.title, e.description, e.category_id, e.programming_language, e.code_to_remember,
+			e.created_at, e.updated_at, e.is_active, e.is_common, e.comparison_mode, c.name,
+			COALESCE(es.successful_attempts, 0) > 0 AS is_solved,
+			rv.ease_factor, rv.interval_days, rv.repetitions, rv.due_at, rv.last_reviewed_at
+		FROM user_exercises ue
+		JOIN exercises e ON e.id = ue.exercise_id AND e.is_active = TRUE
+		JOIN categories c ON c.id = e.category_id AND c.is_active = TRUE AND c.status IS DISTINCT FROM $5
+		LEFT JOIN exercise_stats es ON es.exercise_id = ue.exercise_id AND es.user_id = ue.user_id
+		LEFT JOIN exercise_reviews rv ON rv.exercise_id = ue.exercise_id AND rv.user_id = ue.user_id
+		WHERE ue.user_id = $1 AND (rv.due_at IS NULL OR rv.due_at < $2)
+		ORDER BY rv.due_at ASC NULLS FIRST, e.id ASC
+		LIMIT $3 OFFSET $4`, userID, until, pageSize, offset, model.CategoryStatusArchived)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	detailseList := []*model.ExerciseDetailse{}
+	for rows.Next() {
+		var (
+			exercise     model.Exercise
+			description  *string
+			isActive     *bool
+			isCommon     *bool
+			isSolved     bool
+			easeFactor   *float64
+			intervalDays *int
+			repetitions  *int
+			dueAt        *time.Time
+			lastReviewed *time.Time
+		)
+		err := rows.Scan(&exercise.ID, &exercise.UserID, &exercise.Title, &description, &exercise.CategoryID, &exercise.ProgrammingLanguage, &exercise.CodeToRemember,
+			&exercise.CreatedAt, &exercise.UpdatedAt, &isActive, &isCommon, &exercise.ComparisonMode, &exercise.CategoryName,
+			&isSolved,
+			&easeFactor, &intervalDays, &repetitions, &dueAt, &lastReviewed)
+		if err != nil {
+			return nil, 0, err
+		}
+		exercise.Description = derefString(description)
+		exercise.IsActive = derefBool(isActive)
+		exercise.IsCommon = derefBool(isCommon)
+
+		detailse := &model.ExerciseDetailse{
+			Exercise: exercise,
+			UserIfo: model.UserInfo{
+				IsSolved:       isSolved,
+				IsUserExercise: true,
+			},
+		}
+		if dueAt != nil {
+			detailse.Review = &model.ExerciseReview{
+				UserID:         userID,
+				ExerciseID:     exercise.ID,
+				EaseFactor:     *easeFactor,
+				IntervalDays:   *intervalDays,
+				Repetitions:    *repetitions,
+				DueAt:          *dueAt,
+				LastReviewedAt: lastReviewed,
+			}
+		}
+		detailseList = append(detailseList, detailse)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return detailseList, total, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+)
+
+const revisionColumns = `r.id, r.exercise_id, r.revision, r.title, r.description, r.programming_language,
+	r.code_to_remember, r.comparison_mode, r.edited_by, r.created_at`
+
+// recordExerciseRevision сохраняет текущее содержимое упражнения новой ревизией.
+// Если содержимое не отличается от текущей ревизии, ничего не пишется.
+func (r *Repository) recordExerciseRevision(ctx context.Context, exerciseID int64, editedBy model.UserID) error {
+	_, err := r.conn.Exec(ctx, `
+		WITH changed AS (
+			SELECT e.id, e.title, COALESCE(e.description, '') AS description, e.programming_language, e.code_to_remember, e.comparison_mode
+			FROM exercises e
+			WHERE e.id = $1 AND NOT EXISTS (
+				SELECT 1 FROM exercise_revisions r
+				WHERE r.exercise_id = e.id AND r.revision = e.revision
+					AND r.title = e.title AND r.description = COALESCE(e.description, '')
+					AND r.programming_language = e.programming_language
+					AND r.code_to_remember = e.code_to_remember AND r.comparison_mode = e.comparison_mode
+			)
+		),
+		bumped AS (
+			UPDATE exercises e SET revision = e.revision + 1
+			FROM changed c WHERE e.id = c.id
+			RETURNING e.revision
+		)
+		INSERT INTO exercise_revisions (exercise_id, revision, title, description, programming_language, code_to_remember, comparison_mode, edited_by)
+		SELECT c.id, b.revision, c.title, c.description, c.programming_language, c.code_to_remember, c.comparison_mode, $2
+		FROM changed c CROSS JOIN bumped b`, exerciseID, editedBy)
+	return err
+}
+
+// GetExerciseRevisions возвращает ревизии видимого пользователю упражнения, начиная с последней
+func (r *Repository) GetExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) ([]*model.ExerciseRevision, int, error) {
+	offset := (page - 1) * pageSize
+	rows, err := r.conn.Query(ctx, `SELECT `+revisionColumns+`, COUNT(*) OVER ()
+		FROM exercise_revisions r
+		JOIN exercises e ON e.id = r.exercise_id AND e.is_active = TRUE AND e.user_id IN ($1, 0)
+		WHERE r.exercise_id = $2
+		ORDER BY r.revision DESC
+		LIMIT $3 OFFSET $4`, userID, exerciseID, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	revisions := []*model.ExerciseRevision{}
+	for rows.Next() {
+		var revision model.ExerciseRevision
+		err := rows.Scan(&revision.ID, &revision.ExerciseID, &revision.Revision, &revision.Title, &revision.Description,
+			&revision.ProgrammingLanguage, &revision.CodeToRemember, &revision.ComparisonMode, &revision.EditedBy, &revision.CreatedAt, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		revisions = append(revisions, &revision)
+	}
+	return revisions, total, rows.Err()
+}
+
+// GetExerciseRevision возвращает ревизию видимого пользователю упражнения; revision = 0 - текущую
+func (r *Repository) GetExerciseRevision(ctx context.Context, userID model.UserID, exerciseID int64, revisionNumber int) (*model.ExerciseRevision, error) {
+	var revision model.ExerciseRevision
+	err := r.conn.QueryRow(ctx, `SELECT `+revisionColumns+`
+		FROM exercise_revisions r
+		JOIN exercises e ON e.id = r.exercise_id AND e.is_active = TRUE AND e.user_id IN ($1, 0)
+		WHERE r.exercise_id = $2 AND r.revision = CASE WHEN $3::int = 0 THEN e.revision ELSE $3 END`, userID, exerciseID, revisionNumber).
+		Scan(&revision.ID, &revision.ExerciseID, &revision.Revision, &revision.Title, &revision.Description,
+			&revision.ProgrammingLanguage, &revision.CodeToRemember, &revision.ComparisonMode, &revision.EditedBy, &revision.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: revision %d of exercise %d", model.ErrorNotFound, revisionNumber, exerciseID)
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"inzarubin80/MemCode/internal/model"
+)
+
+// GetRoles возвращает все роли из справочника вместе с их правами
+func (r *Repository) GetRoles(ctx context.Context) ([]*model.RoleInfo, error) {
+	rows, err := r.conn.Query(ctx, `SELECT name, description FROM roles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []*model.RoleInfo{}
+	for rows.Next() {
+		var role model.RoleInfo
+		if err := rows.Scan(&role.Name, &role.Description); err != nil {
+			return nil, err
+		}
+		role.Permissions = model.RolePermissions(role.Name)
+		roles = append(roles, &role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *Repository) GetUserRoles(ctx context.Context, userID model.UserID) (model.Roles, error) {
+	rows, err := r.conn.Query(ctx, `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := model.Roles{}
+	for rows.Next() {
+		var role model.Role
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GetAllUserRoles возвращает роли всех пользователей, у которых они есть
+func (r *Repository) GetAllUserRoles(ctx context.Context) (map[model.UserID]model.Roles, error) {
+	rows, err := r.conn.Query(ctx, `SELECT user_id, role FROM user_roles ORDER BY user_id, role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := map[model.UserID]model.Roles{}
+	for rows.Next() {
+		var (
+			userID model.UserID
+			role   model.Role
+		)
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = append(roles[userID], role)
+	}
+	return roles, rows.Err()
+}
+
+// GrantUserRole выдаёт роль пользователю; флаг users.is_admin поддерживается в соответствии с ролью admin
+func (r *Repository) GrantUserRole(ctx context.Context, userID model.UserID, role model.Role, grantedBy model.UserID) error {
+	_, err := r.conn.Exec(ctx, `WITH granted AS (
+			INSERT INTO user_roles (user_id, role, granted_by, granted_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (user_id, role) DO NOTHING
+		)
+		UPDATE users SET is_admin = TRUE WHERE user_id = $1 AND $2 = $4`, userID, role, grantedBy, model.RoleAdmin)
+	return err
+}
+
+func (r *Repository) RevokeUserRole(ctx context.Context, userID model.UserID, role model.Role) error {
+	_, err := r.conn.Exec(ctx, `WITH revoked AS (
+			DELETE FROM user_roles WHERE user_id = $1 AND role = $2
+		)
+		UPDATE users SET is_admin = FALSE WHERE user_id = $1 AND $2 = $3`, userID, role, model.RoleAdmin)
+	return err
+}
+
+// LockUsersWithRole возвращает пользователей с ролью и блокирует их строки до конца транзакции,
+// чтобы параллельные отзывы роли не обошли проверку по количеству
+func (r *Repository) LockUsersWithRole(ctx context.Context, role model.Role) ([]model.UserID, error) {
+	rows, err := r.conn.Query(ctx, `SELECT user_id FROM user_roles WHERE role = $1 ORDER BY user_id FOR UPDATE`, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []model.UserID{}
+	for rows.Next() {
+		var userID model.UserID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Списки упражнений с поиском по q, фильтром по меткам или по категории вместе с подкатегориями.
+// Без этих фильтров используются запросы sqlc.
+//
+// Совпадение по q ищется двумя способами: полнотекстово по search_vector и по триграммам,
+// когда каждое слово запроса похоже на слово из названия или кода. Триграммы находят
+// части идентификаторов и опечатки, которых нет в словаре tsvector.
+// Ранг - ts_rank_cd по весам полей плюс наибольшая триграммная похожесть; без q он нулевой
+// и порядок совпадает с обычным списком.
+//
+// Параметры: $1 - пользователь, $2 - язык, $3 - категория с подкатегориями, $4 - q, $5 - слова q,
+// $6 - ID меток, $7 - нужны все метки, $8 - limit, $9 - offset.
+const (
+	searchQueryCTE = `
+WITH RECURSIVE q AS (
+	SELECT websearch_to_tsquery('russian', $4) || websearch_to_tsquery('simple', $4) AS tsq
+),
+category_tree AS (
+	SELECT id FROM categories WHERE id = $3
+	UNION
+	SELECT c.id FROM categories c JOIN category_tree t ON c.parent_id = t.id
+)`
+
+	searchConditions = `
+	AND ($4::text = '' OR e.search_vector @@ q.tsq OR NOT EXISTS (
+		SELECT 1 FROM unnest($5::text[]) AS term
+		WHERE NOT (term <% e.title OR term <% e.code_to_remember)
+	))
+	AND (cardinality($6::bigint[]) = 0 OR (
+		SELECT COUNT(*) FROM exercise_tags et
+		JOIN tags t ON t.id = et.tag_id AND t.user_id IN ($1, 0)
+		WHERE et.exercise_id = e.id AND et.tag_id = ANY($6)
+	) >= CASE WHEN $7::boolean THEN cardinality($6) ELSE 1 END)`
+
+	searchRank = `CASE WHEN $4::text = '' THEN 0
+		ELSE ts_rank_cd(e.search_vector, q.tsq) + GREATEST(word_similarity($4, e.title), word_similarity($4, e.code_to_remember))
+	END AS rank`
+
+	searchExercises = searchQueryCTE + `
+SELECT
+	e.id, e.user_id, e.title, COALESCE(e.description, ''), e.category_id, e.programming_language, e.code_to_remember,
+	e.created_at, e.updated_at, COALESCE(e.is_active, TRUE), COALESCE(e.is_common, FALSE), e.comparison_mode,
+	ue.exercise_id IS NOT NULL AS is_user_exercise,
+	COALESCE(es.successful_attempts, 0) > 0 AS is_solved,
+	c.name AS category_name,
+	` + searchRank + `,
+	COUNT(*) OVER () AS total
+FROM exercises e
+CROSS JOIN q
+LEFT JOIN user_exercises ue ON ue.exercise_id = e.id AND ue.user_id = $1
+LEFT JOIN exercise_stats es ON es.exercise_id = e.id AND es.user_id = $1
+JOIN categories c ON c.id = e.category_id AND c.is_active = TRUE
+WHERE e.user_id IN ($1, 0)
+	AND e.is_active = TRUE
+	AND ($2::varchar = '' OR e.programming_language = $2)
+	AND ($3::bigint = 0 OR e.category_id IN (SELECT id FROM category_tree))` + searchConditions + `
+ORDER BY rank DESC, e.programming_language ASC, e.category_id ASC, e.created_at DESC
+LIMIT $8 OFFSET $9`
+
+	searchUserExercises = searchQueryCTE + `
+SELECT
+	e.id, e.user_id, e.title, COALESCE(e.description, ''), e.category_id, c.programming_language, e.code_to_remember,
+	e.created_at, e.updated_at, COALESCE(e.is_active, TRUE), COALESCE(e.is_common, FALSE), e.comparison_mode,
+	TRUE AS is_user_exercise,
+	COALESCE(es.successful_attempts, 0) > 0 AS is_solved,
+	c.name AS category_name,
+	` + searchRank + `,
+	COUNT(*) OVER () AS total
+FROM user_exercises ue
+JOIN exercises e ON e.id = ue.exercise_id AND e.is_active = TRUE
+JOIN categories c ON c.id = e.category_id AND c.is_active = TRUE
+CROSS JOIN q
+LEFT JOIN exercise_stats es ON es.exercise_id = e.id AND es.user_id = $1
+WHERE ue.user_id = $1
+	AND ($2::varchar = '' OR c.programming_language = $2)
+	AND ($3::bigint = 0 OR e.category_id IN (SELECT id FROM category_tree))` + searchConditions + `
+ORDER BY rank DESC, c.programming_language ASC, e.category_id ASC, e.created_at DESC
+LIMIT $8 OFFSET $9`
+)
+
+// searchExercises возвращает страницу упражнений по поисковому запросу и фильтру меток
+func (r *ExerciseRepository) searchExercises(ctx context.Context, sql string, userID model.UserID, language string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+	terms := strings.Fields(query)
+	tagIDs := []int64{}
+	matchAllTags := false
+	if tags != nil {
+		tagIDs = tags.IDs
+		matchAllTags = tags.Mode == model.TagMatchAll
+	}
+	offset := (page - 1) * pageSize
+
+	rows, err := r.conn.Query(ctx, sql, int64(userID), language, categoryID, query, terms, tagIDs, matchAllTags, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	exercises := make([]*model.ExerciseDetailse, 0, pageSize)
+	for rows.Next() {
+		var (
+			exercise       model.Exercise
+			userInfo       model.UserInfo
+			search         model.SearchMatch
+			language       string
+			comparisonMode string
+			createdAt      pgtype.Timestamptz
+			updatedAt      pgtype.Timestamptz
+		)
+		err := rows.Scan(
+			&exercise.ID, &exercise.UserID, &exercise.Title, &exercise.Description, &exercise.CategoryID, &language, &exercise.CodeToRemember,
+			&createdAt, &updatedAt, &exercise.IsActive, &exercise.IsCommon, &comparisonMode,
+			&userInfo.IsUserExercise,
+			&userInfo.IsSolved,
+			&exercise.CategoryName,
+			&search.Rank,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		exercise.ProgrammingLanguage = model.ProgrammingLanguage(language)
+		exercise.CreatedAt = createdAt.Time
+		exercise.UpdatedAt = updatedAt.Time
+		exercise.ComparisonMode = model.ComparisonMode(comparisonMode)
+
+		detailse := &model.ExerciseDetailse{
+			Exercise: exercise,
+			UserIfo:  userInfo,
+		}
+		if query != "" {
+			detailse.Search = &search
+		}
+		exercises = append(exercises, detailse)
+	}
+	return exercises, total, rows.Err()
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"inzarubin80/MemCode/internal/model"
+)
+
+// UpsertCommonCategory создаёт или обновляет общую категорию (user_id = 0), найденную по имени
+func (r *Repository) UpsertCommonCategory(ctx context.Context, category *model.Category) (int64, bool, error) {
+	var id int64
+	err := r.conn.QueryRow(ctx, `SELECT id FROM categories WHERE user_id = 0 AND name = $1 ORDER BY id LIMIT 1`, category.Name).Scan(&id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	if err == nil {
+		_, err = r.conn.Exec(ctx, `UPDATE categories SET
+				description = $2, programming_language = $3, color = $4, icon = $5, status = $6,
+				is_active = TRUE, is_common = TRUE, updated_at = NOW()
+			WHERE id = $1`,
+			id, category.Description, category.ProgrammingLanguage, category.Color, category.Icon, category.Status)
+		return id, false, err
+	}
+
+	err = r.conn.QueryRow(ctx, `INSERT INTO categories (user_id, name, description, programming_language, color, icon, status, is_active, is_common, created_at, updated_at)
+		VALUES (0, $1, $2, $3, $4, $5, $6, TRUE, TRUE, NOW(), NOW())
+		RETURNING id`,
+		category.Name, category.Description, category.ProgrammingLanguage, category.Color, category.Icon, category.Status).Scan(&id)
+	return id, true, err
+}
+
+// UpsertCommonExercise создаёт или обновляет общее упражнение (user_id = 0), найденное по названию в категории
+func (r *Repository) UpsertCommonExercise(ctx context.Context, exercise *model.Exercise) (int64, bool, error) {
+	var id int64
+	err := r.conn.QueryRow(ctx, `SELECT id FROM exercises WHERE user_id = 0 AND category_id = $1 AND title = $2 ORDER BY id LIMIT 1`,
+		exercise.CategoryID, exercise.Title).Scan(&id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	if err == nil {
+		_, err = r.conn.Exec(ctx, `UPDATE exercises SET
+				description = $2, programming_language = $3, code_to_remember = $4,
+				is_active = TRUE, is_common = TRUE, updated_at = NOW()
+			WHERE id = $1`,
+			id, exercise.Description, exercise.ProgrammingLanguage, exercise.CodeToRemember)
+		if err != nil {
+			return 0, false, err
+		}
+		return id, false, r.recordExerciseRevision(ctx, id, 0)
+	}
+
+	err = r.conn.QueryRow(ctx, `INSERT INTO exercises (user_id, title, description, category_id, programming_language, code_to_remember, comparison_mode, is_active, is_common, created_at, updated_at)
+		VALUES (0, $1, $2, $3, $4, $5, $6, TRUE, TRUE, NOW(), NOW())
+		RETURNING id`,
+		exercise.Title, exercise.Description, exercise.CategoryID, exercise.ProgrammingLanguage, exercise.CodeToRemember, exercise.ComparisonMode).Scan(&id)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, r.recordExerciseRevision(ctx, id, 0)
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"inzarubin80/MemCode/internal/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *Repository) GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT t.id, t.user_id, t.name, t.created_at, COUNT(e.id)
+		FROM tags t
+		LEFT JOIN exercise_tags et ON et.tag_id = t.id
+		LEFT JOIN exercises e ON e.id = et.exercise_id AND e.is_active = TRUE AND e.user_id IN ($1, 0)
+		WHERE t.user_id IN ($1, 0)
+		GROUP BY t.id
+		ORDER BY lower(t.name), t.user_id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]*model.TagWithCount, 0)
+	for rows.Next() {
+		var tag model.TagWithCount
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt, &tag.ExerciseCount); err != nil {
+			return nil, err
+		}
+		tag.IsCommon = tag.UserID == 0
+		tags = append(tags, &tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetTag возвращает метку, если она видна пользователю: личная или общая
+func (r *Repository) GetTag(ctx context.Context, userID model.UserID, tagID int64) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.conn.QueryRow(ctx, `SELECT id, user_id, name, created_at FROM tags WHERE id = $1 AND user_id IN ($2, 0)`, tagID, userID).
+		Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: tag %d", model.ErrorNotFound, tagID)
+		}
+		return nil, err
+	}
+	tag.IsCommon = tag.UserID == 0
+	return &tag, nil
+}
+
+func (r *Repository) CreateTag(ctx context.Context, ownerID model.UserID, name string) (*model.Tag, error) {
+	tag := model.Tag{UserID: ownerID, Name: name, IsCommon: ownerID == 0}
+	err := r.conn.QueryRow(ctx, `INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING id, created_at`, ownerID, name).
+		Scan(&tag.ID, &tag.CreatedAt)
+	if err != nil {
+		return nil, tagNameError(err)
+	}
+	return &tag, nil
+}
+
+func (r *Repository) RenameTag(ctx context.Context, tagID int64, name string) error {
+	_, err := r.conn.Exec(ctx, `UPDATE tags SET name = $2, updated_at = NOW() WHERE id = $1`, tagID, name)
+	return tagNameError(err)
+}
+
+func (r *Repository) DeleteTag(ctx context.Context, tagID int64) error {
+	_, err := r.conn.Exec(ctx, `DELETE FROM tags WHERE id = $1`, tagID)
+	return err
+}
+
+// MergeTags переносит упражнения на целевую метку и удаляет исходную одним запросом
+func (r *Repository) MergeTags(ctx context.Context, sourceID int64, targetID int64) error {
+	_, err := r.conn.Exec(ctx, `
+		WITH moved AS (
+			INSERT INTO exercise_tags (exercise_id, tag_id)
+			SELECT exercise_id, $2 FROM exercise_tags WHERE tag_id = $1
+			ON CONFLICT DO NOTHING
+		)
+		DELETE FROM tags WHERE id = $1`, sourceID, targetID)
+	return err
+}
+
+func (r *Repository) AddExerciseTag(ctx context.Context, exerciseID int64, tagID int64) error {
+	_, err := r.conn.Exec(ctx, `INSERT INTO exercise_tags (exercise_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, exerciseID, tagID)
+	return err
+}
+
+func (r *Repository) RemoveExerciseTag(ctx context.Context, exerciseID int64, tagID int64) error {
+	tag, err := r.conn.Exec(ctx, `DELETE FROM exercise_tags WHERE exercise_id = $1 AND tag_id = $2`, exerciseID, tagID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: exercise %d has no tag %d", model.ErrorNotFound, exerciseID, tagID)
+	}
+	return nil
+}
+
+// GetExercisesTags возвращает видимые пользователю метки упражнений, сгруппированные по ID упражнения
+func (r *Repository) GetExercisesTags(ctx context.Context, userID model.UserID, exerciseIDs []int64) (map[int64][]*model.Tag, error) {
+	tags := make(map[int64][]*model.Tag)
+	if len(exerciseIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := r.conn.Query(ctx, `
+		SELECT et.exercise_id, t.id, t.user_id, t.name, t.created_at
+		FROM exercise_tags et
+		JOIN tags t ON t.id = et.tag_id AND t.user_id IN ($1, 0)
+		WHERE et.exercise_id = ANY($2)
+		ORDER BY lower(t.name)`, userID, exerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			exerciseID int64
+			tag        model.Tag
+		)
+		if err := rows.Scan(&exerciseID, &tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tag.IsCommon = tag.UserID == 0
+		tags[exerciseID] = append(tags[exerciseID], &tag)
+	}
+	return tags, rows.Err()
+}
+
+func tagNameError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: tag with this name already exists", model.ErrInvalidParameter)
+	}
+	return err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"inzarubin80/MemCode/internal/model"
+)
+
+// DeleteExercise переносит упражнение в корзину
+func (r *Repository) DeleteExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64) error {
+	_, err := r.conn.Exec(ctx, `
+		UPDATE exercises SET is_active = FALSE, deleted_at = NOW(), deleted_by = $2, deleted_with_category_id = NULL
+		WHERE id = $1 AND is_active = TRUE AND ($3::boolean OR user_id = $2)`, exerciseID, userID, isAdmin)
+	return err
+}
+
+// DeleteCategory переносит категорию в корзину вместе с активными упражнениями её владельца.
+// Упражнения других пользователей не трогаются, их наличие проверяет CountForeignExercises.
+func (r *Repository) DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error {
+	_, err := r.conn.Exec(ctx, `
+		WITH deleted AS (
+			UPDATE categories SET is_active = FALSE, deleted_at = NOW(), deleted_by = $2, deleted_with_category_id = NULL, updated_at = NOW()
+			WHERE id = $1 AND is_active = TRUE AND ($3::boolean OR user_id = $2)
+			RETURNING id, user_id
+		)
+		UPDATE exercises SET is_active = FALSE, deleted_at = NOW(), deleted_by = $2, deleted_with_category_id = $1
+		WHERE (category_id, user_id) IN (SELECT id, user_id FROM deleted) AND is_active = TRUE`, categoryID, userID, isAdmin)
+	return err
+}
+
+// CountForeignExercises считает активные упражнения других пользователей в категории,
+// а если withDescendants - то и во всех её подкатегориях
+func (r *Repository) CountForeignExercises(ctx context.Context, categoryID int64, withDescendants bool) (int, error) {
+	var count int
+	err := r.conn.QueryRow(ctx, subtreeCTE+`
+		SELECT COUNT(*)
+		FROM exercises e
+		JOIN categories c ON c.id = e.category_id
+		WHERE e.category_id IN (SELECT id FROM subtree) AND ($2::boolean OR e.category_id = $1)
+			AND e.is_active = TRUE AND e.user_id <> c.user_id`, categoryID, withDescendants).Scan(&count)
+	return count, err
+}
+
+// GetTrash возвращает удалённые пользователем записи, начиная с последних.
+// includeCommon добавляет удалённый общий контент.
+func (r *Repository) GetTrash(ctx context.Context, userID model.UserID, includeCommon bool) ([]*model.TrashItem, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT 'category', c.id, c.name, 0, c.user_id = 0, c.deleted_at, COALESCE(c.deleted_by, 0),
+			(SELECT COUNT(*) FROM exercises e WHERE e.deleted_with_category_id = c.id),
+			(SELECT COUNT(*) FROM categories s WHERE s.deleted_with_category_id = c.id)
+		FROM categories c
+		WHERE c.is_active = FALSE AND c.deleted_at IS NOT NULL AND c.deleted_with_category_id IS NULL
+			AND (c.user_id = $1 OR ($2::boolean AND c.user_id = 0))
+		UNION ALL
+		SELECT 'exercise', e.id, e.title, e.category_id, e.user_id = 0, e.deleted_at, COALESCE(e.deleted_by, 0), 0, 0
+		FROM exercises e
+		WHERE e.is_active = FALSE AND e.deleted_at IS NOT NULL AND e.deleted_with_category_id IS NULL
+			AND (e.user_id = $1 OR ($2::boolean AND e.user_id = 0))
+		ORDER BY 6 DESC, 2 DESC`, userID, includeCommon)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*model.TrashItem{}
+	for rows.Next() {
+		var item model.TrashItem
+		err := rows.Scan(&item.Type, &item.ID, &item.Title, &item.CategoryID, &item.IsCommon, &item.DeletedAt, &item.DeletedBy,
+			&item.ExerciseCount, &item.CategoryCount)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+// RestoreExercise возвращает упражнение из корзины. Категория упражнения должна быть активной.
+func (r *Repository) RestoreExercise(ctx context.Context, userID model.UserID, includeCommon bool, exerciseID int64) error {
+	var categoryActive bool
+	err := r.conn.QueryRow(ctx, `
+		SELECT COALESCE(c.is_active, FALSE)
+		FROM exercises e
+		LEFT JOIN categories c ON c.id = e.category_id
+		WHERE e.id = $1 AND e.is_active = FALSE AND e.deleted_with_category_id IS NULL
+			AND (e.user_id = $2 OR ($3::boolean AND e.user_id = 0))
+		FOR UPDATE OF e`, exerciseID, userID, includeCommon).Scan(&categoryActive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: exercise %d is not in trash", model.ErrorNotFound, exerciseID)
+		}
+		return err
+	}
+	if !categoryActive {
+		return fmt.Errorf("%w: category of exercise %d is deleted, restore it first", model.ErrInvalidParameter, exerciseID)
+	}
+
+	_, err = r.conn.Exec(ctx, `
+		UPDATE exercises SET is_active = TRUE, deleted_at = NULL, deleted_by = NULL
+		WHERE id = $1`, exerciseID)
+	return err
+}
+
+// RestoreCategory возвращает категорию из корзины вместе со всем, что было удалено с ней.
+// Если родитель категории тоже удалён, категория восстанавливается в корень дерева.
+func (r *Repository) RestoreCategory(ctx context.Context, userID model.UserID, includeCommon bool, categoryID int64) error {
+	var parentDeleted bool
+	err := r.conn.QueryRow(ctx, `
+		SELECT c.parent_id IS NOT NULL AND NOT COALESCE(p.is_active, FALSE)
+		FROM categories c
+		LEFT JOIN categories p ON p.id = c.parent_id
+		WHERE c.id = $1 AND c.is_active = FALSE AND c.deleted_with_category_id IS NULL
+			AND (c.user_id = $2 OR ($3::boolean AND c.user_id = 0))
+		FOR UPDATE OF c`, categoryID, userID, includeCommon).Scan(&parentDeleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: category %d is not in trash", model.ErrorNotFound, categoryID)
+		}
+		return err
+	}
+
+	_, err = r.conn.Exec(ctx, `
+		UPDATE categories SET is_active = TRUE, deleted_at = NULL, deleted_by = NULL, deleted_with_category_id = NULL,
+			parent_id = CASE WHEN id = $1 AND $2::boolean THEN NULL ELSE parent_id END,
+			updated_at = NOW()
+		WHERE id = $1 OR deleted_with_category_id = $1`, categoryID, parentDeleted)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn.Exec(ctx, `
+		UPDATE exercises SET is_active = TRUE, deleted_at = NULL, deleted_by = NULL, deleted_with_category_id = NULL
+		WHERE deleted_with_category_id = $1`, categoryID)
+	return err
+}
+
+// purgedCategoriesQuery - категории, пролежавшие в корзине дольше $1, в которых не осталось упражнений
+const purgedCategoriesQuery = `
+	SELECT c.id FROM categories c
+	WHERE c.is_active = FALSE AND c.deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM exercises e WHERE e.category_id = c.id)`
+
+// PurgeTrash окончательно удаляет записи, попавшие в корзину раньше before.
+// Категория удаляется только после того, как в ней не осталось упражнений.
+// История попыток и статистика остаются: по ним строятся активность и общая статистика пользователя.
+func (r *Repository) PurgeTrash(ctx context.Context, before time.Time) (int64, int64, error) {
+	var exercises int64
+	err := r.conn.QueryRow(ctx, `
+		WITH purged AS (
+			DELETE FROM exercises WHERE is_active = FALSE AND deleted_at < $1
+			RETURNING id
+		),
+		user_lists AS (
+			DELETE FROM user_exercises WHERE exercise_id IN (SELECT id FROM purged)
+		),
+		reviews AS (
+			DELETE FROM exercise_reviews WHERE exercise_id IN (SELECT id FROM purged)
+		)
+		SELECT COUNT(*) FROM purged`, before).Scan(&exercises)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Оставшиеся подкатегории удаляемых категорий поднимаем в корень, чтобы не нарушить ссылку на родителя
+	_, err = r.conn.Exec(ctx, `
+		WITH purged AS (`+purgedCategoriesQuery+`)
+		UPDATE categories SET parent_id = NULL
+		WHERE parent_id IN (SELECT id FROM purged) AND id NOT IN (SELECT id FROM purged)`, before)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tag, err := r.conn.Exec(ctx, `DELETE FROM categories WHERE id IN (`+purgedCategoriesQuery+`)`, before)
+	if err != nil {
+		return 0, 0, err
+	}
+	return exercises, tag.RowsAffected(), nil
+}
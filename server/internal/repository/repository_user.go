@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"inzarubin80/MemCode/internal/model"
 	sqlc_repository "inzarubin80/MemCode/internal/repository_sqlc"
@@ -26,6 +27,7 @@ func (r *Repository) CreateUser(ctx context.Context, userData *model.UserProfile
 		ID:      model.UserID(user.UserID),
 		Name:    user.Name,
 		IsAdmin: user.IsAdmin,
+		Roles:   model.Roles{},
 	}, nil
 }
 
@@ -54,45 +56,61 @@ func (r *Repository) GetUser(ctx context.Context, userID model.UserID) (*model.U
 		return nil, err
 	}
 
+	roles, err := r.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &model.User{
 		ID:      model.UserID(user.UserID),
 		Name:    user.Name,
 		IsAdmin: user.IsAdmin,
+		Roles:   roles,
 	}, nil
 
 }
 
-
 func (r *Repository) GetAllUsers(ctx context.Context) ([]*model.User, error) {
 	reposqlsc := sqlc_repository.New(r.conn)
 	users, err := reposqlsc.GetAllUsers(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userRoles, err := r.GetAllUserRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
 	usersRes := make([]*model.User, len(users))
 	for i, value := range users {
+		roles := userRoles[model.UserID(value.UserID)]
+		if roles == nil {
+			roles = model.Roles{}
+		}
 		usersRes[i] = &model.User{
-			ID:                 model.UserID(value.UserID),
-			Name:               value.Name,
-			IsAdmin:            value.IsAdmin,
+			ID:      model.UserID(value.UserID),
+			Name:    value.Name,
+			IsAdmin: value.IsAdmin,
+			Roles:   roles,
 		}
 	}
 	return usersRes, nil
 }
 
-func (r *Repository) SetUserAdmin(ctx context.Context, userID model.UserID, isAdmin bool) (*model.User, error) {
-	reposqlsc := sqlc_repository.New(r.conn)
-	params := &sqlc_repository.SetUserAdminParams{
-		UserID:  int64(userID),
-		IsAdmin: isAdmin,
-	}
-	user, err := reposqlsc.SetUserAdmin(ctx, params)
+// InvalidateUserTokens отзывает все выпущенные пользователю токены доступа
+func (r *Repository) InvalidateUserTokens(ctx context.Context, userID model.UserID) error {
+	_, err := r.conn.Exec(ctx, `UPDATE users SET tokens_valid_after = NOW() WHERE user_id = $1`, userID)
+	return err
+}
+
+// GetUserTokensValidAfter возвращает момент, раньше которого токены пользователя недействительны, или nil
+func (r *Repository) GetUserTokensValidAfter(ctx context.Context, userID model.UserID) (*time.Time, error) {
+	var validAfter *time.Time
+	err := r.conn.QueryRow(ctx, `SELECT tokens_valid_after FROM users WHERE user_id = $1`, userID).Scan(&validAfter)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %v", model.ErrorNotFound, err)
+		}
 		return nil, err
 	}
-	return &model.User{
-		ID:      model.UserID(user.UserID),
-		Name:    user.Name,
-		IsAdmin: user.IsAdmin,
-	}, nil
+	return validAfter, nil
 }
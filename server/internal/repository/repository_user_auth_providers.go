@@ -62,3 +62,53 @@ func (r *Repository) AddUserAuthProviders(ctx context.Context, userProfileFromPr
 	}, nil
 
 }
+
+func (r *Repository) GetUserLinkedProviders(ctx context.Context, userID model.UserID) ([]*model.LinkedProvider, error) {
+	rows, err := r.conn.Query(ctx, `SELECT provider, COALESCE(name, ''), linked_at FROM user_auth_providers WHERE user_id = $1 ORDER BY linked_at, provider`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	providers := make([]*model.LinkedProvider, 0)
+	for rows.Next() {
+		var provider model.LinkedProvider
+		if err := rows.Scan(&provider.Provider, &provider.Name, &provider.LinkedAt); err != nil {
+			return nil, err
+		}
+		providers = append(providers, &provider)
+	}
+	return providers, rows.Err()
+}
+
+// DeleteUserAuthProvider отвязывает провайдера, если у пользователя остаётся другой способ входа.
+// Строки пользователя блокируются, чтобы параллельные запросы не отвязали все способы сразу.
+func (r *Repository) DeleteUserAuthProvider(ctx context.Context, userID model.UserID, provider string) error {
+	rows, err := r.conn.Query(ctx, `SELECT provider FROM user_auth_providers WHERE user_id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		return err
+	}
+	linked := make(map[string]bool)
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			rows.Close()
+			return err
+		}
+		linked[p] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !linked[provider] {
+		return fmt.Errorf("%w: provider %s is not linked", model.ErrorNotFound, provider)
+	}
+	if len(linked) == 1 {
+		return fmt.Errorf("%w: cannot unlink the last login method", model.ErrInvalidParameter)
+	}
+
+	_, err = r.conn.Exec(ctx, `DELETE FROM user_auth_providers WHERE user_id = $1 AND provider = $2`, userID, provider)
+	return err
+}
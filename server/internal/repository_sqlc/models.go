@@ -35,6 +35,7 @@ type Exercise struct {
 	UpdatedAt           pgtype.Timestamptz
 	IsActive            *bool
 	IsCommon            *bool
+	ComparisonMode      string
 }
 
 type ExerciseStat struct {
@@ -46,6 +47,9 @@ type ExerciseStat struct {
 	TotalTypedChars    int32
 	CreatedAt          pgtype.Timestamp
 	UpdatedAt          pgtype.Timestamp
+	TotalScore         int64
+	BestScore          int32
+	LastScore          int32
 }
 
 type User struct {
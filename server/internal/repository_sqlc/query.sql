@@ -25,10 +25,10 @@ returning *;
 
 -- name: CreateExercise :one
 INSERT INTO exercises (
-    user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common
+    user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common, comparison_mode
 ) VALUES (
-    $1, $2, $3, $4, $5, NOW(), NOW(), TRUE, $6, $7
-) RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common;
+    $1, $2, $3, $4, $5, NOW(), NOW(), TRUE, $6, $7, $8
+) RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common, comparison_mode;
 
 
 
@@ -40,7 +40,7 @@ FROM exercises as e
 WHERE e.user_id in ($1,0) AND is_active = TRUE;
 
 -- name: GetExercise :one
-SELECT e.id, e.user_id, e.title, e.description, e.category_id, e.programming_language, e.code_to_remember, e.created_at, e.updated_at, e.is_active, e.is_common
+SELECT e.id, e.user_id, e.title, e.description, e.category_id, e.programming_language, e.code_to_remember, e.created_at, e.updated_at, e.is_active, e.is_common, e.comparison_mode
 FROM exercises e
 WHERE e.id = $1 AND e.is_active = TRUE
 AND e.user_id in($2,0) 
@@ -55,11 +55,12 @@ UPDATE exercises SET
     updated_at = NOW(),
     programming_language = $5,
     is_common = $6,
-    user_id = $8
+    user_id = $8,
+    comparison_mode = $10
 WHERE id = $7
   AND is_active = TRUE
   AND ($9::boolean OR user_id = $8)
-RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common;
+RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common, comparison_mode;
 
 -- name: DeleteExercise :exec
 UPDATE exercises SET is_active = FALSE
@@ -138,6 +139,7 @@ SELECT
   e.updated_at,
   e.is_active,
   e.is_common,
+  e.comparison_mode,
   CASE 
     WHEN ue.exercise_id IS NULL THEN 
       FALSE
@@ -187,19 +189,22 @@ WHERE e.user_id in ($1, 0)
   AND ($3::bigint = 0 OR e.category_id = $3);
 
 -- name: GetExerciseStat :one
-SELECT es.user_id, es.exercise_id, es.total_attempts, es.successful_attempts, es.total_typing_time, es.total_typed_chars, es.created_at, es.updated_at
+SELECT es.user_id, es.exercise_id, es.total_attempts, es.successful_attempts, es.total_typing_time, es.total_typed_chars, es.created_at, es.updated_at, es.total_score, es.best_score, es.last_score
 FROM exercise_stats es WHERE es.user_id = $1 AND es.exercise_id = $2;
 
 -- name: UpsertExerciseStat :one
-INSERT INTO exercise_stats (user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+INSERT INTO exercise_stats (user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, total_score, best_score, last_score, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7, NOW(), NOW())
 ON CONFLICT (user_id, exercise_id) DO UPDATE SET
     total_attempts = exercise_stats.total_attempts + EXCLUDED.total_attempts,
     successful_attempts = exercise_stats.successful_attempts + EXCLUDED.successful_attempts,
     total_typing_time = exercise_stats.total_typing_time + EXCLUDED.total_typing_time,
     total_typed_chars = exercise_stats.total_typed_chars + EXCLUDED.total_typed_chars,
+    total_score = exercise_stats.total_score + EXCLUDED.total_score,
+    best_score = GREATEST(exercise_stats.best_score, EXCLUDED.best_score),
+    last_score = EXCLUDED.last_score,
     updated_at = NOW()
-RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at;
+RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at, total_score, best_score, last_score;
 
 -- name: UpdateExerciseStat :one
 UPDATE exercise_stats SET
@@ -209,7 +214,7 @@ UPDATE exercise_stats SET
     total_typed_chars = $6,
     updated_at = NOW()
 WHERE user_id = $1 AND exercise_id = $2
-RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at;
+RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at, total_score, best_score, last_score;
 
 -- name: GetUserStats :one
 SELECT
@@ -217,11 +222,12 @@ SELECT
     COUNT(DISTINCT es.exercise_id) as total_exercises,
     COUNT(DISTINCT CASE WHEN es.successful_attempts > 0 THEN es.exercise_id END) as completed_exercises,
     CASE WHEN SUM(es.total_attempts) > 0
-         THEN ROUND(SUM(es.successful_attempts)::numeric / NULLIF(SUM(es.total_attempts),0) * 100)::int
+         THEN ROUND(SUM(es.total_score)::numeric / NULLIF(SUM(es.total_attempts),0))::int
          ELSE 0
     END as average_score,
     COALESCE(SUM(es.total_attempts), 0)::bigint as total_attempts,
-    COALESCE(SUM(es.total_typing_time), 0)::bigint as total_time
+    COALESCE(SUM(es.total_typing_time), 0)::bigint as total_time,
+    COALESCE(SUM(es.total_typed_chars), 0)::bigint as total_typed_chars
 FROM exercise_stats es
 WHERE es.user_id = $1;
 
@@ -249,6 +255,7 @@ SELECT COUNT(*) FROM user_exercises WHERE user_id = $1;
         e.updated_at as updated_at,
         e.is_active,
         e.is_common,
+        e.comparison_mode,
         TRUE AS is_user_exercise,
         c.name as category_name,
 
@@ -223,10 +223,10 @@ func (q *Queries) CreateCategory(ctx context.Context, arg *CreateCategoryParams)
 
 const createExercise = `-- name: CreateExercise :one
 INSERT INTO exercises (
-    user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common
+    user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common, comparison_mode
 ) VALUES (
-    $1, $2, $3, $4, $5, NOW(), NOW(), TRUE, $6, $7
-) RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common
+    $1, $2, $3, $4, $5, NOW(), NOW(), TRUE, $6, $7, $8
+) RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common, comparison_mode
 `
 
 type CreateExerciseParams struct {
@@ -237,6 +237,7 @@ type CreateExerciseParams struct {
 	CodeToRemember      string
 	ProgrammingLanguage string
 	IsCommon            *bool
+	ComparisonMode      string
 }
 
 type CreateExerciseRow struct {
@@ -251,6 +252,7 @@ type CreateExerciseRow struct {
 	IsActive            *bool
 	ProgrammingLanguage string
 	IsCommon            *bool
+	ComparisonMode      string
 }
 
 func (q *Queries) CreateExercise(ctx context.Context, arg *CreateExerciseParams) (*CreateExerciseRow, error) {
@@ -262,6 +264,7 @@ func (q *Queries) CreateExercise(ctx context.Context, arg *CreateExerciseParams)
 		arg.CodeToRemember,
 		arg.ProgrammingLanguage,
 		arg.IsCommon,
+		arg.ComparisonMode,
 	)
 	var i CreateExerciseRow
 	err := row.Scan(
@@ -276,6 +279,7 @@ func (q *Queries) CreateExercise(ctx context.Context, arg *CreateExerciseParams)
 		&i.IsActive,
 		&i.ProgrammingLanguage,
 		&i.IsCommon,
+		&i.ComparisonMode,
 	)
 	return &i, err
 }
@@ -492,7 +496,7 @@ func (q *Queries) GetCategory(ctx context.Context, arg *GetCategoryParams) (*Cat
 }
 
 const getExercise = `-- name: GetExercise :one
-SELECT e.id, e.user_id, e.title, e.description, e.category_id, e.programming_language, e.code_to_remember, e.created_at, e.updated_at, e.is_active, e.is_common
+SELECT e.id, e.user_id, e.title, e.description, e.category_id, e.programming_language, e.code_to_remember, e.created_at, e.updated_at, e.is_active, e.is_common, e.comparison_mode
 FROM exercises e
 WHERE e.id = $1 AND e.is_active = TRUE
 AND e.user_id in($2,0)
@@ -518,12 +522,13 @@ func (q *Queries) GetExercise(ctx context.Context, arg *GetExerciseParams) (*Exe
 		&i.UpdatedAt,
 		&i.IsActive,
 		&i.IsCommon,
+		&i.ComparisonMode,
 	)
 	return &i, err
 }
 
 const getExerciseStat = `-- name: GetExerciseStat :one
-SELECT es.user_id, es.exercise_id, es.total_attempts, es.successful_attempts, es.total_typing_time, es.total_typed_chars, es.created_at, es.updated_at
+SELECT es.user_id, es.exercise_id, es.total_attempts, es.successful_attempts, es.total_typing_time, es.total_typed_chars, es.created_at, es.updated_at, es.total_score, es.best_score, es.last_score
 FROM exercise_stats es WHERE es.user_id = $1 AND es.exercise_id = $2
 `
 
@@ -544,6 +549,9 @@ func (q *Queries) GetExerciseStat(ctx context.Context, arg *GetExerciseStatParam
 		&i.TotalTypedChars,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TotalScore,
+		&i.BestScore,
+		&i.LastScore,
 	)
 	return &i, err
 }
@@ -561,6 +569,7 @@ SELECT
   e.updated_at,
   e.is_active,
   e.is_common,
+  e.comparison_mode,
   CASE 
     WHEN ue.exercise_id IS NULL THEN 
       FALSE
@@ -620,6 +629,7 @@ type GetExercisesFilteredRow struct {
 	UpdatedAt           pgtype.Timestamptz
 	IsActive            *bool
 	IsCommon            *bool
+	ComparisonMode      string
 	IsUserExercise      bool
 	IsSolved            bool
 	CategoryName        string
@@ -653,6 +663,7 @@ func (q *Queries) GetExercisesFiltered(ctx context.Context, arg *GetExercisesFil
 			&i.UpdatedAt,
 			&i.IsActive,
 			&i.IsCommon,
+			&i.ComparisonMode,
 			&i.IsUserExercise,
 			&i.IsSolved,
 			&i.CategoryName,
@@ -750,6 +761,7 @@ const getUserExercisesFiltered = `-- name: GetUserExercisesFiltered :many
         e.updated_at as updated_at,
         e.is_active,
         e.is_common,
+        e.comparison_mode,
         TRUE AS is_user_exercise,
         c.name as category_name,
 
@@ -792,6 +804,7 @@ type GetUserExercisesFilteredRow struct {
 	UpdatedAt           pgtype.Timestamptz
 	IsActive            *bool
 	IsCommon            *bool
+	ComparisonMode      string
 	IsUserExercise      bool
 	CategoryName        string
 	IsSolved            bool
@@ -830,6 +843,7 @@ func (q *Queries) GetUserExercisesFiltered(ctx context.Context, arg *GetUserExer
 			&i.UpdatedAt,
 			&i.IsActive,
 			&i.IsCommon,
+			&i.ComparisonMode,
 			&i.IsUserExercise,
 			&i.CategoryName,
 			&i.IsSolved,
@@ -850,11 +864,12 @@ SELECT
     COUNT(DISTINCT es.exercise_id) as total_exercises,
     COUNT(DISTINCT CASE WHEN es.successful_attempts > 0 THEN es.exercise_id END) as completed_exercises,
     CASE WHEN SUM(es.total_attempts) > 0
-         THEN ROUND(SUM(es.successful_attempts)::numeric / NULLIF(SUM(es.total_attempts),0) * 100)::int
+         THEN ROUND(SUM(es.total_score)::numeric / NULLIF(SUM(es.total_attempts),0))::int
          ELSE 0
     END as average_score,
     COALESCE(SUM(es.total_attempts), 0)::bigint as total_attempts,
-    COALESCE(SUM(es.total_typing_time), 0)::bigint as total_time
+    COALESCE(SUM(es.total_typing_time), 0)::bigint as total_time,
+    COALESCE(SUM(es.total_typed_chars), 0)::bigint as total_typed_chars
 FROM exercise_stats es
 WHERE es.user_id = $1
 `
@@ -866,6 +881,7 @@ type GetUserStatsRow struct {
 	AverageScore       int32
 	TotalAttempts      int64
 	TotalTime          int64
+	TotalTypedChars    int64
 }
 
 func (q *Queries) GetUserStats(ctx context.Context, dollar_1 int64) (*GetUserStatsRow, error) {
@@ -878,6 +894,7 @@ func (q *Queries) GetUserStats(ctx context.Context, dollar_1 int64) (*GetUserSta
 		&i.AverageScore,
 		&i.TotalAttempts,
 		&i.TotalTime,
+		&i.TotalTypedChars,
 	)
 	return &i, err
 }
@@ -989,11 +1006,12 @@ UPDATE exercises SET
     updated_at = NOW(),
     programming_language = $5,
     is_common = $6,
-    user_id = $8
+    user_id = $8,
+    comparison_mode = $10
 WHERE id = $7
   AND is_active = TRUE
   AND ($9::boolean OR user_id = $8)
-RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common
+RETURNING id, user_id, title, description, category_id, code_to_remember, created_at, updated_at, is_active, programming_language, is_common, comparison_mode
 `
 
 type UpdateExerciseParams struct {
@@ -1006,6 +1024,7 @@ type UpdateExerciseParams struct {
 	ID                  int64
 	UserID              int64
 	Column9             bool
+	ComparisonMode      string
 }
 
 type UpdateExerciseRow struct {
@@ -1020,6 +1039,7 @@ type UpdateExerciseRow struct {
 	IsActive            *bool
 	ProgrammingLanguage string
 	IsCommon            *bool
+	ComparisonMode      string
 }
 
 func (q *Queries) UpdateExercise(ctx context.Context, arg *UpdateExerciseParams) (*UpdateExerciseRow, error) {
@@ -1033,6 +1053,7 @@ func (q *Queries) UpdateExercise(ctx context.Context, arg *UpdateExerciseParams)
 		arg.ID,
 		arg.UserID,
 		arg.Column9,
+		arg.ComparisonMode,
 	)
 	var i UpdateExerciseRow
 	err := row.Scan(
@@ -1047,6 +1068,7 @@ func (q *Queries) UpdateExercise(ctx context.Context, arg *UpdateExerciseParams)
 		&i.IsActive,
 		&i.ProgrammingLanguage,
 		&i.IsCommon,
+		&i.ComparisonMode,
 	)
 	return &i, err
 }
@@ -1059,7 +1081,7 @@ UPDATE exercise_stats SET
     total_typed_chars = $6,
     updated_at = NOW()
 WHERE user_id = $1 AND exercise_id = $2
-RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at
+RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at, total_score, best_score, last_score
 `
 
 type UpdateExerciseStatParams struct {
@@ -1090,6 +1112,9 @@ func (q *Queries) UpdateExerciseStat(ctx context.Context, arg *UpdateExerciseSta
 		&i.TotalTypedChars,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TotalScore,
+		&i.BestScore,
+		&i.LastScore,
 	)
 	return &i, err
 }
@@ -1120,15 +1145,18 @@ func (q *Queries) UpdateUserName(ctx context.Context, arg *UpdateUserNameParams)
 }
 
 const upsertExerciseStat = `-- name: UpsertExerciseStat :one
-INSERT INTO exercise_stats (user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+INSERT INTO exercise_stats (user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, total_score, best_score, last_score, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7, NOW(), NOW())
 ON CONFLICT (user_id, exercise_id) DO UPDATE SET
     total_attempts = exercise_stats.total_attempts + EXCLUDED.total_attempts,
     successful_attempts = exercise_stats.successful_attempts + EXCLUDED.successful_attempts,
     total_typing_time = exercise_stats.total_typing_time + EXCLUDED.total_typing_time,
     total_typed_chars = exercise_stats.total_typed_chars + EXCLUDED.total_typed_chars,
+    total_score = exercise_stats.total_score + EXCLUDED.total_score,
+    best_score = GREATEST(exercise_stats.best_score, EXCLUDED.best_score),
+    last_score = EXCLUDED.last_score,
     updated_at = NOW()
-RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at
+RETURNING  user_id, exercise_id, total_attempts, successful_attempts, total_typing_time, total_typed_chars, created_at, updated_at, total_score, best_score, last_score
 `
 
 type UpsertExerciseStatParams struct {
@@ -1138,6 +1166,7 @@ type UpsertExerciseStatParams struct {
 	SuccessfulAttempts int32
 	TotalTypingTime    int64
 	TotalTypedChars    int32
+	TotalScore         int64
 }
 
 func (q *Queries) UpsertExerciseStat(ctx context.Context, arg *UpsertExerciseStatParams) (*ExerciseStat, error) {
@@ -1148,6 +1177,7 @@ func (q *Queries) UpsertExerciseStat(ctx context.Context, arg *UpsertExerciseSta
 		arg.SuccessfulAttempts,
 		arg.TotalTypingTime,
 		arg.TotalTypedChars,
+		arg.TotalScore,
 	)
 	var i ExerciseStat
 	err := row.Scan(
@@ -1159,6 +1189,9 @@ func (q *Queries) UpsertExerciseStat(ctx context.Context, arg *UpsertExerciseSta
 		&i.TotalTypedChars,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TotalScore,
+		&i.BestScore,
+		&i.LastScore,
 	)
 	return &i, err
 }
@@ -0,0 +1,129 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+)
+
+type (
+	// Data формат файла seed_data.json
+	Data struct {
+		Categories []Category `json:"categories"`
+		Exercises  []Exercise `json:"exercises"`
+	}
+
+	Category struct {
+		Name                string `json:"name"`
+		Description         string `json:"description"`
+		ProgrammingLanguage string `json:"programming_language"`
+		Color               string `json:"color"`
+		Icon                string `json:"icon"`
+		Status              string `json:"status"`
+	}
+
+	Exercise struct {
+		Title               string `json:"title"`
+		Description         string `json:"description"`
+		CategoryName        string `json:"category_name"`
+		ProgrammingLanguage string `json:"programming_language"`
+		CodeToRemember      string `json:"code_to_remember"`
+	}
+
+	// Report итог загрузки начальных данных
+	Report struct {
+		CategoriesCreated int
+		CategoriesUpdated int
+		ExercisesCreated  int
+		ExercisesUpdated  int
+		Skipped           []string
+	}
+)
+
+// Run загружает общие категории и упражнения в одной транзакции. Категории сопоставляются
+// по имени, упражнения - по названию внутри категории, поэтому повторный запуск только
+// обновляет тексты и не создаёт дубликатов.
+func Run(ctx context.Context, transactionProvider stor.TransactionProvider, raw []byte) (*Report, error) {
+	var data Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("parse seed data: %w", err)
+	}
+
+	report := &Report{}
+	err := transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		repo := adapters.Repository
+		categoryIDs := map[string]int64{}
+
+		for _, item := range data.Categories {
+			language, ok := model.NormalizeLanguage(item.ProgrammingLanguage)
+			if !ok {
+				report.Skipped = append(report.Skipped, fmt.Sprintf("category %q: unsupported language %q", item.Name, item.ProgrammingLanguage))
+				continue
+			}
+			status := item.Status
+			if status == "" {
+				status = model.CategoryStatusActive
+			}
+
+			id, created, err := repo.UpsertCommonCategory(ctx, &model.Category{
+				Name:                item.Name,
+				Description:         item.Description,
+				ProgrammingLanguage: language,
+				Color:               item.Color,
+				Icon:                item.Icon,
+				Status:              status,
+			})
+			if err != nil {
+				return fmt.Errorf("category %q: %w", item.Name, err)
+			}
+			categoryIDs[item.Name] = id
+			if created {
+				report.CategoriesCreated++
+			} else {
+				report.CategoriesUpdated++
+			}
+		}
+
+		for _, item := range data.Exercises {
+			categoryID, ok := categoryIDs[item.CategoryName]
+			if !ok {
+				report.Skipped = append(report.Skipped, fmt.Sprintf("exercise %q: category %q is not loaded", item.Title, item.CategoryName))
+				continue
+			}
+			language, ok := model.NormalizeLanguage(item.ProgrammingLanguage)
+			if !ok {
+				report.Skipped = append(report.Skipped, fmt.Sprintf("exercise %q: unsupported language %q", item.Title, item.ProgrammingLanguage))
+				continue
+			}
+
+			_, created, err := repo.UpsertCommonExercise(ctx, &model.Exercise{
+				Title:               item.Title,
+				Description:         item.Description,
+				CategoryID:          categoryID,
+				ProgrammingLanguage: language,
+				CodeToRemember:      item.CodeToRemember,
+				ComparisonMode:      model.DefaultComparisonMode,
+			})
+			if err != nil {
+				return fmt.Errorf("exercise %q: %w", item.Title, err)
+			}
+			if created {
+				report.ExercisesCreated++
+			} else {
+				report.ExercisesUpdated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("categories: %d created, %d updated; exercises: %d created, %d updated; skipped: %d",
+		r.CategoriesCreated, r.CategoriesUpdated, r.ExercisesCreated, r.ExercisesUpdated, len(r.Skipped))
+}
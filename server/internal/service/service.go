@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	authinterface "inzarubin80/MemCode/internal/app/authinterface"
 	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"time"
 )
 
 type (
@@ -13,6 +16,9 @@ type (
 		accessTokenService  TokenService
 		refreshTokenService TokenService
 		providersUserData   authinterface.ProvidersUserData
+		transactionProvider stor.TransactionProvider
+		mailer              Mailer
+		publicURL           string // адрес фронтенда для ссылок в письмах
 	}
 
 	Repository interface {
@@ -20,20 +26,35 @@ type (
 		//User
 		GetUserAuthProvidersByProviderUid(ctx context.Context, ProviderUid string, Provider string) (*model.UserAuthProviders, error)
 		AddUserAuthProviders(ctx context.Context, userProfileFromProvide *model.UserProfileFromProvider, userID model.UserID) (*model.UserAuthProviders, error)
+		GetUserLinkedProviders(ctx context.Context, userID model.UserID) ([]*model.LinkedProvider, error)
 		CreateUser(ctx context.Context, userData *model.UserProfileFromProvider) (*model.User, error)
 		SetUserName(ctx context.Context, userID model.UserID, name string) error
 		GetUser(ctx context.Context, userID model.UserID) (*model.User, error)
 		GetAllUsers(ctx context.Context) ([]*model.User, error)
-		SetUserAdmin(ctx context.Context, userID model.UserID, isAdmin bool) (*model.User, error)
+		InvalidateUserTokens(ctx context.Context, userID model.UserID) error
+		GetUserTokensValidAfter(ctx context.Context, userID model.UserID) (*time.Time, error)
+
+		//Role
+		GetRoles(ctx context.Context) ([]*model.RoleInfo, error)
+		GrantUserRole(ctx context.Context, userID model.UserID, role model.Role, grantedBy model.UserID) error
+		RevokeUserRole(ctx context.Context, userID model.UserID, role model.Role) error
 
 		//Exercise
 		CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error)
 		GetExercise(ctx context.Context, userID model.UserID, exerciseID int64) (*model.Exercise, error)
 		UpdateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error)
 		DeleteExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64) error
-		GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) ([]*model.ExerciseDetailse, int, error)
-		UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts int, successAttempts int) (*model.ExerciseStat, error)
+		GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error)
+		UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts int, successAttempts int, typingTime int64, typedChars int, score int) (*model.ExerciseStat, error)
+		AddExerciseTypingTime(ctx context.Context, userID model.UserID, exerciseID int64, typingTime int64, typedChars int) (*model.ExerciseStat, error)
 		GetExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseStat, error)
+		GetExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) ([]*model.ExerciseRevision, int, error)
+		GetExerciseRevision(ctx context.Context, userID model.UserID, exerciseID int64, revisionNumber int) (*model.ExerciseRevision, error)
+		IsExerciseArchived(ctx context.Context, exerciseID int64) (bool, error)
+
+		// Exercise attempts
+		CreateExerciseAttempt(ctx context.Context, attempt *model.ExerciseAttempt) (*model.ExerciseAttempt, error)
+		GetExerciseAttempts(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) ([]*model.ExerciseAttempt, int, error)
 
 		//Category
 		CreateCategory(ctx context.Context, userID model.UserID, isAdmin bool, category *model.Category) (*model.Category, error)
@@ -42,30 +63,77 @@ type (
 		UpdateCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64, category *model.Category) (*model.Category, error)
 		DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error
 		CountExercisesByCategory(ctx context.Context, categoryID int64) (int64, error)
+		CountExercisesByCategories(ctx context.Context, userID model.UserID) (map[int64]int64, error)
+
+		//Tag
+		GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error)
+		GetTag(ctx context.Context, userID model.UserID, tagID int64) (*model.Tag, error)
+		CreateTag(ctx context.Context, ownerID model.UserID, name string) (*model.Tag, error)
+		RenameTag(ctx context.Context, tagID int64, name string) error
+		DeleteTag(ctx context.Context, tagID int64) error
+		MergeTags(ctx context.Context, sourceID int64, targetID int64) error
+		AddExerciseTag(ctx context.Context, exerciseID int64, tagID int64) error
+		RemoveExerciseTag(ctx context.Context, exerciseID int64, tagID int64) error
+		GetExercisesTags(ctx context.Context, userID model.UserID, exerciseIDs []int64) (map[int64][]*model.Tag, error)
+
+		// Export
+		GetOwnedCategories(ctx context.Context, userID model.UserID) ([]*model.BundleCategory, error)
+		GetOwnedExercises(ctx context.Context, userID model.UserID, afterID int64, limit int, withStats bool) ([]*model.BundleExercise, error)
 
 		// User Stats
 		GetUserStats(ctx context.Context, userID model.UserID) (*model.UserStats, error)
+		GetUserTimezone(ctx context.Context, userID model.UserID) (string, error)
+		SetUserTimezone(ctx context.Context, userID model.UserID, timezone string) error
+		GetUserDailyActivity(ctx context.Context, userID model.UserID, timezone string, from, to time.Time) ([]*model.DailyActivity, error)
+		GetUserStreaks(ctx context.Context, userID model.UserID, timezone string) (current int, longest int, err error)
 
 		// User Exercises
-		GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) ([]*model.ExerciseDetailse, int, error)
+		GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) ([]*model.ExerciseDetailse, int, error)
 		AddUserExercise(ctx context.Context, userID model.UserID, exerciseID int64) error
 		RemoveUserExercise(ctx context.Context, userID model.UserID, exerciseID int64) error
 		GetUserExerciseIDs(ctx context.Context, userID model.UserID) ([]int64, error)
 
 		IsExerciseSolvedByUser(ctx context.Context, userID model.UserID, exerciseID int64) (bool, error)
 		IsUserExercise(ctx context.Context, userID model.UserID, exerciseID int64) (bool, error)
+		UpdateUserExerciseProgress(ctx context.Context, userID model.UserID, exerciseID int64, isCorrect bool, score int) error
+
+		// Spaced repetition
+		GetExerciseReview(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseReview, error)
+		UpsertExerciseReview(ctx context.Context, review *model.ExerciseReview) error
+		GetDueExercises(ctx context.Context, userID model.UserID, until time.Time, page, pageSize int) ([]*model.ExerciseDetailse, int, error)
+
+		// Trash
+		GetTrash(ctx context.Context, userID model.UserID, includeCommon bool) ([]*model.TrashItem, error)
 
 		// Refresh Tokens
 		CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
 		GetRefreshTokenByToken(ctx context.Context, token string) (*model.RefreshToken, error)
-		RevokeRefreshToken(ctx context.Context, token string) error
+		RevokeRefreshToken(ctx context.Context, token string) (bool, error)
+		RevokeRefreshTokenFamily(ctx context.Context, familyID string) (int64, error)
+		CreateSecurityEvent(ctx context.Context, event *model.SecurityEvent) error
 		DeleteRefreshTokenByToken(ctx context.Context, token string) error
 		DeleteAllUserRefreshTokens(ctx context.Context, userID model.UserID) error
+		GetActiveRefreshTokens(ctx context.Context, userID model.UserID) ([]*model.RefreshToken, error)
+		RevokeUserSession(ctx context.Context, userID model.UserID, tokenID int64) error
+		RevokeAllUserRefreshTokens(ctx context.Context, userID model.UserID) error
 		CleanupExpiredTokens(ctx context.Context) error
+
+		//Local auth
+		GetLocalCredentialsByEmail(ctx context.Context, email string) (*model.LocalCredentials, error)
+		GetLocalCredentials(ctx context.Context, userID model.UserID) (*model.LocalCredentials, error)
+		SetLocalPasswordHash(ctx context.Context, userID model.UserID, passwordHash string) error
+		MarkLocalEmailVerified(ctx context.Context, userID model.UserID) error
+		CreateAuthToken(ctx context.Context, token *model.AuthToken) error
+		ConsumeAuthToken(ctx context.Context, tokenHash string, purpose string) (model.UserID, error)
+		DeleteUserAuthTokens(ctx context.Context, userID model.UserID, purpose string) error
+	}
+
+	Mailer interface {
+		Send(ctx context.Context, message *model.MailMessage) error
 	}
 
 	TokenService interface {
-		GenerateToken(userID model.UserID, isAdmin bool) (string, error)
+		GenerateToken(userID model.UserID, roles model.Roles) (string, error)
 		ValidateToken(tokenString string) (*model.Claims, error)
 	}
 
@@ -74,22 +142,40 @@ type (
 	}
 )
 
-func NewPokerService(repository Repository, accessTokenService TokenService, refreshTokenService TokenService, providersUserData authinterface.ProvidersUserData) *PokerService {
+func NewPokerService(repository Repository, accessTokenService TokenService, refreshTokenService TokenService, providersUserData authinterface.ProvidersUserData, transactionProvider stor.TransactionProvider, mailer Mailer, publicURL string) *PokerService {
 	return &PokerService{
 		repository:          repository,
 		accessTokenService:  accessTokenService,
 		refreshTokenService: refreshTokenService,
 		providersUserData:   providersUserData,
+		transactionProvider: transactionProvider,
+		mailer:              mailer,
+		publicURL:           publicURL,
 	}
 }
 
 // Методы для упражнений
-func (s *PokerService) CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error) {
-	// Проверка: только админ может создавать общие задачи
-	if exercise.IsCommon && !isAdmin {
-		return nil, errors.New("only admin can create common exercises")
+func (s *PokerService) CreateExercise(ctx context.Context, userID model.UserID, roles model.Roles, exercise *model.Exercise) (*model.Exercise, error) {
+	if err := validateExerciseCode(exercise.CodeToRemember); err != nil {
+		return nil, err
+	}
+	// Общие задачи создают только редакторы контента
+	if exercise.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
+		}
+	}
+	if exercise.ComparisonMode == "" {
+		exercise.ComparisonMode = model.DefaultComparisonMode
 	}
-	return s.repository.CreateExercise(ctx, userID, isAdmin, exercise)
+	// Упражнение и его первая ревизия пишутся вместе
+	var created *model.Exercise
+	err := s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		var err error
+		created, err = adapters.Repository.CreateExercise(ctx, userID, roles.Can(model.PermissionManageCommonContent), exercise)
+		return err
+	})
+	return created, err
 }
 
 func (s *PokerService) GetExercise(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseDetailse, error) {
@@ -109,13 +195,20 @@ func (s *PokerService) GetExercise(ctx context.Context, userID model.UserID, exe
 		IsSolved:       isSolved,
 		IsUserExercise: isUserExercise,
 	}
-	return &model.ExerciseDetailse{
+	detailse := &model.ExerciseDetailse{
 		UserIfo:  userIfo,
 		Exercise: *exercise,
-	}, nil
+	}
+	if err := s.attachTags(ctx, userID, []*model.ExerciseDetailse{detailse}); err != nil {
+		return nil, err
+	}
+	return detailse, nil
 }
 
-func (s *PokerService) UpdateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error) {
+func (s *PokerService) UpdateExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error) {
+	if err := validateExerciseCode(exercise.CodeToRemember); err != nil {
+		return nil, err
+	}
 	existingExercise, err := s.repository.GetExercise(ctx, userID, exerciseID)
 	if err != nil {
 		return nil, err
@@ -124,21 +217,28 @@ func (s *PokerService) UpdateExercise(ctx context.Context, userID model.UserID,
 		return nil, errors.New("exercise not found")
 	}
 
-	if !isAdmin {
-		// Нельзя обновлять общую задачу
-		if existingExercise.IsCommon {
-			return nil, errors.New("only admin can update common exercises")
-		}
-		// Нельзя менять обычную задачу на общую
-		if exercise.IsCommon {
-			return nil, errors.New("only admin can set exercise as common")
+	// Общую задачу, как и перевод задачи в общие, меняют только редакторы контента
+	if existingExercise.IsCommon || exercise.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
 		}
 	}
 
-	return s.repository.UpdateExercise(ctx, userID, isAdmin, exerciseID, exercise)
+	// Если режим сравнения не передан, сохраняем текущий
+	if exercise.ComparisonMode == "" {
+		exercise.ComparisonMode = existingExercise.ComparisonMode
+	}
+
+	var updated *model.Exercise
+	err = s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		var err error
+		updated, err = adapters.Repository.UpdateExercise(ctx, userID, roles.Can(model.PermissionManageCommonContent), exerciseID, exercise)
+		return err
+	})
+	return updated, err
 }
 
-func (s *PokerService) DeleteExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64) error {
+func (s *PokerService) DeleteExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64) error {
 	// Проверяем, что упражнение принадлежит пользователю
 	existingExercise, err := s.repository.GetExercise(ctx, userID, exerciseID)
 	if err != nil {
@@ -148,25 +248,48 @@ func (s *PokerService) DeleteExercise(ctx context.Context, userID model.UserID,
 		return errors.New("exercise not found")
 	}
 
-	if !isAdmin && existingExercise.IsCommon {
-		return errors.New("only admin can delete common exercises")
+	if existingExercise.IsCommon {
+		if err := requirePermission(roles, model.PermissionModerateContent); err != nil {
+			return err
+		}
 	}
 
-	return s.repository.DeleteExercise(ctx, userID, isAdmin, exerciseID)
+	return s.repository.DeleteExercise(ctx, userID, roles.Can(model.PermissionModerateContent), exerciseID)
 }
 
 // Методы для категорий
-func (s *PokerService) CreateCategory(ctx context.Context, userID model.UserID, isAdmin bool, category *model.Category) (*model.Category, error) {
-	if category.IsCommon && !isAdmin {
-		return nil, errors.New("only admin can create common categories")
+func (s *PokerService) CreateCategory(ctx context.Context, userID model.UserID, roles model.Roles, category *model.Category) (*model.Category, error) {
+	if category.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
+		}
 	}
 
-	
 	if category.IsCommon {
 		userID = 0
 	}
 
-	return s.repository.CreateCategory(ctx, userID, isAdmin, category)
+	if category.ParentID == nil {
+		return s.repository.CreateCategory(ctx, userID, roles.Can(model.PermissionManageCommonContent), category)
+	}
+	if err := s.checkCategoryParent(ctx, userID, category); err != nil {
+		return nil, err
+	}
+
+	var created *model.Category
+	err := s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		var err error
+		created, err = adapters.Repository.CreateCategory(ctx, userID, roles.Can(model.PermissionManageCommonContent), category)
+		if err != nil {
+			return err
+		}
+		return adapters.Repository.SetCategoryParent(ctx, created.ID, category.ParentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	created.ParentID = category.ParentID
+	return created, nil
 }
 
 func (s *PokerService) GetCategories(ctx context.Context, userID model.UserID) (model.CategoryListResponse, error) {
@@ -189,7 +312,7 @@ func (s *PokerService) GetCategory(ctx context.Context, userID model.UserID, cat
 	return s.repository.GetCategory(ctx, userID, categoryID)
 }
 
-func (s *PokerService) UpdateCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64, category *model.Category) (*model.Category, error) {
+func (s *PokerService) UpdateCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64, category *model.Category) (*model.Category, error) {
 	existingCategory, err := s.repository.GetCategory(ctx, userID, categoryID)
 	if err != nil {
 		return nil, err
@@ -197,24 +320,66 @@ func (s *PokerService) UpdateCategory(ctx context.Context, userID model.UserID,
 	if existingCategory == nil {
 		return nil, errors.New("category not found")
 	}
-	if !isAdmin {
-		if existingCategory.IsCommon {
-			return nil, errors.New("only admin can update common categories")
-		}
-		if category.IsCommon {
-			return nil, errors.New("only admin can set category as common")
+	if existingCategory.IsCommon || category.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
 		}
 	}
 
-	
 	if category.IsCommon {
 		userID = 0
 	}
 
-	return s.repository.UpdateCategory(ctx, userID, isAdmin, categoryID, category)
+	// Без parent_id в запросе категория остаётся на прежнем месте
+	if !category.ParentIDSet {
+		category.ParentID = existingCategory.ParentID
+	}
+	if category.ParentID != nil {
+		if err := s.checkCategoryParent(ctx, userID, category); err != nil {
+			return nil, err
+		}
+	}
+	// Подкатегории должны совпадать с родителем по владельцу и языку
+	moved := userID != existingCategory.UserID || category.ProgrammingLanguage != existingCategory.ProgrammingLanguage
+
+	var updated *model.Category
+	err = s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		if err := adapters.Repository.LockCategoryTree(ctx); err != nil {
+			return err
+		}
+		if moved {
+			children, err := adapters.Repository.CountChildCategories(ctx, categoryID)
+			if err != nil {
+				return err
+			}
+			if children > 0 {
+				return fmt.Errorf("%w: category with subcategories cannot change owner or language", model.ErrInvalidParameter)
+			}
+		}
+
+		var err error
+		updated, err = adapters.Repository.UpdateCategory(ctx, userID, roles.Can(model.PermissionManageCommonContent), categoryID, category)
+		if err != nil {
+			return err
+		}
+		return adapters.Repository.SetCategoryParent(ctx, categoryID, category.ParentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated.ParentID = category.ParentID
+	return updated, nil
 }
 
-func (s *PokerService) DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error {
+// DeleteCategory удаляет категорию, а её подкатегории обрабатывает по policy (по умолчанию reject)
+func (s *PokerService) DeleteCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64, policy model.CategoryDeletePolicy) error {
+	if policy == "" {
+		policy = model.CategoryDeleteReject
+	}
+	if policy != model.CategoryDeleteReject && policy != model.CategoryDeleteCascade && policy != model.CategoryDeleteReparent {
+		return fmt.Errorf("%w: unknown delete policy %q", model.ErrInvalidParameter, policy)
+	}
+
 	existingCategory, err := s.repository.GetCategory(ctx, userID, categoryID)
 	if err != nil {
 		return err
@@ -222,17 +387,56 @@ func (s *PokerService) DeleteCategory(ctx context.Context, userID model.UserID,
 	if existingCategory == nil {
 		return errors.New("category not found")
 	}
-	if !isAdmin && existingCategory.IsCommon {
-		return errors.New("only admin can delete common categories")
+	if existingCategory.IsCommon {
+		if err := requirePermission(roles, model.PermissionModerateContent); err != nil {
+			return err
+		}
 	}
-	return s.repository.DeleteCategory(ctx, userID, isAdmin, categoryID)
+
+	return s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		if err := adapters.Repository.LockCategoryTree(ctx); err != nil {
+			return err
+		}
+		switch policy {
+		case model.CategoryDeleteCascade:
+			if err := adapters.Repository.DeleteCategoryDescendants(ctx, categoryID, userID); err != nil {
+				return err
+			}
+		case model.CategoryDeleteReparent:
+			if err := adapters.Repository.ReparentChildCategories(ctx, categoryID); err != nil {
+				return err
+			}
+		default:
+			children, err := adapters.Repository.CountChildCategories(ctx, categoryID)
+			if err != nil {
+				return err
+			}
+			if children > 0 {
+				return fmt.Errorf("%w: category has %d subcategories", model.ErrInvalidParameter, children)
+			}
+		}
+		// Упражнения других пользователей в корзину владельца категории не попадают, поэтому такую категорию не удаляем
+		foreign, err := adapters.Repository.CountForeignExercises(ctx, categoryID, policy == model.CategoryDeleteCascade)
+		if err != nil {
+			return err
+		}
+		if foreign > 0 {
+			return fmt.Errorf("%w: category contains %d exercises of other users", model.ErrInvalidParameter, foreign)
+		}
+		return adapters.Repository.DeleteCategory(ctx, userID, roles.Can(model.PermissionModerateContent), categoryID)
+	})
 }
 
-func (s *PokerService) GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) (*model.ExerciseListWithUserResponse, error) {
-	detailseList, total, err := s.repository.GetExercisesFiltered(ctx, userID, language, categoryID, page, pageSize)
+func (s *PokerService) GetExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) (*model.ExerciseListWithUserResponse, error) {
+	query = normalizeSearchQuery(query)
+	detailseList, total, err := s.repository.GetExercisesFiltered(ctx, userID, language, categoryID, query, tags, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.attachTags(ctx, userID, detailseList); err != nil {
+		return nil, err
+	}
+	highlightSearchMatches(detailseList, query)
 	hasNext := (page * pageSize) < total
 	hasPrev := page > 1
 
@@ -246,8 +450,10 @@ func (s *PokerService) GetExercisesFiltered(ctx context.Context, userID model.Us
 	}, nil
 }
 
-func (s *PokerService) UpsertExerciseStat(userID model.UserID, exerciseID int64, attempts int, successAttempts int) (*model.ExerciseStat, error) {
-	return s.repository.UpsertExerciseStat(context.Background(), userID, exerciseID, attempts, successAttempts)
+// UpsertExerciseStat принимает от клиента только время набора и число символов.
+// Попытки и оценки сюда не попадают: ответ проверяется только в SubmitAttempt.
+func (s *PokerService) UpsertExerciseStat(ctx context.Context, userID model.UserID, update *model.ExerciseStatUpdate) (*model.ExerciseStat, error) {
+	return s.repository.AddExerciseTypingTime(ctx, userID, update.ExerciseID, update.TypingTime, update.TypedChars)
 }
 
 func (s *PokerService) GetExerciseStat(userID model.UserID, exerciseID int64) (*model.ExerciseStat, error) {
@@ -255,14 +461,33 @@ func (s *PokerService) GetExerciseStat(userID model.UserID, exerciseID int64) (*
 }
 
 func (s *PokerService) GetUserStats(ctx context.Context, userID model.UserID) (*model.UserStats, error) {
-	return s.repository.GetUserStats(ctx, userID)
+	stats, err := s.repository.GetUserStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.CurrentStreak, stats.LongestStreak, err = s.repository.GetUserStreaks(ctx, userID, location.String())
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
 }
 
-func (s *PokerService) GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, page, pageSize int) (*model.ExerciseListWithUserResponse, error) {
-	detailseList, total, err := s.repository.GetUserExercisesFiltered(ctx, userID, language, categoryID, page, pageSize)
+func (s *PokerService) GetUserExercisesFiltered(ctx context.Context, userID model.UserID, language *string, categoryID int64, query string, tags *model.TagFilter, page, pageSize int) (*model.ExerciseListWithUserResponse, error) {
+	query = normalizeSearchQuery(query)
+	detailseList, total, err := s.repository.GetUserExercisesFiltered(ctx, userID, language, categoryID, query, tags, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.attachTags(ctx, userID, detailseList); err != nil {
+		return nil, err
+	}
+	highlightSearchMatches(detailseList, query)
 	hasNext := (page * pageSize) < total
 	hasPrev := page > 1
 
@@ -296,10 +521,6 @@ func (s *PokerService) GetAllUsers(ctx context.Context) ([]*model.User, error) {
 	return s.repository.GetAllUsers(ctx)
 }
 
-func (s *PokerService) SetUserAdmin(ctx context.Context, userID model.UserID, isAdmin bool) (*model.User, error) {
-	return s.repository.SetUserAdmin(ctx, userID, isAdmin)
-}
-
 // Методы для refresh-токенов
 func (s *PokerService) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
 	return s.repository.CreateRefreshToken(ctx, token)
@@ -309,7 +530,7 @@ func (s *PokerService) GetRefreshTokenByToken(ctx context.Context, token string)
 	return s.repository.GetRefreshTokenByToken(ctx, token)
 }
 
-func (s *PokerService) RevokeRefreshToken(ctx context.Context, token string) error {
+func (s *PokerService) RevokeRefreshToken(ctx context.Context, token string) (bool, error) {
 	return s.repository.RevokeRefreshToken(ctx, token)
 }
 
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"time"
+)
+
+// GetUserActivity возвращает активность пользователя по дням за период [from, to] для тепловой карты.
+// Нулевые from и to означают последние model.DefaultActivityDays дней по часовому поясу пользователя.
+func (s *PokerService) GetUserActivity(ctx context.Context, userID model.UserID, from, to time.Time) (*model.UserActivity, error) {
+	location, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if to.IsZero() {
+		now := time.Now().In(location)
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -(model.DefaultActivityDays - 1))
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("%w: from is after to", model.ErrInvalidParameter)
+	}
+	if to.Sub(from) >= model.MaxActivityDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: period is longer than %d days", model.ErrInvalidParameter, model.MaxActivityDays)
+	}
+
+	timezone := location.String()
+	activeDays, err := s.repository.GetUserDailyActivity(ctx, userID, timezone, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	current, longest, err := s.repository.GetUserStreaks(ctx, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserActivity{
+		From:          from.Format(time.DateOnly),
+		To:            to.Format(time.DateOnly),
+		Timezone:      timezone,
+		Days:          fillActivityDays(activeDays, from, to),
+		CurrentStreak: current,
+		LongestStreak: longest,
+	}, nil
+}
+
+// SetUserTimezone сохраняет часовой пояс пользователя в формате базы IANA, например Europe/Moscow.
+// Имя должно быть известно и Go, и Postgres: по нему группируются попытки в запросах.
+func (s *PokerService) SetUserTimezone(ctx context.Context, userID model.UserID, timezone string) error {
+	if timezone == "" {
+		return fmt.Errorf("%w: timezone is empty", model.ErrInvalidParameter)
+	}
+	if _, err := loadLocation(timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %s", model.ErrInvalidParameter, timezone)
+	}
+	return s.repository.SetUserTimezone(ctx, userID, timezone)
+}
+
+// userLocation возвращает часовой пояс пользователя; неизвестный пояс заменяется на UTC
+func (s *PokerService) userLocation(ctx context.Context, userID model.UserID) (*time.Location, error) {
+	timezone, err := s.repository.GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := loadLocation(timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return location, nil
+}
+
+// loadLocation загружает пояс IANA. "Local" означает пояс сервера и неизвестен Postgres, поэтому отклоняется
+func loadLocation(timezone string) (*time.Location, error) {
+	if timezone == "Local" {
+		return nil, fmt.Errorf("timezone %s is not allowed", timezone)
+	}
+	return time.LoadLocation(timezone)
+}
+
+// fillActivityDays дополняет список активных дней пустыми днями, чтобы период был непрерывным
+func fillActivityDays(activeDays []*model.DailyActivity, from, to time.Time) []*model.DailyActivity {
+	byDate := make(map[string]*model.DailyActivity, len(activeDays))
+	for _, day := range activeDays {
+		byDate[day.Date] = day
+	}
+
+	days := []*model.DailyActivity{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		if activity, ok := byDate[date]; ok {
+			days = append(days, activity)
+			continue
+		}
+		days = append(days, &model.DailyActivity{Date: date})
+	}
+	return days
+}
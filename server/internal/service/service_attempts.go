@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"inzarubin80/MemCode/internal/model"
+)
+
+// GetExerciseAttempts возвращает историю попыток пользователя по задаче
+func (s *PokerService) GetExerciseAttempts(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) (*model.ExerciseAttemptListResponse, error) {
+	attempts, total, err := s.repository.GetExerciseAttempts(ctx, userID, exerciseID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	hasNext := (page * pageSize) < total
+	hasPrev := page > 1
+
+	return &model.ExerciseAttemptListResponse{
+		Attempts: attempts,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		HasNext:  hasNext,
+		HasPrev:  hasPrev,
+	}, nil
+}
+
+// hashCode возвращает sha256 присланного кода, сам код в истории не хранится
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,11 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
 	"inzarubin80/MemCode/internal/model"
 )
 
 func (s *PokerService) Authorization(ctx context.Context, accessToken string) (*model.Claims, error) {
 
-	return s.accessTokenService.ValidateToken(accessToken)
+	claims, err := s.accessTokenService.ValidateToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Токены, выпущенные до смены роли пользователя, больше не действуют.
+	// iat хранится с точностью до секунды, поэтому токен, выпущенный в ту же секунду, что и отзыв, тоже отклоняется.
+	validAfter, err := s.repository.GetUserTokensValidAfter(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if validAfter != nil && claims.IssuedAt <= validAfter.Unix() {
+		return nil, errors.New("token revoked")
+	}
+
+	return claims, nil
 
 }
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/bundle"
+	"inzarubin80/MemCode/internal/grading"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"strings"
+	"time"
+)
+
+const (
+	exportPageSize = 200
+)
+
+// ExportBundle выгружает категории и упражнения пользователя, не загружая их в память целиком
+func (s *PokerService) ExportBundle(ctx context.Context, userID model.UserID, withStats bool, writer bundle.Writer) error {
+	categories, err := s.repository.GetOwnedCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Родитель, не попавший в выгрузку, не переносится: такая категория станет корневой
+	exported := make(map[int64]bool, len(categories))
+	for _, category := range categories {
+		exported[category.ID] = true
+	}
+	for _, category := range categories {
+		if category.ParentID != nil && !exported[*category.ParentID] {
+			category.ParentID = nil
+		}
+	}
+
+	header := &model.BundleHeader{
+		Version:    model.BundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Categories: categories,
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	var afterID int64
+	for {
+		exercises, err := s.repository.GetOwnedExercises(ctx, userID, afterID, exportPageSize, withStats)
+		if err != nil {
+			return err
+		}
+		if err := s.attachBundleTags(ctx, userID, exercises); err != nil {
+			return err
+		}
+		if err := writer.WriteExercises(exercises); err != nil {
+			return err
+		}
+		if len(exercises) < exportPageSize {
+			break
+		}
+		afterID = exercises[len(exercises)-1].ID
+	}
+
+	return writer.Close()
+}
+
+// ImportBundle восстанавливает выгрузку в одной транзакции, сопоставляя ID из выгрузки с новыми.
+// Категория совпадает с существующей по имени и языку, упражнение - по названию внутри категории.
+// Общая категория из выгрузки связывается с общей категорией сервера, а если такой нет - создаётся личная.
+// При strategy=skip совпавшая категория переиспользуется, а упражнение пропускается;
+// при strategy=rename создаются копии с суффиксом " (2)", " (3)" и т.д.
+// Родитель назначается только созданным категориям; если родитель связан с общей категорией,
+// потомок остаётся корневым. Метки сопоставляются по названию, недостающие создаются личными.
+func (s *PokerService) ImportBundle(ctx context.Context, userID model.UserID, data *model.Bundle, strategy string) (*model.BundleImportResult, error) {
+	if err := validateBundle(data, strategy); err != nil {
+		return nil, err
+	}
+
+	result := &model.BundleImportResult{
+		CategoryIDs: map[int64]int64{},
+		ExerciseIDs: map[int64]int64{},
+	}
+	createdCategories := map[int64]bool{}
+	linkedCategories := map[int64]bool{}
+
+	err := s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		repo := adapters.Repository
+
+		existingCategories, err := repo.GetOwnedCategories(ctx, userID)
+		if err != nil {
+			return err
+		}
+		categoryByKey := map[string]int64{}
+		for _, category := range existingCategories {
+			if !category.IsCommon {
+				categoryByKey[categoryKey(category.Name, category.ProgrammingLanguage)] = category.ID
+			}
+		}
+
+		commonCategories, err := repo.GetCommonCategories(ctx)
+		if err != nil {
+			return err
+		}
+		commonByKey := map[string]int64{}
+		for _, category := range commonCategories {
+			key := categoryKey(category.Name, category.ProgrammingLanguage)
+			if _, ok := commonByKey[key]; !ok {
+				commonByKey[key] = category.ID
+			}
+		}
+
+		// Названия существующих упражнений по категориям, чтобы находить дубликаты
+		titles := map[int64]map[string]bool{}
+		var afterID int64
+		for {
+			exercises, err := repo.GetOwnedExercises(ctx, userID, afterID, exportPageSize, false)
+			if err != nil {
+				return err
+			}
+			for _, exercise := range exercises {
+				markTaken(titles, exercise.CategoryID, exercise.Title)
+			}
+			if len(exercises) < exportPageSize {
+				break
+			}
+			afterID = exercises[len(exercises)-1].ID
+		}
+
+		for _, category := range data.Categories {
+			if category.IsCommon {
+				if commonID, ok := commonByKey[categoryKey(category.Name, category.ProgrammingLanguage)]; ok {
+					result.CategoryIDs[category.ID] = commonID
+					linkedCategories[category.ID] = true
+					result.CategoriesLinked++
+					continue
+				}
+			}
+
+			name := category.Name
+			if existingID, ok := categoryByKey[categoryKey(name, category.ProgrammingLanguage)]; ok {
+				if strategy == model.ConflictStrategySkip {
+					result.CategoryIDs[category.ID] = existingID
+					result.CategoriesSkipped++
+					continue
+				}
+				name = uniqueName(name, func(candidate string) bool {
+					_, taken := categoryByKey[categoryKey(candidate, category.ProgrammingLanguage)]
+					return taken
+				})
+				result.CategoriesRenamed++
+			}
+
+			status := category.Status
+			if status == "" {
+				status = model.CategoryStatusActive
+			}
+			created, err := repo.CreateCategory(ctx, userID, false, &model.Category{
+				Name:                name,
+				Description:         category.Description,
+				ProgrammingLanguage: category.ProgrammingLanguage,
+				Color:               category.Color,
+				Icon:                category.Icon,
+				Status:              status,
+			})
+			if err != nil {
+				return fmt.Errorf("category %q: %w", category.Name, err)
+			}
+			categoryByKey[categoryKey(name, category.ProgrammingLanguage)] = created.ID
+			result.CategoryIDs[category.ID] = created.ID
+			createdCategories[category.ID] = true
+			result.CategoriesCreated++
+		}
+
+		// Родителей назначаем после создания всех категорий: в выгрузке родитель может идти после потомка
+		if err := repo.LockCategoryTree(ctx); err != nil {
+			return err
+		}
+		for _, category := range data.Categories {
+			if category.ParentID == nil || !createdCategories[category.ID] || linkedCategories[*category.ParentID] {
+				continue
+			}
+			parentID := result.CategoryIDs[*category.ParentID]
+			if err := repo.SetCategoryParent(ctx, result.CategoryIDs[category.ID], &parentID); err != nil {
+				return fmt.Errorf("category %q: %w", category.Name, err)
+			}
+		}
+
+		existingTags, err := repo.GetTags(ctx, userID)
+		if err != nil {
+			return err
+		}
+		tagByName := map[string]int64{}
+		for _, tag := range existingTags {
+			// Личная метка идёт раньше общей с тем же названием
+			if _, ok := tagByName[strings.ToLower(tag.Name)]; !ok {
+				tagByName[strings.ToLower(tag.Name)] = tag.ID
+			}
+		}
+
+		for _, exercise := range data.Exercises {
+			categoryID := result.CategoryIDs[exercise.CategoryID]
+			title := exercise.Title
+			if titles[categoryID][strings.ToLower(title)] {
+				if strategy == model.ConflictStrategySkip {
+					result.ExercisesSkipped++
+					continue
+				}
+				title = uniqueName(title, func(candidate string) bool {
+					return titles[categoryID][strings.ToLower(candidate)]
+				})
+				result.ExercisesRenamed++
+			}
+
+			comparisonMode := exercise.ComparisonMode
+			if comparisonMode == "" {
+				comparisonMode = model.DefaultComparisonMode
+			}
+			created, err := repo.CreateExercise(ctx, userID, false, &model.Exercise{
+				Title:               title,
+				Description:         exercise.Description,
+				CategoryID:          categoryID,
+				ProgrammingLanguage: exercise.ProgrammingLanguage,
+				CodeToRemember:      exercise.CodeToRemember,
+				ComparisonMode:      comparisonMode,
+			})
+			if err != nil {
+				return fmt.Errorf("exercise %q: %w", exercise.Title, err)
+			}
+			markTaken(titles, categoryID, title)
+			result.ExerciseIDs[exercise.ID] = created.ID
+			result.ExercisesCreated++
+
+			for _, name := range exercise.Tags {
+				tagID, ok := tagByName[strings.ToLower(name)]
+				if !ok {
+					tag, err := repo.CreateTag(ctx, userID, name)
+					if err != nil {
+						return fmt.Errorf("tag %q: %w", name, err)
+					}
+					tagID = tag.ID
+					tagByName[strings.ToLower(name)] = tagID
+					result.TagsCreated++
+				}
+				if err := repo.AddExerciseTag(ctx, created.ID, tagID); err != nil {
+					return fmt.Errorf("exercise %q tag %q: %w", exercise.Title, name, err)
+				}
+			}
+
+			if stat := clampBundleStat(exercise.Stat); stat != nil {
+				if err := repo.RestoreExerciseStat(ctx, userID, created.ID, stat); err != nil {
+					return fmt.Errorf("exercise %q stat: %w", exercise.Title, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateBundle проверяет выгрузку до начала транзакции
+func validateBundle(data *model.Bundle, strategy string) error {
+	if strategy != model.ConflictStrategySkip && strategy != model.ConflictStrategyRename {
+		return fmt.Errorf("%w: unknown conflict strategy %s", model.ErrInvalidParameter, strategy)
+	}
+	if data.Version < 1 || data.Version > model.BundleVersion {
+		return fmt.Errorf("%w: unsupported bundle version %d", model.ErrInvalidParameter, data.Version)
+	}
+
+	categoryIDs := map[int64]bool{}
+	categoryByID := map[int64]*model.BundleCategory{}
+	for _, category := range data.Categories {
+		if category.Name == "" {
+			return fmt.Errorf("%w: category %d has no name", model.ErrInvalidParameter, category.ID)
+		}
+		if categoryIDs[category.ID] {
+			return fmt.Errorf("%w: duplicate category id %d", model.ErrInvalidParameter, category.ID)
+		}
+		categoryIDs[category.ID] = true
+		categoryByID[category.ID] = category
+	}
+
+	for _, category := range data.Categories {
+		if category.ParentID == nil {
+			continue
+		}
+		parent, ok := categoryByID[*category.ParentID]
+		switch {
+		case !ok:
+			return fmt.Errorf("%w: category %d refers to unknown parent %d", model.ErrInvalidParameter, category.ID, *category.ParentID)
+		case parent.ProgrammingLanguage != category.ProgrammingLanguage:
+			return fmt.Errorf("%w: category %d has parent with another programming language", model.ErrInvalidParameter, category.ID)
+		}
+	}
+
+	// Поднимаемся к корню; категория, встреченная дважды за подъём, замыкает цикл.
+	// Проверенные категории запоминаются, поэтому каждая проходится один раз.
+	acyclic := map[int64]bool{}
+	for _, category := range data.Categories {
+		path := map[int64]bool{}
+		for current := category; current.ParentID != nil && !acyclic[current.ID]; current = categoryByID[*current.ParentID] {
+			if path[current.ID] {
+				return fmt.Errorf("%w: category %d is its own ancestor", model.ErrInvalidParameter, current.ID)
+			}
+			path[current.ID] = true
+		}
+		for id := range path {
+			acyclic[id] = true
+		}
+	}
+
+	for _, exercise := range data.Exercises {
+		switch {
+		case exercise.Title == "" || exercise.CodeToRemember == "":
+			return fmt.Errorf("%w: exercise %d has no title or code", model.ErrInvalidParameter, exercise.ID)
+		case len(exercise.CodeToRemember) > model.MaxExerciseCodeLength:
+			return fmt.Errorf("%w: exercise %d code is longer than %d bytes", model.ErrInvalidParameter, exercise.ID, model.MaxExerciseCodeLength)
+		case !categoryIDs[exercise.CategoryID]:
+			return fmt.Errorf("%w: exercise %d refers to unknown category %d", model.ErrInvalidParameter, exercise.ID, exercise.CategoryID)
+		case !model.IsSupportedLanguage(exercise.ProgrammingLanguage):
+			return fmt.Errorf("%w: exercise %d has unsupported language %s", model.ErrInvalidParameter, exercise.ID, exercise.ProgrammingLanguage)
+		case exercise.ComparisonMode != "" && !model.IsSupportedComparisonMode(exercise.ComparisonMode):
+			return fmt.Errorf("%w: exercise %d has unsupported comparison mode %s", model.ErrInvalidParameter, exercise.ID, exercise.ComparisonMode)
+		}
+
+		// Названия меток нормализуются так же, как при создании метки
+		for i, tag := range exercise.Tags {
+			name, err := normalizeTagName(tag)
+			if err != nil {
+				return fmt.Errorf("exercise %d: %w", exercise.ID, err)
+			}
+			exercise.Tags[i] = name
+		}
+	}
+	return nil
+}
+
+// attachBundleTags заполняет названия меток упражнений одним запросом на страницу
+func (s *PokerService) attachBundleTags(ctx context.Context, userID model.UserID, exercises []*model.BundleExercise) error {
+	ids := make([]int64, 0, len(exercises))
+	for _, exercise := range exercises {
+		ids = append(ids, exercise.ID)
+	}
+	tags, err := s.repository.GetExercisesTags(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	for _, exercise := range exercises {
+		for _, tag := range tags[exercise.ID] {
+			exercise.Tags = append(exercise.Tags, tag.Name)
+		}
+	}
+	return nil
+}
+
+// clampBundleStat приводит статистику из выгрузки к значениям, которых можно достичь попытками:
+// выгрузку могли отредактировать вручную. Статистика без попыток не переносится.
+func clampBundleStat(stat *model.BundleExerciseStat) *model.BundleExerciseStat {
+	if stat == nil || stat.TotalAttempts <= 0 {
+		return nil
+	}
+	attempts := stat.TotalAttempts
+	return &model.BundleExerciseStat{
+		TotalAttempts:      attempts,
+		SuccessfulAttempts: min(max(stat.SuccessfulAttempts, 0), attempts),
+		TotalTypingTime:    min(max(stat.TotalTypingTime, 0), int64(attempts)*model.MaxAttemptTypingTime),
+		TotalTypedChars:    min(max(stat.TotalTypedChars, 0), attempts*model.MaxAttemptTypedChars),
+		TotalScore:         min(max(stat.TotalScore, 0), int64(attempts)*grading.MaxScore),
+		BestScore:          min(max(stat.BestScore, 0), grading.MaxScore),
+		LastScore:          min(max(stat.LastScore, 0), grading.MaxScore),
+	}
+}
+
+func categoryKey(name string, language model.ProgrammingLanguage) string {
+	return strings.ToLower(name) + "\x00" + string(language)
+}
+
+func markTaken(titles map[int64]map[string]bool, categoryID int64, title string) {
+	if titles[categoryID] == nil {
+		titles[categoryID] = map[string]bool{}
+	}
+	titles[categoryID][strings.ToLower(title)] = true
+}
+
+// uniqueName подбирает имя вида "name (N)", которое ещё не занято
+func uniqueName(name string, taken func(string) bool) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
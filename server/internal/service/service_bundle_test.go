@@ -0,0 +1,509 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/bundle"
+	"inzarubin80/MemCode/internal/grading"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type (
+	// bundleStore - хранилище в памяти, повторяющее поведение репозитория для выгрузки и восстановления.
+	// Остальные методы stor.Repository не реализованы: их вызов приведёт к панике.
+	bundleStore struct {
+		stor.Repository
+
+		nextID        int64
+		categories    []*model.BundleCategory
+		categoryOwner map[int64]model.UserID
+		exercises     []*model.BundleExercise
+		exerciseOwner map[int64]model.UserID
+		stats         map[int64]*model.BundleExerciseStat
+		tags          []*model.Tag
+		exerciseTags  map[int64][]int64
+	}
+
+	// bundleServiceRepository отдаёт сервису те же данные вне транзакции
+	bundleServiceRepository struct {
+		Repository
+		store *bundleStore
+	}
+
+	bundleTransactions struct {
+		store *bundleStore
+	}
+)
+
+func newBundleStore() *bundleStore {
+	return &bundleStore{
+		categoryOwner: map[int64]model.UserID{},
+		exerciseOwner: map[int64]model.UserID{},
+		stats:         map[int64]*model.BundleExerciseStat{},
+		exerciseTags:  map[int64][]int64{},
+	}
+}
+
+func newBundleService(store *bundleStore) *PokerService {
+	return NewPokerService(&bundleServiceRepository{store: store}, nil, nil, nil, bundleTransactions{store: store}, nil, "")
+}
+
+func (t bundleTransactions) Transact(ctx context.Context, txFunc func(adapters stor.Adapters) error) error {
+	return txFunc(stor.Adapters{Repository: t.store})
+}
+
+func (r *bundleServiceRepository) GetOwnedCategories(ctx context.Context, userID model.UserID) ([]*model.BundleCategory, error) {
+	return r.store.GetOwnedCategories(ctx, userID)
+}
+
+func (r *bundleServiceRepository) GetOwnedExercises(ctx context.Context, userID model.UserID, afterID int64, limit int, withStats bool) ([]*model.BundleExercise, error) {
+	return r.store.GetOwnedExercises(ctx, userID, afterID, limit, withStats)
+}
+
+func (r *bundleServiceRepository) GetExercisesTags(ctx context.Context, userID model.UserID, exerciseIDs []int64) (map[int64][]*model.Tag, error) {
+	tags := map[int64][]*model.Tag{}
+	for _, exerciseID := range exerciseIDs {
+		for _, tagID := range r.store.exerciseTags[exerciseID] {
+			tag := r.store.tag(tagID)
+			if tag.UserID == userID || tag.UserID == 0 {
+				tags[exerciseID] = append(tags[exerciseID], tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+func (s *bundleStore) GetOwnedCategories(ctx context.Context, userID model.UserID) ([]*model.BundleCategory, error) {
+	categories := []*model.BundleCategory{}
+	for _, category := range s.categories {
+		owner := s.categoryOwner[category.ID]
+		used := slices.ContainsFunc(s.exercises, func(exercise *model.BundleExercise) bool {
+			return exercise.CategoryID == category.ID && s.exerciseOwner[exercise.ID] == userID
+		})
+		if owner == userID || (owner == 0 && used) {
+			copied := *category
+			categories = append(categories, &copied)
+		}
+	}
+	return categories, nil
+}
+
+func (s *bundleStore) GetCommonCategories(ctx context.Context) ([]*model.BundleCategory, error) {
+	categories := []*model.BundleCategory{}
+	for _, category := range s.categories {
+		if category.IsCommon {
+			copied := *category
+			categories = append(categories, &copied)
+		}
+	}
+	return categories, nil
+}
+
+func (s *bundleStore) GetOwnedExercises(ctx context.Context, userID model.UserID, afterID int64, limit int, withStats bool) ([]*model.BundleExercise, error) {
+	exercises := []*model.BundleExercise{}
+	for _, exercise := range s.exercises {
+		if s.exerciseOwner[exercise.ID] != userID || exercise.ID <= afterID || len(exercises) == limit {
+			continue
+		}
+		copied := *exercise
+		copied.Stat = nil
+		if stat, ok := s.stats[exercise.ID]; ok && withStats {
+			statCopy := *stat
+			copied.Stat = &statCopy
+		}
+		exercises = append(exercises, &copied)
+	}
+	return exercises, nil
+}
+
+func (s *bundleStore) RestoreExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, stat *model.BundleExerciseStat) error {
+	if _, ok := s.stats[exerciseID]; !ok {
+		copied := *stat
+		s.stats[exerciseID] = &copied
+	}
+	return nil
+}
+
+func (s *bundleStore) CreateCategory(ctx context.Context, userID model.UserID, isAdmin bool, category *model.Category) (*model.Category, error) {
+	s.nextID++
+	s.categories = append(s.categories, &model.BundleCategory{
+		ID:                  s.nextID,
+		Name:                category.Name,
+		Description:         category.Description,
+		ProgrammingLanguage: category.ProgrammingLanguage,
+		Color:               category.Color,
+		Icon:                category.Icon,
+		Status:              category.Status,
+		IsCommon:            userID == 0,
+	})
+	s.categoryOwner[s.nextID] = userID
+	created := *category
+	created.ID = s.nextID
+	return &created, nil
+}
+
+func (s *bundleStore) CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error) {
+	s.nextID++
+	s.exercises = append(s.exercises, &model.BundleExercise{
+		ID:                  s.nextID,
+		CategoryID:          exercise.CategoryID,
+		Title:               exercise.Title,
+		Description:         exercise.Description,
+		ProgrammingLanguage: exercise.ProgrammingLanguage,
+		CodeToRemember:      exercise.CodeToRemember,
+		ComparisonMode:      exercise.ComparisonMode,
+	})
+	s.exerciseOwner[s.nextID] = userID
+	created := *exercise
+	created.ID = s.nextID
+	return &created, nil
+}
+
+func (s *bundleStore) LockCategoryTree(ctx context.Context) error {
+	return nil
+}
+
+func (s *bundleStore) SetCategoryParent(ctx context.Context, categoryID int64, parentID *int64) error {
+	for _, category := range s.categories {
+		if category.ID == categoryID {
+			category.ParentID = parentID
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: category %d", model.ErrorNotFound, categoryID)
+}
+
+func (s *bundleStore) GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error) {
+	tags := []*model.TagWithCount{}
+	for _, tag := range s.tags {
+		if tag.UserID == userID || tag.UserID == 0 {
+			tags = append(tags, &model.TagWithCount{Tag: *tag})
+		}
+	}
+	// Как в репозитории: по названию, личная метка раньше общей
+	sort.SliceStable(tags, func(i, j int) bool {
+		if a, b := strings.ToLower(tags[i].Name), strings.ToLower(tags[j].Name); a != b {
+			return a < b
+		}
+		return tags[i].UserID > tags[j].UserID
+	})
+	return tags, nil
+}
+
+func (s *bundleStore) CreateTag(ctx context.Context, ownerID model.UserID, name string) (*model.Tag, error) {
+	s.nextID++
+	tag := &model.Tag{ID: s.nextID, UserID: ownerID, Name: name, IsCommon: ownerID == 0}
+	s.tags = append(s.tags, tag)
+	return tag, nil
+}
+
+func (s *bundleStore) AddExerciseTag(ctx context.Context, exerciseID int64, tagID int64) error {
+	if !slices.Contains(s.exerciseTags[exerciseID], tagID) {
+		s.exerciseTags[exerciseID] = append(s.exerciseTags[exerciseID], tagID)
+	}
+	return nil
+}
+
+func (s *bundleStore) tag(tagID int64) *model.Tag {
+	for _, tag := range s.tags {
+		if tag.ID == tagID {
+			return tag
+		}
+	}
+	return nil
+}
+
+// addCommonGoCategory создаёт общую категорию, с которой связывается категория из выгрузки
+func (s *bundleStore) addCommonGoCategory() int64 {
+	created, _ := s.CreateCategory(context.Background(), 0, true, &model.Category{Name: "Common Go", ProgrammingLanguage: model.LanguageGo, Status: model.CategoryStatusActive})
+	return created.ID
+}
+
+// seedBundleSource заполняет хранилище пользователя: вложенные категории, упражнение в общей категории,
+// личные и общие метки и статистику
+func seedBundleSource(t *testing.T, store *bundleStore, userID model.UserID) {
+	t.Helper()
+	ctx := context.Background()
+	commonID := store.addCommonGoCategory()
+
+	// Потомок создаётся раньше родителя, чтобы в выгрузке родитель шёл после него
+	child, _ := store.CreateCategory(ctx, userID, false, &model.Category{Name: "Loops", ProgrammingLanguage: model.LanguageGo, Status: model.CategoryStatusActive})
+	root, _ := store.CreateCategory(ctx, userID, false, &model.Category{Name: "Go basics", Description: "first steps", ProgrammingLanguage: model.LanguageGo, Color: "#00add8", Status: model.CategoryStatusActive})
+	if err := store.SetCategoryParent(ctx, child.ID, &root.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	hello, _ := store.CreateExercise(ctx, userID, false, &model.Exercise{Title: "Hello", CategoryID: root.ID, ProgrammingLanguage: model.LanguageGo,
+		CodeToRemember: "fmt.Println(\"hello\")", ComparisonMode: model.ComparisonModeToken})
+	loop, _ := store.CreateExercise(ctx, userID, false, &model.Exercise{Title: "For range", CategoryID: child.ID, ProgrammingLanguage: model.LanguageGo,
+		CodeToRemember: "for i := range items {\n}", ComparisonMode: model.ComparisonModeStrict})
+	common, _ := store.CreateExercise(ctx, userID, false, &model.Exercise{Title: "Errors", CategoryID: commonID, ProgrammingLanguage: model.LanguageGo,
+		CodeToRemember: "errors.New(\"boom\")", ComparisonMode: model.DefaultComparisonMode})
+
+	basics, _ := store.CreateTag(ctx, userID, "basics")
+	output, _ := store.CreateTag(ctx, userID, "Output")
+	std, _ := store.CreateTag(ctx, 0, "std")
+	store.AddExerciseTag(ctx, hello.ID, basics.ID)
+	store.AddExerciseTag(ctx, hello.ID, output.ID)
+	store.AddExerciseTag(ctx, common.ID, std.ID)
+
+	store.RestoreExerciseStat(ctx, userID, loop.ID, &model.BundleExerciseStat{
+		TotalAttempts: 3, SuccessfulAttempts: 2, TotalTypingTime: 90, TotalTypedChars: 120, TotalScore: 250, BestScore: 100, LastScore: 80,
+	})
+}
+
+type (
+	// bundleView - выгрузка без ID: категории и родители по именам, метки отсортированы
+	bundleView struct {
+		Categories []string
+		Exercises  []string
+	}
+)
+
+func viewOf(data *model.Bundle) bundleView {
+	names := map[int64]string{}
+	for _, category := range data.Categories {
+		names[category.ID] = category.Name
+	}
+
+	view := bundleView{}
+	for _, category := range data.Categories {
+		parent := ""
+		if category.ParentID != nil {
+			parent = names[*category.ParentID]
+		}
+		view.Categories = append(view.Categories, fmt.Sprintf("%s|%s|%s|%s|%s|common=%v|parent=%s",
+			category.Name, category.Description, category.ProgrammingLanguage, category.Color, category.Status, category.IsCommon, parent))
+	}
+	for _, exercise := range data.Exercises {
+		tags := slices.Clone(exercise.Tags)
+		sort.Strings(tags)
+		view.Exercises = append(view.Exercises, fmt.Sprintf("%s|%s|%s|%q|%s|tags=%v|stat=%+v",
+			exercise.Title, names[exercise.CategoryID], exercise.ProgrammingLanguage, exercise.CodeToRemember, exercise.ComparisonMode, tags, exercise.Stat))
+	}
+	sort.Strings(view.Categories)
+	sort.Strings(view.Exercises)
+	return view
+}
+
+func exportBundle(t *testing.T, service *PokerService, userID model.UserID, format string) *model.Bundle {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := bundle.NewWriter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ExportBundle(context.Background(), userID, true, writer); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	data, err := bundle.Decode(format, &buf)
+	if err != nil {
+		t.Fatalf("Decode: %v\n%s", err, buf.String())
+	}
+	return data
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	for _, format := range []string{model.BundleFormatJSON, model.BundleFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			source := newBundleStore()
+			seedBundleSource(t, source, 1)
+			exported := exportBundle(t, newBundleService(source), 1, format)
+
+			target := newBundleStore()
+			target.addCommonGoCategory()
+			targetService := newBundleService(target)
+			result, err := targetService.ImportBundle(context.Background(), 2, exported, model.ConflictStrategySkip)
+			if err != nil {
+				t.Fatalf("ImportBundle: %v", err)
+			}
+
+			want := model.BundleImportResult{CategoriesCreated: 2, CategoriesLinked: 1, ExercisesCreated: 3, TagsCreated: 3}
+			got := *result
+			got.CategoryIDs, got.ExerciseIDs = nil, nil
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("result = %+v, want %+v", got, want)
+			}
+
+			reexported := exportBundle(t, targetService, 2, format)
+			if got, want := viewOf(reexported), viewOf(exported); !reflect.DeepEqual(got, want) {
+				t.Errorf("bundle changed after round trip:\n got  %q\n want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestImportBundleConflictStrategies(t *testing.T) {
+	source := newBundleStore()
+	seedBundleSource(t, source, 1)
+	exported := exportBundle(t, newBundleService(source), 1, model.BundleFormatJSON)
+
+	tests := []struct {
+		name       string
+		strategy   string
+		want       model.BundleImportResult
+		categories []string
+	}{
+		{
+			name:     "skip",
+			strategy: model.ConflictStrategySkip,
+			want:     model.BundleImportResult{CategoriesSkipped: 2, CategoriesLinked: 1, ExercisesSkipped: 3},
+			categories: []string{
+				"Common Go||go||active|common=true|parent=",
+				"Go basics|first steps|go|#00add8|active|common=false|parent=",
+				"Loops||go||active|common=false|parent=Go basics",
+			},
+		},
+		{
+			name:     "rename",
+			strategy: model.ConflictStrategyRename,
+			// Упражнения в новых категориях не конфликтуют, переименовывается только упражнение в общей
+			want: model.BundleImportResult{CategoriesCreated: 2, CategoriesRenamed: 2, CategoriesLinked: 1, ExercisesCreated: 3, ExercisesRenamed: 1},
+			categories: []string{
+				"Common Go||go||active|common=true|parent=",
+				"Go basics (2)|first steps|go|#00add8|active|common=false|parent=",
+				"Go basics|first steps|go|#00add8|active|common=false|parent=",
+				"Loops (2)||go||active|common=false|parent=Go basics (2)",
+				"Loops||go||active|common=false|parent=Go basics",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newBundleStore()
+			target.addCommonGoCategory()
+			service := newBundleService(target)
+			if _, err := service.ImportBundle(context.Background(), 2, exported, tt.strategy); err != nil {
+				t.Fatalf("first ImportBundle: %v", err)
+			}
+
+			result, err := service.ImportBundle(context.Background(), 2, exported, tt.strategy)
+			if err != nil {
+				t.Fatalf("second ImportBundle: %v", err)
+			}
+			got := *result
+			got.CategoryIDs, got.ExerciseIDs = nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+
+			if got := viewOf(exportBundle(t, service, 2, model.BundleFormatJSON)).Categories; !reflect.DeepEqual(got, tt.categories) {
+				t.Errorf("categories = %q, want %q", got, tt.categories)
+			}
+		})
+	}
+}
+
+func TestImportBundleChildOfSkippedCategory(t *testing.T) {
+	source := newBundleStore()
+	seedBundleSource(t, source, 1)
+	exported := exportBundle(t, newBundleService(source), 1, model.BundleFormatJSON)
+
+	// У пользователя уже есть родительская категория: потомок создаётся внутри неё
+	target := newBundleStore()
+	existing, _ := target.CreateCategory(context.Background(), 2, false, &model.Category{Name: "go BASICS", ProgrammingLanguage: model.LanguageGo, Status: model.CategoryStatusActive})
+	result, err := newBundleService(target).ImportBundle(context.Background(), 2, exported, model.ConflictStrategySkip)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if result.CategoriesSkipped != 1 || result.CategoriesCreated != 2 {
+		t.Errorf("result = %+v, want 1 skipped and 2 created categories", result)
+	}
+
+	for _, category := range target.categories {
+		if category.Name == "Loops" && (category.ParentID == nil || *category.ParentID != existing.ID) {
+			t.Errorf("Loops parent = %v, want %d", category.ParentID, existing.ID)
+		}
+	}
+}
+
+func TestValidateBundle(t *testing.T) {
+	ptr := func(id int64) *int64 { return &id }
+	category := func(id int64, language model.ProgrammingLanguage, parentID *int64) *model.BundleCategory {
+		return &model.BundleCategory{ID: id, Name: fmt.Sprintf("c%d", id), ProgrammingLanguage: language, ParentID: parentID}
+	}
+	exercise := func(tags ...string) *model.BundleExercise {
+		return &model.BundleExercise{ID: 1, CategoryID: 1, Title: "t", ProgrammingLanguage: model.LanguageGo, CodeToRemember: "x", Tags: tags}
+	}
+	bundleOf := func(exercises []*model.BundleExercise, categories ...*model.BundleCategory) *model.Bundle {
+		return &model.Bundle{BundleHeader: model.BundleHeader{Version: model.BundleVersion, Categories: categories}, Exercises: exercises}
+	}
+
+	tests := []struct {
+		name    string
+		data    *model.Bundle
+		wantErr bool
+	}{
+		{"цепочка родителей в любом порядке", bundleOf(nil, category(3, model.LanguageGo, ptr(2)), category(1, model.LanguageGo, nil), category(2, model.LanguageGo, ptr(1))), false},
+		{"неизвестный родитель", bundleOf(nil, category(1, model.LanguageGo, ptr(9))), true},
+		{"родитель с другим языком", bundleOf(nil, category(1, model.LanguageGo, nil), category(2, model.LanguagePython, ptr(1))), true},
+		{"категория - свой родитель", bundleOf(nil, category(1, model.LanguageGo, ptr(1))), true},
+		{"цикл", bundleOf(nil, category(1, model.LanguageGo, ptr(3)), category(2, model.LanguageGo, ptr(1)), category(3, model.LanguageGo, ptr(2))), true},
+		{"метки", bundleOf([]*model.BundleExercise{exercise("go", " two   words ")}, category(1, model.LanguageGo, nil)), false},
+		{"пустая метка", bundleOf([]*model.BundleExercise{exercise("  ")}, category(1, model.LanguageGo, nil)), true},
+		{"длинная метка", bundleOf([]*model.BundleExercise{exercise(strings.Repeat("я", model.MaxTagNameLength+1))}, category(1, model.LanguageGo, nil)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBundle(tt.data, model.ConflictStrategySkip)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateBundle error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, model.ErrInvalidParameter) {
+				t.Errorf("validateBundle error = %v, want ErrInvalidParameter", err)
+			}
+		})
+	}
+
+	data := bundleOf([]*model.BundleExercise{exercise(" two   words ")}, category(1, model.LanguageGo, nil))
+	if err := validateBundle(data, model.ConflictStrategySkip); err != nil {
+		t.Fatal(err)
+	}
+	if got := data.Exercises[0].Tags[0]; got != "two words" {
+		t.Errorf("normalized tag = %q, want %q", got, "two words")
+	}
+}
+
+func TestClampBundleStat(t *testing.T) {
+	tests := []struct {
+		name string
+		stat *model.BundleExerciseStat
+		want *model.BundleExerciseStat
+	}{
+		{"нет статистики", nil, nil},
+		{"нет попыток", &model.BundleExerciseStat{TotalAttempts: 0, BestScore: 100}, nil},
+		{"отрицательные попытки", &model.BundleExerciseStat{TotalAttempts: -1}, nil},
+		{
+			"допустимые значения не меняются",
+			&model.BundleExerciseStat{TotalAttempts: 2, SuccessfulAttempts: 1, TotalTypingTime: 60, TotalTypedChars: 40, TotalScore: 150, BestScore: 100, LastScore: 50},
+			&model.BundleExerciseStat{TotalAttempts: 2, SuccessfulAttempts: 1, TotalTypingTime: 60, TotalTypedChars: 40, TotalScore: 150, BestScore: 100, LastScore: 50},
+		},
+		{
+			"отрицательные значения обнуляются",
+			&model.BundleExerciseStat{TotalAttempts: 1, SuccessfulAttempts: -1, TotalTypingTime: -5, TotalTypedChars: -5, TotalScore: -5, BestScore: -5, LastScore: -5},
+			&model.BundleExerciseStat{TotalAttempts: 1},
+		},
+		{
+			"значения больше достижимых обрезаются",
+			&model.BundleExerciseStat{TotalAttempts: 2, SuccessfulAttempts: 5, TotalTypingTime: 1 << 40, TotalTypedChars: 1 << 30, TotalScore: 1000, BestScore: 500, LastScore: 101},
+			&model.BundleExerciseStat{TotalAttempts: 2, SuccessfulAttempts: 2, TotalTypingTime: 2 * model.MaxAttemptTypingTime, TotalTypedChars: 2 * model.MaxAttemptTypedChars,
+				TotalScore: 2 * grading.MaxScore, BestScore: grading.MaxScore, LastScore: grading.MaxScore},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampBundleStat(tt.stat); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clampBundleStat(%+v) = %+v, want %+v", tt.stat, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+)
+
+// GetCategoryTree возвращает видимые пользователю категории деревом с числом упражнений.
+// Категория, родитель которой удалён или не виден пользователю, попадает в корень.
+func (s *PokerService) GetCategoryTree(ctx context.Context, userID model.UserID) ([]*model.CategoryTreeNode, error) {
+	categories, _, err := s.repository.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repository.CountExercisesByCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int64]*model.CategoryTreeNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &model.CategoryTreeNode{
+			Category:      *category,
+			ExerciseCount: counts[category.ID],
+			Children:      []*model.CategoryTreeNode{},
+		}
+	}
+
+	roots := []*model.CategoryTreeNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	for _, root := range roots {
+		sumExerciseCounts(root)
+	}
+	return roots, nil
+}
+
+func sumExerciseCounts(node *model.CategoryTreeNode) int64 {
+	node.TotalExerciseCount = node.ExerciseCount
+	for _, child := range node.Children {
+		node.TotalExerciseCount += sumExerciseCounts(child)
+	}
+	return node.TotalExerciseCount
+}
+
+// checkCategoryParent проверяет, что родитель виден пользователю и совпадает с категорией
+// по владельцу и языку. Циклы отсекает SetCategoryParent.
+func (s *PokerService) checkCategoryParent(ctx context.Context, ownerID model.UserID, category *model.Category) error {
+	parent, err := s.repository.GetCategory(ctx, ownerID, *category.ParentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: parent category %d", model.ErrorNotFound, *category.ParentID)
+		}
+		return err
+	}
+	if parent.UserID != ownerID {
+		return fmt.Errorf("%w: parent category belongs to another owner", model.ErrInvalidParameter)
+	}
+	if parent.ProgrammingLanguage != category.ProgrammingLanguage {
+		return fmt.Errorf("%w: parent category has another programming language", model.ErrInvalidParameter)
+	}
+	return nil
+}
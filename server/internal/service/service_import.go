@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/importer"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+)
+
+// ImportExercises создаёт упражнения из файлов архива в одной транзакции.
+// Файлы с ошибками попадают в отчёт и не мешают импорту остальных; при dry run в базу ничего не пишется.
+func (s *PokerService) ImportExercises(ctx context.Context, userID model.UserID, roles model.Roles, request *model.ExerciseImportRequest) (*model.ImportResult, error) {
+	if request.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
+		}
+	}
+	if request.ComparisonMode == "" {
+		request.ComparisonMode = model.DefaultComparisonMode
+	}
+
+	if _, err := s.repository.GetCategory(ctx, userID, request.CategoryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: category %d", model.ErrorNotFound, request.CategoryID)
+		}
+		return nil, err
+	}
+
+	files, err := importer.ReadArchive(request.ArchiveName, request.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidParameter, err)
+	}
+
+	result := &model.ImportResult{
+		DryRun:     request.DryRun,
+		CategoryID: request.CategoryID,
+		Files:      []*model.ImportFileResult{},
+	}
+	for _, file := range files {
+		fileResult := importFile(file, userID, request)
+		if fileResult.Status == model.ImportFileFailed {
+			result.Failed++
+		}
+		result.Imported += len(fileResult.Exercises)
+		result.Files = append(result.Files, fileResult)
+	}
+
+	if request.DryRun || result.Imported == 0 {
+		return result, nil
+	}
+
+	err = s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		for _, fileResult := range result.Files {
+			for i, exercise := range fileResult.Exercises {
+				created, err := adapters.Repository.CreateExercise(ctx, userID, roles.Can(model.PermissionManageCommonContent), exercise)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fileResult.Path, err)
+				}
+				fileResult.Exercises[i] = created
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importFile превращает файл архива в упражнения, не обращаясь к базе
+func importFile(file importer.File, userID model.UserID, request *model.ExerciseImportRequest) *model.ImportFileResult {
+	fileResult := &model.ImportFileResult{Path: file.Path}
+	if file.Err != nil {
+		fileResult.Status = model.ImportFileFailed
+		fileResult.Error = file.Err.Error()
+		return fileResult
+	}
+
+	language, ok := importer.LanguageByPath(file.Path)
+	if !ok {
+		fileResult.Status = model.ImportFileSkipped
+		fileResult.Error = "unsupported file extension"
+		return fileResult
+	}
+
+	snippets, err := importer.Extract(file.Path, file.Content, language)
+	if err != nil {
+		fileResult.Status = model.ImportFileFailed
+		fileResult.Error = err.Error()
+		return fileResult
+	}
+
+	for _, snippet := range snippets {
+		if err := validateExerciseCode(snippet.Code); err != nil {
+			fileResult.Status = model.ImportFileFailed
+			fileResult.Error = fmt.Sprintf("%s: %v", snippet.Title, err)
+			return fileResult
+		}
+	}
+
+	fileResult.Status = model.ImportFileImported
+	for _, snippet := range snippets {
+		fileResult.Exercises = append(fileResult.Exercises, &model.Exercise{
+			UserID:              userID,
+			Title:               snippet.Title,
+			CategoryID:          request.CategoryID,
+			ProgrammingLanguage: snippet.Language,
+			CodeToRemember:      snippet.Code,
+			IsActive:            true,
+			IsCommon:            request.IsCommon,
+			ComparisonMode:      request.ComparisonMode,
+		})
+	}
+	return fileResult
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidCredentials = fmt.Errorf("%w: invalid email or password", model.ErrorUnauthorized)
+
+// dummyPasswordHash сравнивается с паролем, когда email не найден: иначе по времени ответа
+// было бы видно, какие адреса зарегистрированы
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("memcode-dummy-password"), bcrypt.DefaultCost)
+
+// Register создаёт пользователя с входом по email и паролю и отправляет письмо для подтверждения email.
+// Если email уже зарегистрирован, ответ тот же, а владельцу адреса уходит уведомление:
+// так по регистрации нельзя проверить, есть ли у адреса аккаунт.
+func (s *PokerService) Register(ctx context.Context, request *model.RegisterRequest) error {
+	if err := s.requireMailer(); err != nil {
+		return err
+	}
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		return err
+	}
+	if err := validatePassword(request.Password); err != nil {
+		return err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	profile := &model.UserProfileFromProvider{
+		ProviderID:   email,
+		Email:        email,
+		Name:         name,
+		ProviderName: model.LocalProvider,
+	}
+
+	var user *model.User
+	err = s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		var err error
+		user, err = adapters.Repository.CreateUser(ctx, profile)
+		if err != nil {
+			return err
+		}
+		err = adapters.Repository.CreateLocalCredentials(ctx, &model.LocalCredentials{
+			UserID:       user.ID,
+			Email:        email,
+			PasswordHash: string(passwordHash),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = adapters.Repository.AddUserAuthProviders(ctx, profile, user.ID)
+		return err
+	})
+	if errors.Is(err, model.ErrorAlreadyExists) {
+		return s.sendAlreadyRegisteredEmail(ctx, email)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.sendVerificationEmail(ctx, user.ID, email)
+}
+
+// LoginWithPassword выполняет вход по email и паролю; войти можно только с подтверждённым email
+func (s *PokerService) LoginWithPassword(ctx context.Context, request *model.PasswordLoginRequest) (*model.AuthData, error) {
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		return nil, errInvalidCredentials
+	}
+
+	credentials, err := s.repository.GetLocalCredentialsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, model.ErrorNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(request.Password))
+			return nil, errInvalidCredentials
+		}
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(request.Password)) != nil {
+		return nil, errInvalidCredentials
+	}
+	if credentials.EmailVerifiedAt == nil {
+		return nil, fmt.Errorf("%w: email is not verified", model.ErrorForbidden)
+	}
+
+	user, err := s.repository.GetUser(ctx, credentials.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user)
+}
+
+func (s *PokerService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.consumeAuthToken(ctx, token, model.AuthTokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	return s.repository.MarkLocalEmailVerified(ctx, userID)
+}
+
+// ResendVerificationEmail повторно отправляет письмо подтверждения.
+// Ответ не зависит от того, зарегистрирован ли email, чтобы по нему нельзя было перебирать адреса.
+func (s *PokerService) ResendVerificationEmail(ctx context.Context, email string) error {
+	if err := s.requireMailer(); err != nil {
+		return err
+	}
+	credentials, err := s.findLocalCredentials(ctx, email)
+	if err != nil || credentials == nil || credentials.EmailVerifiedAt != nil {
+		return err
+	}
+	return s.sendVerificationEmail(ctx, credentials.UserID, credentials.Email)
+}
+
+// RequestPasswordReset отправляет письмо со ссылкой для сброса пароля, если такой email зарегистрирован
+func (s *PokerService) RequestPasswordReset(ctx context.Context, email string) error {
+	if err := s.requireMailer(); err != nil {
+		return err
+	}
+	credentials, err := s.findLocalCredentials(ctx, email)
+	if err != nil || credentials == nil {
+		return err
+	}
+
+	token, err := s.createAuthToken(ctx, credentials.UserID, model.AuthTokenPurposeResetPassword, model.ResetPasswordTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, &model.MailMessage{
+		To:      credentials.Email,
+		Subject: "MemCode: сброс пароля",
+		Body: "Чтобы задать новый пароль, перейдите по ссылке:\n" + s.publicLink("/reset-password", token) +
+			"\n\nСсылка действует один час. Если вы не запрашивали сброс, просто проигнорируйте это письмо.\n",
+	})
+}
+
+// ResetPassword задаёт новый пароль по токену из письма и завершает все сессии пользователя
+func (s *PokerService) ResetPassword(ctx context.Context, token string, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	userID, err := s.consumeAuthToken(ctx, token, model.AuthTokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.SetLocalPasswordHash(ctx, userID, string(passwordHash)); err != nil {
+		return err
+	}
+	// Письмо пришло на этот адрес, значит email подтверждён
+	if err := s.repository.MarkLocalEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.repository.DeleteUserAuthTokens(ctx, userID, model.AuthTokenPurposeResetPassword); err != nil {
+		return err
+	}
+	return s.LogoutEverywhere(ctx, userID)
+}
+
+// requireMailer отклоняет операции, которым нужно письмо, если MAILER не задан
+func (s *PokerService) requireMailer() error {
+	if s.mailer == nil {
+		return fmt.Errorf("%w: email sending is not configured", model.ErrorUnavailable)
+	}
+	return nil
+}
+
+func (s *PokerService) sendVerificationEmail(ctx context.Context, userID model.UserID, email string) error {
+	token, err := s.createAuthToken(ctx, userID, model.AuthTokenPurposeVerifyEmail, model.VerifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, &model.MailMessage{
+		To:      email,
+		Subject: "MemCode: подтверждение email",
+		Body:    "Чтобы подтвердить email, перейдите по ссылке:\n" + s.publicLink("/verify-email", token) + "\n",
+	})
+}
+
+func (s *PokerService) sendAlreadyRegisteredEmail(ctx context.Context, email string) error {
+	return s.mailer.Send(ctx, &model.MailMessage{
+		To:      email,
+		Subject: "MemCode: попытка регистрации",
+		Body: "Кто-то пытался зарегистрироваться в MemCode с этим адресом, но аккаунт с ним уже есть.\n" +
+			"Если это были вы, войдите или восстановите пароль на странице входа:\n" + strings.TrimRight(s.publicURL, "/") + "/login" +
+			"\n\nЕсли нет, просто проигнорируйте это письмо.\n",
+	})
+}
+
+// findLocalCredentials ищет учётные данные по email; незарегистрированный email не считается ошибкой
+func (s *PokerService) findLocalCredentials(ctx context.Context, email string) (*model.LocalCredentials, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := s.repository.GetLocalCredentialsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, model.ErrorNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// createAuthToken создаёт одноразовый токен; в базе хранится только его хеш
+func (s *PokerService) createAuthToken(ctx context.Context, userID model.UserID, purpose string, ttl time.Duration) (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(randomBytes)
+
+	err := s.repository.CreateAuthToken(ctx, &model.AuthToken{
+		TokenHash: hashCode(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *PokerService) consumeAuthToken(ctx context.Context, token string, purpose string) (model.UserID, error) {
+	userID, err := s.repository.ConsumeAuthToken(ctx, hashCode(strings.TrimSpace(token)), purpose)
+	if err != nil {
+		if errors.Is(err, model.ErrorNotFound) {
+			return 0, fmt.Errorf("%w: invalid or expired token", model.ErrInvalidParameter)
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (s *PokerService) publicLink(path string, token string) string {
+	return strings.TrimRight(s.publicURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func normalizeEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Name != "" {
+		return "", fmt.Errorf("%w: invalid email", model.ErrInvalidParameter)
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+func validatePassword(password string) error {
+	if len([]rune(password)) < model.MinPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", model.ErrInvalidParameter, model.MinPasswordLength)
+	}
+	if len(password) > model.MaxPasswordBytes {
+		return fmt.Errorf("%w: password must be at most %d bytes", model.ErrInvalidParameter, model.MaxPasswordBytes)
+	}
+	return nil
+}
@@ -2,21 +2,50 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"inzarubin80/MemCode/internal/model"
 	"time"
-)
 
-func (s *PokerService) Login(ctx context.Context, providerKey string, authorizationCode string) (*model.AuthData, error) {
+	"golang.org/x/oauth2"
+)
 
+// StartLogin начинает вход через провайдера: создаёт state, nonce и PKCE verifier
+// и возвращает адрес страницы входа. flow нужно сохранить у клиента до обмена кода.
+func (s *PokerService) StartLogin(ctx context.Context, providerKey string) (*model.AuthFlow, string, error) {
 	provider, ok := s.providersUserData[providerKey]
-
 	if !ok {
-		return nil, fmt.Errorf("provider not found")
+		return nil, "", fmt.Errorf("%w: provider not found", model.ErrInvalidParameter)
+	}
+
+	state, err := randomFlowValue()
+	if err != nil {
+		return nil, "", err
+	}
+	nonce, err := randomFlowValue()
+	if err != nil {
+		return nil, "", err
+	}
+	flow := &model.AuthFlow{
+		Provider:     providerKey,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
 	}
 
-	userProfileFromProvider, err := provider.GetUserData(ctx, authorizationCode)
+	authURL, err := provider.AuthCodeURL(ctx, flow)
+	if err != nil {
+		return nil, "", err
+	}
+	return flow, authURL, nil
+}
+
+// Login выполняет вход по коду авторизации. flow - параметры из StartLogin, без них вход не выполняется.
+func (s *PokerService) Login(ctx context.Context, providerKey string, authorizationCode string, flow *model.AuthFlow) (*model.AuthData, error) {
+
+	userProfileFromProvider, err := s.providerUserData(ctx, providerKey, authorizationCode, flow)
 	if err != nil {
 		return nil, err
 	}
@@ -48,34 +77,69 @@ func (s *PokerService) Login(ctx context.Context, providerKey string, authorizat
 		return nil, err
 	}
 
-	refreshToken, err := s.refreshTokenService.GenerateToken(userID, user.IsAdmin)
+	return s.startSession(ctx, user)
+}
+
+func (s *PokerService) providerUserData(ctx context.Context, providerKey string, authorizationCode string, flow *model.AuthFlow) (*model.UserProfileFromProvider, error) {
+	provider, ok := s.providersUserData[providerKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: provider not found", model.ErrInvalidParameter)
+	}
+	if flow == nil {
+		return nil, fmt.Errorf("%w: login must be started by the server", model.ErrInvalidParameter)
+	}
+	if flow.Provider != providerKey {
+		return nil, fmt.Errorf("%w: login was started with another provider", model.ErrInvalidParameter)
+	}
+	return provider.GetUserData(ctx, authorizationCode, flow)
+}
+
+func randomFlowValue() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate login state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// startSession выпускает пару токенов для нового входа, refresh-токен открывает новое семейство
+func (s *PokerService) startSession(ctx context.Context, user *model.User) (*model.AuthData, error) {
+	userID := user.ID
+
+	refreshToken, err := s.refreshTokenService.GenerateToken(userID, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	familyID, err := newTokenFamilyID()
 	if err != nil {
 		return nil, err
 	}
 
+	userAgent, ipAddress := clientInfo(ctx)
 	refreshTokenModel := &model.RefreshToken{
 		UserID:    userID,
 		Token:     refreshToken,
 		IssuedAt:  time.Now().UTC(),
 		ExpiresAt: time.Now().UTC().Add(30 * 24 * time.Hour), // например, 30 дней
 		Revoked:   false,
-		UserAgent: "", // можно получить из ctx или http.Request
-		IPAddress: "", // можно получить из ctx или http.Request
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		FamilyID:  familyID,
 	}
 	err = s.CreateRefreshToken(ctx, refreshTokenModel)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, err := s.accessTokenService.GenerateToken(userID, user.IsAdmin)
+	accessToken, err := s.accessTokenService.GenerateToken(userID, user.Roles)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return &model.AuthData{
-		User:       *user,
+		User:         *user,
 		RefreshToken: refreshToken,
 		AccessToken:  accessToken,
 	}, nil
-
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+)
+
+// GetUserLinkedProviders возвращает способы входа, привязанные к аккаунту
+func (s *PokerService) GetUserLinkedProviders(ctx context.Context, userID model.UserID) ([]*model.LinkedProvider, error) {
+	return s.repository.GetUserLinkedProviders(ctx, userID)
+}
+
+// LinkProvider привязывает к текущему пользователю ещё один OAuth-провайдер,
+// чтобы вход через него открывал тот же аккаунт, а не создавал новый
+func (s *PokerService) LinkProvider(ctx context.Context, userID model.UserID, providerKey string, authorizationCode string, flow *model.AuthFlow) ([]*model.LinkedProvider, error) {
+	userProfileFromProvider, err := s.providerUserData(ctx, providerKey, authorizationCode, flow)
+	if err != nil {
+		return nil, err
+	}
+
+	userAuthProviders, err := s.repository.GetUserAuthProvidersByProviderUid(ctx, userProfileFromProvider.ProviderID, userProfileFromProvider.ProviderName)
+	switch {
+	case err == nil && userAuthProviders.UserID != userID:
+		return nil, fmt.Errorf("%w: this %s account is already linked to another user", model.ErrInvalidParameter, userProfileFromProvider.ProviderName)
+	case err == nil:
+		// Уже привязан к этому пользователю
+		return s.repository.GetUserLinkedProviders(ctx, userID)
+	case !errors.Is(err, model.ErrorNotFound):
+		return nil, err
+	}
+
+	linked, err := s.repository.GetUserLinkedProviders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range linked {
+		if p.Provider == userProfileFromProvider.ProviderName {
+			return nil, fmt.Errorf("%w: another %s account is already linked", model.ErrInvalidParameter, p.Provider)
+		}
+	}
+
+	_, err = s.repository.AddUserAuthProviders(ctx, userProfileFromProvider, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.GetUserLinkedProviders(ctx, userID)
+}
+
+// UnlinkProvider отвязывает провайдера от аккаунта. Последний способ входа отвязать нельзя.
+// Вместе с провайдером local удаляются и email с паролем.
+func (s *PokerService) UnlinkProvider(ctx context.Context, userID model.UserID, provider string) ([]*model.LinkedProvider, error) {
+	err := s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		err := adapters.Repository.DeleteUserAuthProvider(ctx, userID, provider)
+		if err != nil {
+			return err
+		}
+		if provider == model.LocalProvider {
+			return adapters.Repository.DeleteLocalCredentials(ctx, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.GetUserLinkedProviders(ctx, userID)
+}
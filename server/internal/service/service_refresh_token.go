@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"inzarubin80/MemCode/internal/model"
 	"time"
 )
@@ -10,7 +13,14 @@ import (
 func (s *PokerService) RefreshToken(ctx context.Context, refreshToken string) (*model.AuthData, error) {
 	// 1. Проверяем refresh-токен в базе
 	dbToken, err := s.GetRefreshTokenByToken(ctx, refreshToken)
-	if err != nil || dbToken == nil || dbToken.Revoked || dbToken.ExpiresAt.Before(time.Now().UTC()) {
+	if err != nil || dbToken == nil {
+		return nil, errors.New("unauthorized")
+	}
+	// Отозванный токен предъявлен повторно: либо он украден, либо украден его преемник
+	if dbToken.Revoked {
+		return nil, s.handleRefreshTokenReuse(ctx, dbToken)
+	}
+	if dbToken.ExpiresAt.Before(time.Now().UTC()) {
 		return nil, errors.New("unauthorized")
 	}
 
@@ -19,11 +29,14 @@ func (s *PokerService) RefreshToken(ctx context.Context, refreshToken string) (*
 		return nil, err
 	}
 
-	// 2. Отзываем старый refresh-токен
-	err = s.RevokeRefreshToken(ctx, refreshToken)
+	// 2. Отзываем старый refresh-токен; если его успел отозвать параллельный запрос, это тоже повторное использование
+	revoked, err := s.RevokeRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, err
 	}
+	if !revoked {
+		return nil, s.handleRefreshTokenReuse(ctx, dbToken)
+	}
 
 	user, err := s.repository.GetUser(ctx, claims.UserID)
 	if err != nil {
@@ -31,9 +44,10 @@ func (s *PokerService) RefreshToken(ctx context.Context, refreshToken string) (*
 	}
 
 	// 3. Генерируем и сохраняем новый refresh-токен с повторными попытками
+	userAgent, ipAddress := clientInfo(ctx)
 	var newRefreshToken string
 	for attempts := 0; attempts < 3; attempts++ {
-		newRefreshToken, err = s.refreshTokenService.GenerateToken(claims.UserID, user.IsAdmin)
+		newRefreshToken, err = s.refreshTokenService.GenerateToken(claims.UserID, user.Roles)
 		if err != nil {
 			return nil, err
 		}
@@ -44,8 +58,10 @@ func (s *PokerService) RefreshToken(ctx context.Context, refreshToken string) (*
 			IssuedAt:  time.Now().UTC(),
 			ExpiresAt: time.Now().UTC().Add(30 * 24 * time.Hour),
 			Revoked:   false,
-			UserAgent: "",
-			IPAddress: "",
+			UserAgent: userAgent,
+			IPAddress: ipAddress,
+			FamilyID:  dbToken.FamilyID,
+			ParentID:  &dbToken.ID,
 		}
 		err = s.CreateRefreshToken(ctx, refreshTokenModel)
 		if err == nil {
@@ -65,16 +81,45 @@ func (s *PokerService) RefreshToken(ctx context.Context, refreshToken string) (*
 		return nil, err
 	}
 
-	newAccessToken, err := s.accessTokenService.GenerateToken(claims.UserID, user.IsAdmin)
+	newAccessToken, err := s.accessTokenService.GenerateToken(claims.UserID, user.Roles)
 	if err != nil {
 		return nil, err
 	}
 
-
-
 	return &model.AuthData{
 		User:         *user,
 		RefreshToken: newRefreshToken,
 		AccessToken:  newAccessToken,
 	}, nil
 }
+
+// handleRefreshTokenReuse отзывает всё семейство повторно предъявленного токена и пишет событие в журнал безопасности
+func (s *PokerService) handleRefreshTokenReuse(ctx context.Context, token *model.RefreshToken) error {
+	revokedCount, err := s.repository.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	userAgent, ipAddress := clientInfo(ctx)
+	event := &model.SecurityEvent{
+		UserID:    token.UserID,
+		EventType: model.SecurityEventRefreshTokenReuse,
+		Details:   fmt.Sprintf("token_id=%d family_id=%s revoked=%d", token.ID, token.FamilyID, revokedCount),
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+	}
+	fmt.Println("security event:", event.EventType, "user_id:", event.UserID, event.Details, "ip:", event.IPAddress)
+	if err := s.repository.CreateSecurityEvent(ctx, event); err != nil {
+		return err
+	}
+	return errors.New("unauthorized")
+}
+
+// newTokenFamilyID создаёт идентификатор семейства для refresh-токена, выданного при входе
+func newTokenFamilyID() (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate token family id: %w", err)
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
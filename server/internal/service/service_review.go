@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"math"
+	"time"
+)
+
+const (
+	// Параметры алгоритма SM-2
+	defaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+	minPassQuality    = 3
+)
+
+// GetDueExercises возвращает задачи пользователя, которые нужно повторить сегодня.
+// Сегодняшний день заканчивается в полночь по часовому поясу пользователя.
+func (s *PokerService) GetDueExercises(ctx context.Context, userID model.UserID, page, pageSize int) (*model.ExerciseListWithUserResponse, error) {
+	location, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	detailseList, total, err := s.repository.GetDueExercises(ctx, userID, endOfDay(time.Now().In(location)), page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(ctx, userID, detailseList); err != nil {
+		return nil, err
+	}
+	hasNext := (page * pageSize) < total
+	hasPrev := page > 1
+
+	return &model.ExerciseListWithUserResponse{
+		ExerciseDetailse: detailseList,
+		Total:            total,
+		Page:             page,
+		PageSize:         pageSize,
+		HasNext:          hasNext,
+		HasPrev:          hasPrev,
+	}, nil
+}
+
+// endOfDay возвращает ближайшую полночь после now в поясе now.
+// time.Date нормализует переполнение дня и учитывает переход на летнее время.
+func endOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+}
+
+// scheduleReview пересчитывает расписание повторения после проверенной попытки
+func (s *PokerService) scheduleReview(ctx context.Context, repository stor.Repository, userID model.UserID, exerciseID int64, quality int, now time.Time) (*model.ExerciseReview, error) {
+	review, err := repository.GetExerciseReview(ctx, userID, exerciseID)
+	if err != nil && !errors.Is(err, model.ErrorNotFound) {
+		return nil, err
+	}
+	if review == nil {
+		review = &model.ExerciseReview{
+			UserID:     userID,
+			ExerciseID: exerciseID,
+			EaseFactor: defaultEaseFactor,
+		}
+	}
+
+	next := nextReview(review, quality, now)
+	if err := repository.UpsertExerciseReview(ctx, next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// nextReview вычисляет следующее состояние по алгоритму SM-2.
+// quality - оценка ответа от 0 до 5, ответ засчитывается при quality >= 3.
+func nextReview(prev *model.ExerciseReview, quality int, now time.Time) *model.ExerciseReview {
+	if quality < 0 {
+		quality = 0
+	}
+	if quality > 5 {
+		quality = 5
+	}
+
+	next := *prev
+	if quality >= minPassQuality {
+		switch next.Repetitions {
+		case 0:
+			next.IntervalDays = 1
+		case 1:
+			next.IntervalDays = 6
+		default:
+			next.IntervalDays = int(math.Round(float64(prev.IntervalDays) * prev.EaseFactor))
+		}
+		next.Repetitions++
+	} else {
+		next.Repetitions = 0
+		next.IntervalDays = 1
+	}
+
+	q := float64(5 - quality)
+	next.EaseFactor = prev.EaseFactor + (0.1 - q*(0.08+q*0.02))
+	if next.EaseFactor < minEaseFactor {
+		next.EaseFactor = minEaseFactor
+	}
+
+	reviewedAt := now
+	next.LastReviewedAt = &reviewedAt
+	next.DueAt = now.AddDate(0, 0, next.IntervalDays)
+	return &next
+}
+
+// reviewQuality переводит результат проверки в оценку SM-2.
+// Неверный ответ получает от 0 до 2 в зависимости от близости к эталону.
+func reviewQuality(isCorrect bool, score int) int {
+	if isCorrect {
+		return 5
+	}
+	quality := score * 3 / 100
+	if quality > 2 {
+		quality = 2
+	}
+	return quality
+}
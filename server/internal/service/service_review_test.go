@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"inzarubin80/MemCode/internal/model"
+	"testing"
+	"time"
+)
+
+// dueRepository запоминает границу, до которой запрошены задачи на повторение
+type dueRepository struct {
+	Repository
+	timezone string
+	until    time.Time
+}
+
+func (r *dueRepository) GetUserTimezone(ctx context.Context, userID model.UserID) (string, error) {
+	return r.timezone, nil
+}
+
+func (r *dueRepository) GetDueExercises(ctx context.Context, userID model.UserID, until time.Time, page, pageSize int) ([]*model.ExerciseDetailse, int, error) {
+	r.until = until
+	return []*model.ExerciseDetailse{}, 0, nil
+}
+
+func (r *dueRepository) GetExercisesTags(ctx context.Context, userID model.UserID, exerciseIDs []int64) (map[int64][]*model.Tag, error) {
+	return map[int64][]*model.Tag{}, nil
+}
+
+func TestEndOfDay(t *testing.T) {
+	mustLoad := func(name string) *time.Location {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("timezone %s is not available: %v", name, err)
+		}
+		return location
+	}
+	moscow := mustLoad("Europe/Moscow")
+	newYork := mustLoad("America/New_York")
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"UTC", time.Date(2025, 8, 10, 15, 0, 0, 0, time.UTC), time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)},
+		{"конец месяца", time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// 23:30 UTC в Москве уже следующий день
+		{"пояс впереди UTC", time.Date(2025, 8, 10, 23, 30, 0, 0, time.UTC).In(moscow), time.Date(2025, 8, 11, 21, 0, 0, 0, time.UTC)},
+		// 02:00 UTC в Нью-Йорке ещё предыдущий день
+		{"пояс позади UTC", time.Date(2025, 8, 11, 2, 0, 0, 0, time.UTC).In(newYork), time.Date(2025, 8, 11, 4, 0, 0, 0, time.UTC)},
+		// В день перехода на летнее время в сутках 23 часа
+		{"переход на летнее время", time.Date(2025, 3, 9, 0, 30, 0, 0, newYork), time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := endOfDay(tt.now); !got.Equal(tt.want) {
+				t.Errorf("endOfDay(%v) = %v, want %v", tt.now, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDueExercisesUsesUserTimezone(t *testing.T) {
+	for _, timezone := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago", "UTC", "Unknown/Zone"} {
+		t.Run(timezone, func(t *testing.T) {
+			repo := &dueRepository{timezone: timezone}
+			service := NewPokerService(repo, nil, nil, nil, nil, nil, "")
+			if _, err := service.GetDueExercises(context.Background(), 1, 1, 20); err != nil {
+				t.Fatalf("GetDueExercises: %v", err)
+			}
+
+			location, err := time.LoadLocation(timezone)
+			if err != nil {
+				location = time.UTC
+			}
+			local := repo.until.In(location)
+			if local.Hour() != 0 || local.Minute() != 0 {
+				t.Errorf("until = %v, want midnight in %s", local, location)
+			}
+			if wait := time.Until(repo.until); wait <= 0 || wait > 25*time.Hour {
+				t.Errorf("until = %v is not the next midnight", repo.until)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+)
+
+func (s *PokerService) GetExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, page, pageSize int) (*model.ExerciseRevisionListResponse, error) {
+	revisions, total, err := s.repository.GetExerciseRevisions(ctx, userID, exerciseID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ExerciseRevisionListResponse{
+		Revisions: revisions,
+		Total:     total,
+		Page:      page,
+		PageSize:  pageSize,
+		HasNext:   (page * pageSize) < total,
+		HasPrev:   page > 1,
+	}, nil
+}
+
+// DiffExerciseRevisions сравнивает две ревизии упражнения.
+// to = 0 означает текущую ревизию, from = 0 - предыдущую перед to.
+func (s *PokerService) DiffExerciseRevisions(ctx context.Context, userID model.UserID, exerciseID int64, from, to int) (*model.ExerciseRevisionDiff, error) {
+	toRevision, err := s.repository.GetExerciseRevision(ctx, userID, exerciseID, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = toRevision.Revision - 1
+		if from < 1 {
+			return nil, fmt.Errorf("%w: revision %d has no previous revision", model.ErrInvalidParameter, toRevision.Revision)
+		}
+	}
+	fromRevision, err := s.repository.GetExerciseRevision(ctx, userID, exerciseID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	oldLines := splitCodeLines(fromRevision.CodeToRemember)
+	newLines := splitCodeLines(toRevision.CodeToRemember)
+	if len(oldLines) > model.MaxRevisionDiffLines || len(newLines) > model.MaxRevisionDiffLines {
+		return nil, fmt.Errorf("%w: code longer than %d lines cannot be compared", model.ErrInvalidParameter, model.MaxRevisionDiffLines)
+	}
+
+	return &model.ExerciseRevisionDiff{
+		ExerciseID:    exerciseID,
+		From:          fromRevision,
+		To:            toRevision,
+		ChangedFields: changedRevisionFields(fromRevision, toRevision),
+		Code:          diffCodeLines(oldLines, newLines),
+	}, nil
+}
+
+// RestoreExerciseRevision возвращает упражнению содержимое старой ревизии.
+// История не переписывается: восстановленное содержимое становится новой ревизией.
+func (s *PokerService) RestoreExerciseRevision(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, revisionNumber int) (*model.Exercise, error) {
+	existing, err := s.repository.GetExercise(ctx, userID, exerciseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: exercise %d", model.ErrorNotFound, exerciseID)
+		}
+		return nil, err
+	}
+	if revisionNumber < 1 {
+		return nil, fmt.Errorf("%w: revision must be positive", model.ErrInvalidParameter)
+	}
+	revision, err := s.repository.GetExerciseRevision(ctx, userID, exerciseID, revisionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	exercise := *existing
+	exercise.Title = revision.Title
+	exercise.Description = revision.Description
+	exercise.ProgrammingLanguage = revision.ProgrammingLanguage
+	exercise.CodeToRemember = revision.CodeToRemember
+	exercise.ComparisonMode = revision.ComparisonMode
+
+	// Права те же, что при обычном редактировании: общие упражнения меняют только редакторы контента
+	return s.UpdateExercise(ctx, userID, roles, exerciseID, &exercise)
+}
+
+func changedRevisionFields(from, to *model.ExerciseRevision) []string {
+	fields := []string{}
+	if from.Title != to.Title {
+		fields = append(fields, "title")
+	}
+	if from.Description != to.Description {
+		fields = append(fields, "description")
+	}
+	if from.ProgrammingLanguage != to.ProgrammingLanguage {
+		fields = append(fields, "programming_language")
+	}
+	if from.CodeToRemember != to.CodeToRemember {
+		fields = append(fields, "code_to_remember")
+	}
+	if from.ComparisonMode != to.ComparisonMode {
+		fields = append(fields, "comparison_mode")
+	}
+	return fields
+}
+
+func splitCodeLines(code string) []string {
+	return strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+}
+
+// diffCodeLines строит построчное сравнение по наибольшей общей подпоследовательности.
+// Фрагменты для запоминания короткие, поэтому квадратичной таблицы достаточно;
+// длину сравниваемого кода ограничивает MaxRevisionDiffLines.
+func diffCodeLines(oldLines, newLines []string) []model.RevisionDiffLine {
+
+	// lcs[i][j] - длина общей подпоследовательности хвостов oldLines[i:] и newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]model.RevisionDiffLine, 0, max(len(oldLines), len(newLines)))
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			lines = append(lines, model.RevisionDiffLine{Type: model.RevisionDiffEqual, OldLine: i + 1, NewLine: j + 1, Text: oldLines[i]})
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, model.RevisionDiffLine{Type: model.RevisionDiffRemoved, OldLine: i + 1, Text: oldLines[i]})
+			i++
+		default:
+			lines = append(lines, model.RevisionDiffLine{Type: model.RevisionDiffAdded, NewLine: j + 1, Text: newLines[j]})
+			j++
+		}
+	}
+	return lines
+}
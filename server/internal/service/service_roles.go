@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"slices"
+)
+
+// requirePermission возвращает ErrorForbidden, если ни одна из ролей не даёт права
+func requirePermission(roles model.Roles, permission model.Permission) error {
+	if !roles.Can(permission) {
+		return fmt.Errorf("%w: permission %s required", model.ErrorForbidden, permission)
+	}
+	return nil
+}
+
+func (s *PokerService) GetRoles(ctx context.Context) ([]*model.RoleInfo, error) {
+	return s.repository.GetRoles(ctx)
+}
+
+func (s *PokerService) GrantUserRole(ctx context.Context, grantedBy model.UserID, change *model.UserRoleChange) (*model.User, error) {
+	if err := s.checkRoleChange(ctx, change); err != nil {
+		return nil, err
+	}
+	err := s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		if err := adapters.Repository.GrantUserRole(ctx, change.UserID, change.Role, grantedBy); err != nil {
+			return err
+		}
+		return revokeUserTokens(ctx, adapters.Repository, change.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.GetUser(ctx, change.UserID)
+}
+
+func (s *PokerService) RevokeUserRole(ctx context.Context, change *model.UserRoleChange) (*model.User, error) {
+	if err := s.checkRoleChange(ctx, change); err != nil {
+		return nil, err
+	}
+
+	err := s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		// Нельзя оставить систему без администратора; строки админов заблокированы до конца транзакции
+		if change.Role == model.RoleAdmin {
+			admins, err := adapters.Repository.LockUsersWithRole(ctx, model.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(admins, change.UserID) && len(admins) <= 1 {
+				return fmt.Errorf("%w: cannot revoke role from the last admin", model.ErrInvalidParameter)
+			}
+		}
+
+		if err := adapters.Repository.RevokeUserRole(ctx, change.UserID, change.Role); err != nil {
+			return err
+		}
+		return revokeUserTokens(ctx, adapters.Repository, change.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.GetUser(ctx, change.UserID)
+}
+
+// SetUserAdmin выдаёт или отзывает роль admin
+func (s *PokerService) SetUserAdmin(ctx context.Context, grantedBy model.UserID, userID model.UserID, isAdmin bool) (*model.User, error) {
+	change := &model.UserRoleChange{UserID: userID, Role: model.RoleAdmin}
+	if isAdmin {
+		return s.GrantUserRole(ctx, grantedBy, change)
+	}
+	return s.RevokeUserRole(ctx, change)
+}
+
+func (s *PokerService) checkRoleChange(ctx context.Context, change *model.UserRoleChange) error {
+	if !model.IsKnownRole(change.Role) {
+		return fmt.Errorf("%w: unknown role %q", model.ErrInvalidParameter, change.Role)
+	}
+	_, err := s.repository.GetUser(ctx, change.UserID)
+	return err
+}
+
+// revokeUserTokens отзывает токены пользователя в транзакции смены роли:
+// роли зашиты в токены, поэтому после их смены пользователь должен войти заново
+func revokeUserTokens(ctx context.Context, repository stor.Repository, userID model.UserID) error {
+	if err := repository.InvalidateUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return repository.RevokeAllUserRefreshTokens(ctx, userID)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"html"
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxSearchQueryLength = 200
+	// Строк контекста вокруг найденной строки кода
+	snippetContextLines = 1
+	snippetMaxLines     = 3
+)
+
+// normalizeSearchQuery схлопывает пробелы и ограничивает длину запроса
+func normalizeSearchQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if runes := []rune(query); len(runes) > maxSearchQueryLength {
+		query = strings.TrimSpace(string(runes[:maxSearchQueryLength]))
+	}
+	return query
+}
+
+// highlightSearchMatches заполняет подсветку для найденных упражнений: название целиком
+// и несколько строк кода вокруг первого совпадения (или начало описания, если в коде совпадений нет)
+func highlightSearchMatches(list []*model.ExerciseDetailse, query string) {
+	if query == "" {
+		return
+	}
+	terms := strings.Fields(strings.ToLower(query))
+
+	for _, item := range list {
+		if item.Search == nil {
+			continue
+		}
+		item.Search.Title = highlightTerms(item.Exercise.Title, terms)
+
+		snippet, found := matchingLines(item.Exercise.CodeToRemember, terms)
+		if !found {
+			if description, ok := matchingLines(item.Exercise.Description, terms); ok {
+				snippet = description
+			}
+		}
+		item.Search.Snippet = highlightTerms(snippet, terms)
+	}
+}
+
+// matchingLines возвращает строки вокруг строки, в которой встречается больше всего слов запроса;
+// если совпадений нет - первые строки текста
+func matchingLines(text string, terms []string) (string, bool) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	best, bestCount := -1, 0
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		count := 0
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+
+	if best < 0 {
+		return strings.Join(lines[:min(snippetMaxLines, len(lines))], "\n"), false
+	}
+	from := max(best-snippetContextLines, 0)
+	to := min(best+snippetContextLines+1, len(lines))
+	return strings.Join(lines[from:to], "\n"), true
+}
+
+// highlightTerms экранирует текст как HTML и оборачивает вхождения слов запроса в <mark> без учёта регистра
+func highlightTerms(text string, terms []string) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		termRunes := []rune(term)
+		if len(termRunes) == 0 {
+			continue
+		}
+		for i := 0; i+len(termRunes) <= len(lower); i++ {
+			if string(lower[i:i+len(termRunes)]) == term {
+				for j := i; j < i+len(termRunes); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		chunk := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + chunk + "</mark>")
+		} else {
+			b.WriteString(chunk)
+		}
+		i = j
+	}
+	return b.String()
+}
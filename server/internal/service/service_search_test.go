@@ -0,0 +1,111 @@
+package service
+
+import (
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"  sync \t Mutex\n", "sync Mutex"},
+		{strings.Repeat("я", maxSearchQueryLength+10), strings.Repeat("я", maxSearchQueryLength)},
+		// Обрезка по символам, а не байтам, и без пробела на конце
+		{strings.Repeat("a", maxSearchQueryLength-1) + " bc", strings.Repeat("a", maxSearchQueryLength-1)},
+	}
+	for _, tt := range tests {
+		if got := normalizeSearchQuery(tt.query); got != tt.want {
+			t.Errorf("normalizeSearchQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"без совпадений", "plain text", []string{"mutex"}, "plain text"},
+		{"без учёта регистра", "sync.Mutex", []string{"mutex"}, "sync.<mark>Mutex</mark>"},
+		{"несколько вхождений", "go go", []string{"go"}, "<mark>go</mark> <mark>go</mark>"},
+		{"пересекающиеся слова сливаются", "abcd", []string{"abc", "bcd"}, "<mark>abcd</mark>"},
+		{"соседние слова сливаются", "foobar", []string{"foo", "bar"}, "<mark>foobar</mark>"},
+		{"кириллица", "Привет, мир", []string{"мир"}, "Привет, <mark>мир</mark>"},
+		{"HTML экранируется", "<b>a & b</b>", []string{"a"}, "&lt;b&gt;<mark>a</mark> &amp; b&lt;/b&gt;"},
+		{"HTML внутри совпадения", "x<y", []string{"<"}, "x<mark>&lt;</mark>y"},
+		{"пустое слово игнорируется", "text", []string{""}, "text"},
+		{"пустой текст", "", []string{"a"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightTerms(tt.text, tt.terms); got != tt.want {
+				t.Errorf("highlightTerms(%q, %q) = %q, want %q", tt.text, tt.terms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchingLines(t *testing.T) {
+	code := "package main\r\n\r\nimport \"sync\"\r\n\r\nvar mu sync.Mutex\r\n\r\nfunc main() {}"
+	tests := []struct {
+		name      string
+		text      string
+		terms     []string
+		want      string
+		wantFound bool
+	}{
+		{"строка с контекстом", code, []string{"mutex"}, "\nvar mu sync.Mutex\n", true},
+		{"строка с большим числом слов", code, []string{"sync", "mutex"}, "\nvar mu sync.Mutex\n", true},
+		{"первая из равных строк", code, []string{"sync"}, "\nimport \"sync\"\n", true},
+		{"совпадение в первой строке", code, []string{"package"}, "package main\n", true},
+		{"совпадение в последней строке", code, []string{"func"}, "\nfunc main() {}", true},
+		{"без совпадений - начало текста", code, []string{"chan"}, "package main\n\nimport \"sync\"", false},
+		{"короткий текст без совпадений", "one line", []string{"chan"}, "one line", false},
+		{"пустой текст", "", []string{"chan"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := matchingLines(tt.text, tt.terms)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("matchingLines() = %q, %v; want %q, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestHighlightSearchMatches(t *testing.T) {
+	item := func(title, description, code string) *model.ExerciseDetailse {
+		return &model.ExerciseDetailse{
+			Exercise: model.Exercise{Title: title, Description: description, CodeToRemember: code},
+			Search:   &model.SearchMatch{},
+		}
+	}
+	inCode := item("Mutex", "guards state", "var mu sync.Mutex")
+	inDescription := item("Locks", "Use a mutex\nto guard state", "var mu sync.Locker")
+	nowhere := item("Channels", "", "ch := make(chan int)")
+	notSearched := &model.ExerciseDetailse{Exercise: model.Exercise{Title: "Mutex"}}
+
+	highlightSearchMatches([]*model.ExerciseDetailse{inCode, inDescription, nowhere, notSearched}, "MUTEX guard")
+
+	if got, want := inCode.Search.Title, "<mark>Mutex</mark>"; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+	if got, want := inCode.Search.Snippet, "var mu sync.<mark>Mutex</mark>"; got != want {
+		t.Errorf("code snippet = %q, want %q", got, want)
+	}
+	if got, want := inDescription.Search.Snippet, "Use a <mark>mutex</mark>\nto <mark>guard</mark> state"; got != want {
+		t.Errorf("snippet = %q, want %q", got, want)
+	}
+	if got, want := nowhere.Search.Snippet, "ch := make(chan int)"; got != want {
+		t.Errorf("snippet without matches = %q, want %q", got, want)
+	}
+	if notSearched.Search != nil {
+		t.Error("search match was added to an item without one")
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"inzarubin80/MemCode/internal/model"
+)
+
+// clientInfo возвращает user agent и IP клиента, положенные в контекст HTTP-слоем
+func clientInfo(ctx context.Context) (userAgent string, ipAddress string) {
+	info := model.ClientInfoFromContext(ctx)
+	return info.UserAgent, info.IPAddress
+}
+
+// GetUserSessions возвращает активные сессии пользователя.
+// currentToken - refresh-токен текущей сессии, если он известен, такая сессия помечается как текущая.
+func (s *PokerService) GetUserSessions(ctx context.Context, userID model.UserID, currentToken string) ([]*model.Session, error) {
+	tokens, err := s.repository.GetActiveRefreshTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*model.Session, len(tokens))
+	for i, token := range tokens {
+		sessions[i] = &model.Session{
+			ID:        token.ID,
+			IssuedAt:  token.IssuedAt,
+			ExpiresAt: token.ExpiresAt,
+			UserAgent: token.UserAgent,
+			IPAddress: token.IPAddress,
+			Current:   currentToken != "" && token.Token == currentToken,
+		}
+	}
+	return sessions, nil
+}
+
+// DeleteUserSession завершает сессию: её refresh-токен больше нельзя обменять,
+// выданный ранее access-токен доживает свой короткий срок
+func (s *PokerService) DeleteUserSession(ctx context.Context, userID model.UserID, sessionID int64) error {
+	return s.repository.RevokeUserSession(ctx, userID, sessionID)
+}
+
+// LogoutEverywhere завершает все сессии пользователя и отзывает уже выданные access-токены
+func (s *PokerService) LogoutEverywhere(ctx context.Context, userID model.UserID) error {
+	err := s.repository.RevokeAllUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.repository.InvalidateUserTokens(ctx, userID)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/grading"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"time"
+)
+
+// validateExerciseCode ограничивает длину эталона: ответ сравнивается с ним при каждой попытке
+func validateExerciseCode(code string) error {
+	if len(code) > model.MaxExerciseCodeLength {
+		return fmt.Errorf("%w: code_to_remember is longer than %d bytes", model.ErrInvalidParameter, model.MaxExerciseCodeLength)
+	}
+	return nil
+}
+
+// SubmitAttempt проверяет ответ пользователя на сервере и сам обновляет статистику.
+// Упражнения архивных категорий не принимаются: архив скрыт из практики, как и в GetDueExercises.
+func (s *PokerService) SubmitAttempt(ctx context.Context, userID model.UserID, submission *model.AttemptSubmission) (*model.AttemptResult, error) {
+	exerciseID := submission.ExerciseID
+	code := submission.Code
+
+	// Ответ сравнивается с текущей ревизией, её номер сохраняется вместе с попыткой
+	exercise, err := s.repository.GetExerciseRevision(ctx, userID, exerciseID, 0)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.repository.IsExerciseArchived(ctx, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return nil, fmt.Errorf("%w: exercise category is archived", model.ErrInvalidParameter)
+	}
+
+	if len(code) > len(exercise.CodeToRemember)*model.MaxAnswerLengthRatio+model.MaxAnswerLengthSlack {
+		return nil, fmt.Errorf("%w: answer is much longer than the exercise code", model.ErrInvalidParameter)
+	}
+
+	lineDiffs := grading.Compare(exercise.ProgrammingLanguage, exercise.ComparisonMode, exercise.CodeToRemember, code)
+	isCorrect := len(lineDiffs) == 0
+	score := grading.Score(exercise.ProgrammingLanguage, exercise.ComparisonMode, exercise.CodeToRemember, code)
+
+	successAttempts := 0
+	if isCorrect {
+		successAttempts = 1
+	}
+
+	// Статистика, история попыток, прогресс и расписание повторений меняются вместе
+	var (
+		stat   *model.ExerciseStat
+		review *model.ExerciseReview
+	)
+	err = s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		var err error
+		stat, err = adapters.Repository.UpsertExerciseStat(ctx, userID, exerciseID, 1, successAttempts, submission.TypingTime, submission.TypedChars, score)
+		if err != nil {
+			return err
+		}
+
+		_, err = adapters.Repository.CreateExerciseAttempt(ctx, &model.ExerciseAttempt{
+			UserID:     userID,
+			ExerciseID: exerciseID,
+			IsCorrect:  isCorrect,
+			Score:      score,
+			TypingTime: submission.TypingTime,
+			TypedChars: submission.TypedChars,
+			CodeHash:   hashCode(code),
+			Revision:   &exercise.Revision,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := adapters.Repository.UpdateUserExerciseProgress(ctx, userID, exerciseID, isCorrect, score); err != nil {
+			return err
+		}
+
+		review, err = s.scheduleReview(ctx, adapters.Repository, userID, exerciseID, reviewQuality(isCorrect, score), time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AttemptResult{
+		ExerciseID: exerciseID,
+		IsCorrect:  isCorrect,
+		Score:      score,
+		Revision:   exercise.Revision,
+		Errors:     lineDiffs,
+		Stat:       stat,
+		Review:     review,
+	}, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	"strings"
+	"unicode/utf8"
+)
+
+func (s *PokerService) GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error) {
+	return s.repository.GetTags(ctx, userID)
+}
+
+// CreateTag создаёт личную метку пользователя или общую, если у него есть право на общий контент
+func (s *PokerService) CreateTag(ctx context.Context, userID model.UserID, roles model.Roles, tag *model.Tag) (*model.Tag, error) {
+	name, err := normalizeTagName(tag.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID := userID
+	if tag.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
+		}
+		ownerID = 0
+	}
+	return s.repository.CreateTag(ctx, ownerID, name)
+}
+
+func (s *PokerService) RenameTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64, name string) (*model.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := s.editableTag(ctx, userID, roles, tagID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.RenameTag(ctx, tagID, name); err != nil {
+		return nil, err
+	}
+	tag.Name = name
+	return tag, nil
+}
+
+func (s *PokerService) DeleteTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64) error {
+	if _, err := s.editableTag(ctx, userID, roles, tagID); err != nil {
+		return err
+	}
+	return s.repository.DeleteTag(ctx, tagID)
+}
+
+// MergeTags переносит упражнения с одной метки на другую и удаляет исходную.
+// Сливать можно только метки одного владельца: личные с личными, общие с общими.
+func (s *PokerService) MergeTags(ctx context.Context, userID model.UserID, roles model.Roles, merge *model.TagMerge) (*model.Tag, error) {
+	if merge.SourceID == merge.TargetID {
+		return nil, fmt.Errorf("%w: cannot merge a tag into itself", model.ErrInvalidParameter)
+	}
+	source, err := s.editableTag(ctx, userID, roles, merge.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.editableTag(ctx, userID, roles, merge.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if source.UserID != target.UserID {
+		return nil, fmt.Errorf("%w: personal and common tags cannot be merged", model.ErrInvalidParameter)
+	}
+	if err := s.repository.MergeTags(ctx, source.ID, target.ID); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func (s *PokerService) AddExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error {
+	if err := s.checkExerciseTag(ctx, userID, roles, exerciseID, tagID); err != nil {
+		return err
+	}
+	return s.repository.AddExerciseTag(ctx, exerciseID, tagID)
+}
+
+func (s *PokerService) RemoveExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error {
+	if err := s.checkExerciseTag(ctx, userID, roles, exerciseID, tagID); err != nil {
+		return err
+	}
+	return s.repository.RemoveExerciseTag(ctx, exerciseID, tagID)
+}
+
+// editableTag возвращает метку, которую пользователь может менять: свою или общую при наличии права
+func (s *PokerService) editableTag(ctx context.Context, userID model.UserID, roles model.Roles, tagID int64) (*model.Tag, error) {
+	tag, err := s.repository.GetTag(ctx, userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.IsCommon {
+		if err := requirePermission(roles, model.PermissionManageCommonContent); err != nil {
+			return nil, err
+		}
+	}
+	return tag, nil
+}
+
+// checkExerciseTag проверяет, что пользователь видит упражнение и метку.
+// Общие метки на общих упражнениях видны всем, поэтому их расставляют только редакторы контента;
+// личные метки пользователь может ставить на любые доступные ему упражнения.
+func (s *PokerService) checkExerciseTag(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64, tagID int64) error {
+	exercise, err := s.repository.GetExercise(ctx, userID, exerciseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: exercise %d", model.ErrorNotFound, exerciseID)
+		}
+		return err
+	}
+	tag, err := s.repository.GetTag(ctx, userID, tagID)
+	if err != nil {
+		return err
+	}
+	if tag.IsCommon && exercise.IsCommon {
+		return requirePermission(roles, model.PermissionManageCommonContent)
+	}
+	return nil
+}
+
+// attachTags заполняет метки упражнений одним запросом на весь список
+func (s *PokerService) attachTags(ctx context.Context, userID model.UserID, list []*model.ExerciseDetailse) error {
+	ids := make([]int64, 0, len(list))
+	for _, item := range list {
+		ids = append(ids, item.Exercise.ID)
+	}
+	tags, err := s.repository.GetExercisesTags(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	for _, item := range list {
+		item.Exercise.Tags = tags[item.Exercise.ID]
+	}
+	return nil
+}
+
+func normalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: tag name is required", model.ErrInvalidParameter)
+	}
+	if utf8.RuneCountInString(name) > model.MaxTagNameLength {
+		return "", fmt.Errorf("%w: tag name is longer than %d characters", model.ErrInvalidParameter, model.MaxTagNameLength)
+	}
+	return name, nil
+}
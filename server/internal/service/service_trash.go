@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"inzarubin80/MemCode/internal/model"
+	stor "inzarubin80/MemCode/internal/storage"
+	"time"
+)
+
+// GetTrash возвращает корзину пользователя; модераторы видят в ней и удалённый общий контент
+func (s *PokerService) GetTrash(ctx context.Context, userID model.UserID, roles model.Roles) ([]*model.TrashItem, error) {
+	return s.repository.GetTrash(ctx, userID, roles.Can(model.PermissionModerateContent))
+}
+
+// RestoreExercise возвращает упражнение из корзины
+func (s *PokerService) RestoreExercise(ctx context.Context, userID model.UserID, roles model.Roles, exerciseID int64) error {
+	return s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		return adapters.Repository.RestoreExercise(ctx, userID, roles.Can(model.PermissionModerateContent), exerciseID)
+	})
+}
+
+// RestoreCategory возвращает категорию из корзины вместе с удалёнными с ней подкатегориями и упражнениями
+func (s *PokerService) RestoreCategory(ctx context.Context, userID model.UserID, roles model.Roles, categoryID int64) error {
+	return s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		if err := adapters.Repository.LockCategoryTree(ctx); err != nil {
+			return err
+		}
+		return adapters.Repository.RestoreCategory(ctx, userID, roles.Can(model.PermissionModerateContent), categoryID)
+	})
+}
+
+// PurgeTrash окончательно удаляет всё, что пролежало в корзине дольше retention
+func (s *PokerService) PurgeTrash(ctx context.Context, retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("%w: trash retention must be positive", model.ErrInvalidParameter)
+	}
+	before := time.Now().Add(-retention)
+	return s.transactionProvider.Transact(ctx, func(adapters stor.Adapters) error {
+		exercises, categories, err := adapters.Repository.PurgeTrash(ctx, before)
+		if err != nil {
+			return err
+		}
+		if exercises > 0 || categories > 0 {
+			fmt.Printf("trash purged: %d exercises, %d categories\n", exercises, categories)
+		}
+		return nil
+	})
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"inzarubin80/MemCode/internal/model"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -23,6 +24,56 @@ type Repository interface {
 	CreateUser(ctx context.Context, userData *model.UserProfileFromProvider) (*model.User, error)
 	SetUserName(ctx context.Context, userID model.UserID, name string) error
 	GetUser(ctx context.Context, userID model.UserID) (*model.User, error)
+	CreateLocalCredentials(ctx context.Context, credentials *model.LocalCredentials) error
+	DeleteUserAuthProvider(ctx context.Context, userID model.UserID, provider string) error
+	DeleteLocalCredentials(ctx context.Context, userID model.UserID) error
+	InvalidateUserTokens(ctx context.Context, userID model.UserID) error
+	RevokeAllUserRefreshTokens(ctx context.Context, userID model.UserID) error
+
+	//Role
+	GrantUserRole(ctx context.Context, userID model.UserID, role model.Role, grantedBy model.UserID) error
+	RevokeUserRole(ctx context.Context, userID model.UserID, role model.Role) error
+	LockUsersWithRole(ctx context.Context, role model.Role) ([]model.UserID, error)
+
+	//Exercise
+	CreateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exercise *model.Exercise) (*model.Exercise, error)
+	UpdateExercise(ctx context.Context, userID model.UserID, isAdmin bool, exerciseID int64, exercise *model.Exercise) (*model.Exercise, error)
+	GetOwnedExercises(ctx context.Context, userID model.UserID, afterID int64, limit int, withStats bool) ([]*model.BundleExercise, error)
+	RestoreExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, stat *model.BundleExerciseStat) error
+
+	//Attempt
+	UpsertExerciseStat(ctx context.Context, userID model.UserID, exerciseID int64, attempts int, successful int, typingTime int64, typedChars int, score int) (*model.ExerciseStat, error)
+	CreateExerciseAttempt(ctx context.Context, attempt *model.ExerciseAttempt) (*model.ExerciseAttempt, error)
+	UpdateUserExerciseProgress(ctx context.Context, userID model.UserID, exerciseID int64, isCorrect bool, score int) error
+	GetExerciseReview(ctx context.Context, userID model.UserID, exerciseID int64) (*model.ExerciseReview, error)
+	UpsertExerciseReview(ctx context.Context, review *model.ExerciseReview) error
+
+	//Tag
+	GetTags(ctx context.Context, userID model.UserID) ([]*model.TagWithCount, error)
+	CreateTag(ctx context.Context, ownerID model.UserID, name string) (*model.Tag, error)
+	AddExerciseTag(ctx context.Context, exerciseID int64, tagID int64) error
+
+	//Category
+	CreateCategory(ctx context.Context, userID model.UserID, isAdmin bool, category *model.Category) (*model.Category, error)
+	UpdateCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64, category *model.Category) (*model.Category, error)
+	DeleteCategory(ctx context.Context, userID model.UserID, isAdmin bool, categoryID int64) error
+	LockCategoryTree(ctx context.Context) error
+	SetCategoryParent(ctx context.Context, categoryID int64, parentID *int64) error
+	CountChildCategories(ctx context.Context, categoryID int64) (int, error)
+	DeleteCategoryDescendants(ctx context.Context, categoryID int64, deletedBy model.UserID) error
+	ReparentChildCategories(ctx context.Context, categoryID int64) error
+	CountForeignExercises(ctx context.Context, categoryID int64, withDescendants bool) (int, error)
+	GetOwnedCategories(ctx context.Context, userID model.UserID) ([]*model.BundleCategory, error)
+	GetCommonCategories(ctx context.Context) ([]*model.BundleCategory, error)
+
+	//Trash
+	RestoreExercise(ctx context.Context, userID model.UserID, includeCommon bool, exerciseID int64) error
+	RestoreCategory(ctx context.Context, userID model.UserID, includeCommon bool, categoryID int64) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, int64, error)
+
+	//Seed
+	UpsertCommonCategory(ctx context.Context, category *model.Category) (int64, bool, error)
+	UpsertCommonExercise(ctx context.Context, exercise *model.Exercise) (int64, bool, error)
 }
 
 type Adapters struct {
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE exercise_reviews (
+    user_id BIGINT NOT NULL,
+    exercise_id BIGINT NOT NULL,
+    ease_factor DOUBLE PRECISION NOT NULL DEFAULT 2.5,
+    interval_days INT NOT NULL DEFAULT 0,
+    repetitions INT NOT NULL DEFAULT 0,
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    -- Составной первичный ключ
+    PRIMARY KEY (user_id, exercise_id)
+);
+
+-- Индекс для выборки упражнений к повторению
+CREATE INDEX idx_exercise_reviews_user_due_at ON exercise_reviews(user_id, due_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exercise_reviews;
+-- +goose StatementEnd
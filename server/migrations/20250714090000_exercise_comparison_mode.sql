@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE exercises
+    ADD COLUMN comparison_mode VARCHAR(20) NOT NULL DEFAULT 'ignore_whitespace';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE exercises DROP COLUMN IF EXISTS comparison_mode;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE exercise_stats
+    ADD COLUMN total_score BIGINT NOT NULL DEFAULT 0, -- сумма оценок всех попыток (0-100 за попытку)
+    ADD COLUMN best_score INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_score INT NOT NULL DEFAULT 0;
+
+-- Старые попытки оценивались только как успешные или нет
+UPDATE exercise_stats SET
+    total_score = successful_attempts * 100,
+    best_score = CASE WHEN successful_attempts > 0 THEN 100 ELSE 0 END,
+    last_score = CASE WHEN successful_attempts > 0 THEN 100 ELSE 0 END;
+
+-- +goose Down
+ALTER TABLE exercise_stats
+    DROP COLUMN IF EXISTS total_score,
+    DROP COLUMN IF EXISTS best_score,
+    DROP COLUMN IF EXISTS last_score;
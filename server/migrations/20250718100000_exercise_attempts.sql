@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE exercise_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    exercise_id BIGINT NOT NULL,
+    is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+    score INT NOT NULL DEFAULT 0, -- оценка попытки 0-100
+    typing_time BIGINT NOT NULL DEFAULT 0, -- в секундах
+    typed_chars INT NOT NULL DEFAULT 0,
+    code_hash VARCHAR(64) NOT NULL DEFAULT '', -- sha256 присланного кода
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для истории попыток пользователя по задаче
+CREATE INDEX idx_exercise_attempts_user_exercise_created ON exercise_attempts(user_id, exercise_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exercise_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Часовой пояс пользователя, по нему считаются дни активности и серии
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
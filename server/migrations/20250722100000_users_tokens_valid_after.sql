@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Токены, выпущенные раньше этого момента, считаются отозванными (например, после смены роли)
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Полный доступ, включая управление пользователями и ролями'),
+    ('content_editor', 'Создание и редактирование общих задач и категорий'),
+    ('moderator', 'Просмотр пользователей и удаление общих задач и категорий');
+
+CREATE TABLE user_roles (
+    user_id BIGINT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    granted_by BIGINT,
+    granted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+-- Существующие администраторы получают роль admin, флаг is_admin остаётся для совместимости
+INSERT INTO user_roles (user_id, role)
+SELECT user_id, 'admin' FROM users WHERE is_admin = TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
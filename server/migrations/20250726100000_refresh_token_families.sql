@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Все токены, полученные последовательной ротацией от одного входа, составляют семейство
+ALTER TABLE refresh_tokens ADD COLUMN family_id VARCHAR(64);
+ALTER TABLE refresh_tokens ADD COLUMN parent_id BIGINT;
+UPDATE refresh_tokens SET family_id = 'legacy-' || id;
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+CREATE TABLE security_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_security_events_user_created ON security_events (user_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS security_events;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS family_id;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Учётные данные для входа по email и паролю; сама привязка хранится в user_auth_providers с provider = 'local'
+CREATE TABLE local_credentials (
+    user_id BIGINT PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    email_verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Одноразовые токены подтверждения email и сброса пароля; хранится только хеш токена
+CREATE TABLE auth_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    purpose VARCHAR(30) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_auth_tokens_user_purpose ON auth_tokens (user_id, purpose);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS auth_tokens;
+DROP TABLE IF EXISTS local_credentials;
+-- +goose StatementEnd
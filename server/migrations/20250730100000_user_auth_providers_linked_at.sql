@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- К одному пользователю можно привязать несколько провайдеров, по одному аккаунту каждого
+ALTER TABLE user_auth_providers ADD COLUMN linked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+CREATE UNIQUE INDEX idx_user_auth_providers_user_provider ON user_auth_providers (user_id, provider);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_auth_providers_user_provider;
+ALTER TABLE user_auth_providers DROP COLUMN IF EXISTS linked_at;
+-- +goose StatementEnd
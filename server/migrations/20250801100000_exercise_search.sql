@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поисковый вектор: название важнее описания, описание важнее кода.
+-- Название и описание разбираются конфигурацией russian (английские слова в ней тоже приводятся к основе),
+-- код - simple, при этом знаки препинания заменяются пробелами, чтобы sync.Mutex дал слова sync и mutex.
+ALTER TABLE exercises ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(COALESCE(code_to_remember, ''), '[^[:alnum:]_]+', ' ', 'g')), 'C')
+) STORED;
+
+CREATE INDEX idx_exercises_search_vector ON exercises USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exercises_search_vector;
+ALTER TABLE exercises DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Метки упражнений; user_id = 0 у общих меток, которые ведут редакторы контента
+CREATE TABLE tags (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_tags_user_name ON tags (user_id, lower(name));
+
+CREATE TABLE exercise_tags (
+    exercise_id BIGINT NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (exercise_id, tag_id)
+);
+CREATE INDEX idx_exercise_tags_tag_id ON exercise_tags (tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exercise_tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
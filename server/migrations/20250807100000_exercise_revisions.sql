@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Снимки содержимого упражнения: новая ревизия пишется при каждом изменении текста или кода.
+-- exercises.revision - номер текущей ревизии.
+ALTER TABLE exercises ADD COLUMN revision INT NOT NULL DEFAULT 0;
+
+CREATE TABLE exercise_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    exercise_id BIGINT NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    programming_language VARCHAR(50) NOT NULL,
+    code_to_remember TEXT NOT NULL,
+    comparison_mode VARCHAR(20) NOT NULL,
+    edited_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (exercise_id, revision)
+);
+
+-- Текущее содержимое существующих упражнений становится первой ревизией
+INSERT INTO exercise_revisions (exercise_id, revision, title, description, programming_language, code_to_remember, comparison_mode, edited_by, created_at)
+SELECT id, 1, title, COALESCE(description, ''), programming_language, code_to_remember, comparison_mode, user_id, COALESCE(updated_at, created_at, NOW())
+FROM exercises;
+UPDATE exercises SET revision = 1;
+
+-- Ревизия, с которой сравнивался ответ; пусто у попыток до появления ревизий
+ALTER TABLE exercise_attempts ADD COLUMN revision INT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE exercise_attempts DROP COLUMN IF EXISTS revision;
+DROP TABLE IF EXISTS exercise_revisions;
+ALTER TABLE exercises DROP COLUMN IF EXISTS revision;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Корзина: когда и кем удалена запись. deleted_with_category_id - категория, вместе с которой
+-- запись попала в корзину; такие записи восстанавливаются и удаляются навсегда вместе с ней.
+ALTER TABLE exercises
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_by BIGINT,
+    ADD COLUMN deleted_with_category_id BIGINT;
+ALTER TABLE categories
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_by BIGINT,
+    ADD COLUMN deleted_with_category_id BIGINT;
+
+-- Время удаления уже удалённых записей неизвестно. Считаем их удалёнными сейчас,
+-- чтобы до окончательного удаления у владельцев был весь срок хранения корзины.
+UPDATE categories SET deleted_at = NOW(), deleted_by = user_id WHERE is_active = FALSE;
+
+-- Упражнения удалённых категорий оставались активными; упражнения владельца категории переносим
+-- в корзину вместе с ней. Чужие упражнения не трогаем: в корзину владельца категории они попасть не должны.
+UPDATE exercises e SET is_active = FALSE, deleted_at = c.deleted_at, deleted_by = c.deleted_by, deleted_with_category_id = c.id
+FROM categories c
+WHERE c.id = e.category_id AND c.is_active = FALSE AND e.is_active = TRUE AND e.user_id = c.user_id;
+
+UPDATE exercises SET deleted_at = NOW(), deleted_by = user_id WHERE is_active = FALSE AND deleted_at IS NULL;
+
+CREATE INDEX idx_exercises_deleted_at ON exercises (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_categories_deleted_at ON categories (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_categories_deleted_at;
+DROP INDEX IF EXISTS idx_exercises_deleted_at;
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS deleted_with_category_id,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE exercises
+    DROP COLUMN IF EXISTS deleted_with_category_id,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Нечёткий поиск сравнивает запрос с названием и кодом операторами pg_trgm (<%, word_similarity);
+-- без триграммных индексов каждое такое сравнение - полный просмотр таблицы.
+CREATE INDEX idx_exercises_title_trgm ON exercises USING GIN (title gin_trgm_ops);
+CREATE INDEX idx_exercises_code_to_remember_trgm ON exercises USING GIN (code_to_remember gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exercises_code_to_remember_trgm;
+DROP INDEX IF EXISTS idx_exercises_title_trgm;
+-- +goose StatementEnd